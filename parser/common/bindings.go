@@ -0,0 +1,131 @@
+// Copyright (c) 2019 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package common
+
+import (
+	"fmt"
+
+	"github.com/hydralang/hydra/utils"
+)
+
+// Operator fixities.  These flags describe the positions in which an
+// operator may be used; an operator may have more than one fixity,
+// e.g., "-" is usually both a prefix and an infix operator.
+const (
+	FixPrefix  uint8 = 1 << iota // Prefix (unary) operator
+	FixInfix                     // Infix (binary) operator
+	FixPostfix                   // Postfix (unary) operator
+)
+
+// FixityFlags is a mapping of fixity flags to names.
+var FixityFlags = utils.FlagSet8{
+	FixPrefix:  "prefix",
+	FixInfix:   "infix",
+	FixPostfix: "postfix",
+}
+
+// Assoc describes the associativity of an infix operator.
+type Assoc uint8
+
+// Defined associativities.
+const (
+	AssocNone  Assoc = iota // Non-associative; may not be chained
+	AssocLeft               // Left-associative
+	AssocRight              // Right-associative
+)
+
+// assocNames is a mapping of associativities to names.
+var assocNames = map[Assoc]string{
+	AssocNone:  "none",
+	AssocLeft:  "left",
+	AssocRight: "right",
+}
+
+// String constructs a string representation of an associativity.
+func (a Assoc) String() string {
+	if name, ok := assocNames[a]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("assoc %d", uint8(a))
+}
+
+// Binding describes how an operator binds to its operands.  Binding
+// powers are positive integers; an operator with a higher binding
+// power binds more tightly.  Only the powers for the fixities the
+// operator has are meaningful.
+type Binding struct {
+	Fixity  uint8 // The fixities of the operator
+	Prefix  int   // Binding power when used as a prefix operator
+	Infix   int   // Binding power when used as an infix operator
+	Postfix int   // Binding power when used as a postfix operator
+	Assoc   Assoc // Associativity when used as an infix operator
+}
+
+// InfixPowers returns the left and right binding powers of an infix
+// operator, for use by a Pratt parser: the operator is only applied
+// if its left binding power exceeds the binding power of the
+// enclosing context, and its right operand is parsed with the right
+// binding power.  Left-associative and non-associative operators bind
+// more tightly to the right; right-associative operators bind more
+// tightly to the left.
+func (b Binding) InfixPowers() (int, int) {
+	if b.Assoc == AssocRight {
+		return b.Infix*2 + 1, b.Infix * 2
+	}
+
+	return b.Infix * 2, b.Infix*2 + 1
+}
+
+// validate checks that a binding is consistent.
+func (b Binding) validate() error {
+	if b.Fixity == 0 || b.Fixity&^(FixPrefix|FixInfix|FixPostfix) != 0 {
+		return fmt.Errorf("%w: bad fixity %d", ErrBadBinding, b.Fixity)
+	}
+
+	// Check the binding powers
+	if b.Fixity&FixPrefix != 0 && b.Prefix <= 0 {
+		return fmt.Errorf("%w: prefix binding power must be positive", ErrBadBinding)
+	}
+	if b.Fixity&FixInfix != 0 && b.Infix <= 0 {
+		return fmt.Errorf("%w: infix binding power must be positive", ErrBadBinding)
+	}
+	if b.Fixity&FixPostfix != 0 && b.Postfix <= 0 {
+		return fmt.Errorf("%w: postfix binding power must be positive", ErrBadBinding)
+	}
+
+	// Associativity only applies to infix operators
+	if b.Fixity&FixInfix == 0 && b.Assoc != AssocNone {
+		return fmt.Errorf("%w: associativity %s for non-infix operator", ErrBadBinding, b.Assoc)
+	} else if _, ok := assocNames[b.Assoc]; !ok {
+		return fmt.Errorf("%w: bad associativity %d", ErrBadBinding, uint8(b.Assoc))
+	}
+
+	return nil
+}
+
+// Bindings is a map mapping operator symbols to their bindings.  It
+// forms the precedence table for the parser.
+type Bindings map[*Symbol]Binding
+
+// Copy produces a new copy of a Bindings object.
+func (b Bindings) Copy() Bindings {
+	new := Bindings{}
+	for sym, binding := range b {
+		new[sym] = binding
+	}
+
+	return new
+}
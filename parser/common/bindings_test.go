@@ -0,0 +1,159 @@
+// Copyright (c) 2019 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package common
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hydralang/hydra/testutils"
+)
+
+func TestAssocString(t *testing.T) {
+	a := assert.New(t)
+
+	result := AssocRight.String()
+
+	a.Equal("right", result)
+}
+
+func TestAssocStringUnknown(t *testing.T) {
+	a := assert.New(t)
+
+	result := Assoc(42).String()
+
+	a.Equal("assoc 42", result)
+}
+
+func TestBindingInfixPowersLeft(t *testing.T) {
+	a := assert.New(t)
+	b := Binding{Fixity: FixInfix, Infix: 3, Assoc: AssocLeft}
+
+	left, right := b.InfixPowers()
+
+	a.Equal(6, left)
+	a.Equal(7, right)
+}
+
+func TestBindingInfixPowersRight(t *testing.T) {
+	a := assert.New(t)
+	b := Binding{Fixity: FixInfix, Infix: 3, Assoc: AssocRight}
+
+	left, right := b.InfixPowers()
+
+	a.Equal(7, left)
+	a.Equal(6, right)
+}
+
+func TestBindingInfixPowersNone(t *testing.T) {
+	a := assert.New(t)
+	b := Binding{Fixity: FixInfix, Infix: 3}
+
+	left, right := b.InfixPowers()
+
+	a.Equal(6, left)
+	a.Equal(7, right)
+}
+
+func TestBindingValidate(t *testing.T) {
+	a := assert.New(t)
+	b := Binding{
+		Fixity: FixPrefix | FixInfix,
+		Prefix: 10,
+		Infix:  5,
+		Assoc:  AssocLeft,
+	}
+
+	err := b.validate()
+
+	a.NoError(err)
+}
+
+func TestBindingValidateNoFixity(t *testing.T) {
+	a := assert.New(t)
+	b := Binding{}
+
+	err := b.validate()
+
+	a.True(errors.Is(err, ErrBadBinding))
+	a.EqualError(err, "bad operator binding: bad fixity 0")
+}
+
+func TestBindingValidateBadFixity(t *testing.T) {
+	a := assert.New(t)
+	b := Binding{Fixity: 0x80}
+
+	err := b.validate()
+
+	a.EqualError(err, "bad operator binding: bad fixity 128")
+}
+
+func TestBindingValidatePrefixPower(t *testing.T) {
+	a := assert.New(t)
+	b := Binding{Fixity: FixPrefix}
+
+	err := b.validate()
+
+	a.EqualError(err, "bad operator binding: prefix binding power must be positive")
+}
+
+func TestBindingValidateInfixPower(t *testing.T) {
+	a := assert.New(t)
+	b := Binding{Fixity: FixInfix, Infix: -1}
+
+	err := b.validate()
+
+	a.EqualError(err, "bad operator binding: infix binding power must be positive")
+}
+
+func TestBindingValidatePostfixPower(t *testing.T) {
+	a := assert.New(t)
+	b := Binding{Fixity: FixPostfix}
+
+	err := b.validate()
+
+	a.EqualError(err, "bad operator binding: postfix binding power must be positive")
+}
+
+func TestBindingValidateAssocNotInfix(t *testing.T) {
+	a := assert.New(t)
+	b := Binding{Fixity: FixPrefix, Prefix: 1, Assoc: AssocRight}
+
+	err := b.validate()
+
+	a.EqualError(err, "bad operator binding: associativity right for non-infix operator")
+}
+
+func TestBindingValidateBadAssoc(t *testing.T) {
+	a := assert.New(t)
+	b := Binding{Fixity: FixInfix, Infix: 1, Assoc: 42}
+
+	err := b.validate()
+
+	a.EqualError(err, "bad operator binding: bad associativity 42")
+}
+
+func TestBindingsCopy(t *testing.T) {
+	a := assert.New(t)
+	sym := &Symbol{Name: "+"}
+	b := Bindings{sym: {Fixity: FixInfix, Infix: 1}}
+
+	result := b.Copy()
+
+	a.Equal(b, result)
+	testutils.AssertPtrNotEqual(a, b, result)
+}
@@ -91,12 +91,29 @@ var digits = map[rune]digitData{
 
 // AugChar is a struct that packages together a character, its class,
 // its location, and any numeric value it may have.  This is the type
-// that the scanner returns.
+// that the scanner returns.  If the character was translated from
+// different source text, such as a carriage return-newline pair
+// translated into a single newline, the original text is available
+// in Raw.
 type AugChar struct {
 	C     rune        // The character
 	Class uint16      // The character's class
 	Loc   Location    // The character's location
 	Val   interface{} // The "value"; an integer for digits
+	Raw   string      // The source text, if different from C
+}
+
+// Text returns the source text of the character.  This is Raw, if
+// set; otherwise, it is the character itself.  The EOF and Err
+// characters have no source text.
+func (ch AugChar) Text() string {
+	if ch.Raw != "" {
+		return ch.Raw
+	} else if ch.C == EOF || ch.C == Err {
+		return ""
+	}
+
+	return string(ch.C)
 }
 
 // Classify classifies a character and composes an AugChar describing
@@ -107,7 +124,7 @@ func (opts *Options) Classify(ch rune, loc Location, err error) AugChar {
 
 	// Handle the special characters
 	if ch == EOF || ch == Err {
-		return AugChar{ch, 0, loc, err}
+		return AugChar{C: ch, Loc: loc, Val: err}
 	}
 
 	// Start off with whitespace and newline
@@ -118,7 +135,7 @@ func (opts *Options) Classify(ch rune, loc Location, err error) AugChar {
 		}
 
 		// Space is exclusive with everything else
-		return AugChar{ch, class, loc, val}
+		return AugChar{C: ch, Class: class, Loc: loc, Val: val}
 	}
 
 	// See if it's a digit
@@ -148,7 +165,7 @@ func (opts *Options) Classify(ch rune, loc Location, err error) AugChar {
 		class |= CharComment
 	}
 
-	return AugChar{ch, class, loc, val}
+	return AugChar{C: ch, Class: class, Loc: loc, Val: val}
 }
 
 // Advance advances the location to account for the specified
@@ -361,3 +361,30 @@ func TestOptionsAdvanceOther(t *testing.T) {
 		E:    FilePos{L: 3, C: 4},
 	}, loc)
 }
+
+func TestAugCharText(t *testing.T) {
+	a := assert.New(t)
+	ch := AugChar{C: 'a'}
+
+	result := ch.Text()
+
+	a.Equal("a", result)
+}
+
+func TestAugCharTextRaw(t *testing.T) {
+	a := assert.New(t)
+	ch := AugChar{C: '\n', Raw: "\r\n"}
+
+	result := ch.Text()
+
+	a.Equal("\r\n", result)
+}
+
+func TestAugCharTextEOF(t *testing.T) {
+	a := assert.New(t)
+	ch := AugChar{C: EOF}
+
+	result := ch.Text()
+
+	a.Equal("", result)
+}
@@ -0,0 +1,260 @@
+// Copyright (c) 2019 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package common
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Severity describes the severity of a diagnostic.
+type Severity uint8
+
+// Defined severities.
+const (
+	SevError   Severity = iota // An error; processing cannot continue
+	SevWarning                 // A warning; something is questionable
+	SevNote                    // A note; purely informational
+)
+
+// severityNames is a mapping of severities to names.
+var severityNames = map[Severity]string{
+	SevError:   "error",
+	SevWarning: "warning",
+	SevNote:    "note",
+}
+
+// String constructs a string representation of a severity.
+func (s Severity) String() string {
+	if name, ok := severityNames[s]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("severity %d", uint8(s))
+}
+
+// Diagnostic codes.  Codes are stable and may be used to filter,
+// suppress, or document diagnostics.  Codes beginning with "H00" are
+// general; codes beginning with "H01" are errors produced by the
+// lexer; and codes beginning with "H02" are warnings produced by the
+// lexer.
+const (
+	CodeUnknown           = "H0000" // Error with no assigned code
+	CodeSplitEntity       = "H0001" // ErrSplitEntity
+	CodeBadEdit           = "H0002" // ErrBadEdit
+	CodeOverlappingEdits  = "H0003" // ErrOverlappingEdits
+	CodeUnknownSymbol     = "H0004" // ErrUnknownSymbol
+	CodeBadTokenValue     = "H0005" // ErrBadTokenValue
+	CodeSymbolKind        = "H0006" // ErrSymbolKind
+	CodeBadBinding        = "H0007" // ErrBadBinding
+	CodeBadOpName         = "H0008" // ErrBadOpName
+	CodeDuplicateOp       = "H0009" // ErrDuplicateOp
+	CodeOpConflict        = "H0010" // ErrOpConflict
+	CodeNoSuchOp          = "H0011" // ErrNoSuchOp
+	CodeFrozen            = "H0012" // ErrFrozen
+	CodeStatePos          = "H0013" // ErrStatePos
+	CodeBadRecog          = "H0014" // ErrBadRecog
+	CodeDuplicateRecog    = "H0015" // ErrDuplicateRecog
+	CodeNoSuchRecog       = "H0016" // ErrNoSuchRecog
+	CodeNoMode            = "H0017" // ErrNoMode
+	CodeBadRune           = "H0101" // ErrBadRune
+	CodeBadIndent         = "H0102" // ErrBadIndent
+	CodeBadOp             = "H0103" // ErrBadOp
+	CodeMixedIndent       = "H0104" // ErrMixedIndent
+	CodeDanglingBackslash = "H0105" // ErrDanglingBackslash
+	CodeBadNumber         = "H0106" // ErrBadNumber
+	CodeBadEscape         = "H0107" // ErrBadEscape
+	CodeBadStrChar        = "H0108" // ErrBadStrChar
+	CodeUnclosedStr       = "H0109" // ErrUnclosedStr
+	CodeBadIdent          = "H0110" // ErrBadIdent
+	CodeUnclosedOp        = "H0111" // ErrUnclosedOp
+	CodeUnopenedOp        = "H0112" // ErrUnopenedOp
+	CodeMismatchedOp      = "H0113" // ErrMismatchedOp
+	CodeNonASCIIOp        = "H0114" // ErrNonASCIIOp
+	CodeUnclosedMode      = "H0115" // ErrUnclosedMode
+	CodeFStringBrace      = "H0116" // ErrFStringBrace
+	CodeFStringBytes      = "H0117" // ErrFStringBytes
+	CodeFStringField      = "H0118" // ErrFStringField
+	CodeAmbiguousIndent   = "H0119" // ErrAmbiguousIndent
+	CodeTabIndent         = "H0120" // ErrTabIndent
+	CodeSpaceIndent       = "H0121" // ErrSpaceIndent
+	CodeBackslashCont     = "H0122" // ErrBackslashCont
+	CodeTrailingWS        = "H0201" // WarnTrailingWS
+	CodeFormFeed          = "H0202" // WarnFormFeed
+	CodeTabAfterSpace     = "H0203" // WarnTabAfterSpace
+	CodeBackslash         = "H0204" // WarnBackslash
+	CodeOctalEscape       = "H0205" // WarnOctalEscape
+	CodeNonASCIIOpWarn    = "H0206" // WarnNonASCIIOp
+	CodeBackslashWS       = "H0207" // WarnBackslashWS
+)
+
+// codeEntry associates a sentinel error with a diagnostic code.
+type codeEntry struct {
+	err  error  // The sentinel error
+	code string // The diagnostic code
+}
+
+// codes is a list of sentinel errors and their diagnostic codes.
+var codes = []codeEntry{
+	{ErrSplitEntity, CodeSplitEntity},
+	{ErrBadEdit, CodeBadEdit},
+	{ErrOverlappingEdits, CodeOverlappingEdits},
+	{ErrUnknownSymbol, CodeUnknownSymbol},
+	{ErrBadTokenValue, CodeBadTokenValue},
+	{ErrSymbolKind, CodeSymbolKind},
+	{ErrBadBinding, CodeBadBinding},
+	{ErrBadOpName, CodeBadOpName},
+	{ErrDuplicateOp, CodeDuplicateOp},
+	{ErrOpConflict, CodeOpConflict},
+	{ErrNoSuchOp, CodeNoSuchOp},
+	{ErrFrozen, CodeFrozen},
+	{ErrStatePos, CodeStatePos},
+	{ErrBadRecog, CodeBadRecog},
+	{ErrDuplicateRecog, CodeDuplicateRecog},
+	{ErrNoSuchRecog, CodeNoSuchRecog},
+	{ErrNoMode, CodeNoMode},
+	{ErrBadRune, CodeBadRune},
+	{ErrBadIndent, CodeBadIndent},
+	{ErrBadOp, CodeBadOp},
+	{ErrMixedIndent, CodeMixedIndent},
+	{ErrDanglingBackslash, CodeDanglingBackslash},
+	{ErrBadNumber, CodeBadNumber},
+	{ErrBadEscape, CodeBadEscape},
+	{ErrBadStrChar, CodeBadStrChar},
+	{ErrUnclosedStr, CodeUnclosedStr},
+	{ErrBadIdent, CodeBadIdent},
+	{ErrUnclosedOp, CodeUnclosedOp},
+	{ErrUnopenedOp, CodeUnopenedOp},
+	{ErrMismatchedOp, CodeMismatchedOp},
+	{ErrNonASCIIOp, CodeNonASCIIOp},
+	{ErrUnclosedMode, CodeUnclosedMode},
+	{ErrFStringBrace, CodeFStringBrace},
+	{ErrFStringBytes, CodeFStringBytes},
+	{ErrFStringField, CodeFStringField},
+	{ErrAmbiguousIndent, CodeAmbiguousIndent},
+	{ErrTabIndent, CodeTabIndent},
+	{ErrSpaceIndent, CodeSpaceIndent},
+	{ErrBackslashCont, CodeBackslashCont},
+	{WarnTrailingWS, CodeTrailingWS},
+	{WarnFormFeed, CodeFormFeed},
+	{WarnTabAfterSpace, CodeTabAfterSpace},
+	{WarnBackslash, CodeBackslash},
+	{WarnOctalEscape, CodeOctalEscape},
+	{WarnNonASCIIOp, CodeNonASCIIOpWarn},
+	{WarnBackslashWS, CodeBackslashWS},
+}
+
+// Names of structured diagnostic fields.
+const (
+	FieldOpen     = "open"     // The open operator token (*Token)
+	FieldClose    = "close"    // The close operator symbol (*Symbol)
+	FieldExpected = "expected" // The expected indent columns ([]int)
+	FieldColumn   = "column"   // The actual indent column (int)
+)
+
+// Label is a secondary annotation on a diagnostic, identifying a
+// related location, such as the open operator for a mismatched close
+// operator.
+type Label struct {
+	Loc Location // The location being labeled
+	Msg string   // The label text
+}
+
+// Diagnostic describes a single problem found while processing a
+// source file.  It implements error, and wraps the underlying error,
+// which is usually one of the sentinels in errors.go, so that
+// errors.Is may be used to test for specific errors.
+type Diagnostic struct {
+	Code     string                 // The stable diagnostic code
+	Severity Severity               // The severity of the diagnostic
+	Msg      string                 // The human-readable message
+	Args     []interface{}          // Arguments for the message format
+	Loc      Location               // The location of the problem
+	Fields   map[string]interface{} // Structured data, e.g., FieldOpen
+	Labels   []Label                // Secondary labels
+	Fixes    []Fix                  // Suggested fixes
+	Err      error                  // The underlying error
+}
+
+// Error returns the diagnostic message.  This implements the error
+// interface.
+func (d *Diagnostic) Error() string {
+	return d.Msg
+}
+
+// Unwrap returns the underlying error.  This allows errors.Is and
+// errors.As to see through the diagnostic.
+func (d *Diagnostic) Unwrap() error {
+	return d.Err
+}
+
+// String constructs a string representation of the diagnostic,
+// including its location, severity, and code.
+func (d *Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s[%s]: %s", d.Loc, d.Severity, d.Code, d.Msg)
+}
+
+// Code looks up the diagnostic code for an error.  If the error is,
+// or wraps, a Diagnostic, that diagnostic's code is returned;
+// otherwise, the code for the first matching sentinel is returned.
+// Errors with no assigned code return CodeUnknown.
+func Code(err error) string {
+	// Check for a diagnostic first
+	var diag *Diagnostic
+	if errors.As(err, &diag) {
+		return diag.Code
+	}
+
+	// Look for a matching sentinel
+	for _, entry := range codes {
+		if errors.Is(err, entry.err) {
+			return entry.code
+		}
+	}
+
+	return CodeUnknown
+}
+
+// Diagnose converts an error into a Diagnostic with error severity.
+// If the error is already a Diagnostic, a copy of it is returned,
+// with the location filled in if it was not already set.
+func Diagnose(err error, loc Location) *Diagnostic {
+	// Is it already a diagnostic?
+	if diag, ok := err.(*Diagnostic); ok {
+		result := *diag
+		if result.Loc == (Location{}) {
+			result.Loc = loc
+		}
+
+		return &result
+	}
+
+	return &Diagnostic{
+		Code:     Code(err),
+		Severity: SevError,
+		Msg:      err.Error(),
+		Loc:      loc,
+		Err:      err,
+	}
+}
+
+// Warning converts an error into a Diagnostic with warning severity.
+// It is otherwise identical to Diagnose.
+func Warning(err error, loc Location) *Diagnostic {
+	diag := Diagnose(err, loc)
+	diag.Severity = SevWarning
+
+	return diag
+}
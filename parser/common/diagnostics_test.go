@@ -0,0 +1,162 @@
+// Copyright (c) 2019 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package common
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hydralang/hydra/testutils"
+)
+
+func TestSeverityString(t *testing.T) {
+	a := assert.New(t)
+
+	a.Equal("error", SevError.String())
+	a.Equal("warning", SevWarning.String())
+	a.Equal("note", SevNote.String())
+	a.Equal("severity 42", Severity(42).String())
+}
+
+func TestDiagnosticImplementsError(t *testing.T) {
+	assert.Implements(t, (*error)(nil), &Diagnostic{})
+}
+
+func TestDiagnosticError(t *testing.T) {
+	a := assert.New(t)
+	diag := &Diagnostic{Msg: "message"}
+
+	result := diag.Error()
+
+	a.Equal("message", result)
+}
+
+func TestDiagnosticUnwrap(t *testing.T) {
+	a := assert.New(t)
+	diag := &Diagnostic{Err: ErrBadOp}
+
+	result := diag.Unwrap()
+
+	a.Equal(ErrBadOp, result)
+	a.True(errors.Is(diag, ErrBadOp))
+	a.False(errors.Is(diag, ErrBadIdent))
+}
+
+func TestDiagnosticString(t *testing.T) {
+	a := assert.New(t)
+	diag := &Diagnostic{
+		Code:     CodeBadOp,
+		Severity: SevError,
+		Msg:      "message",
+		Loc:      Location{File: "file", B: FilePos{3, 5}, E: FilePos{3, 9}},
+	}
+
+	result := diag.String()
+
+	a.Equal("file:3:5-9: error[H0103]: message", result)
+}
+
+func TestCodeSentinel(t *testing.T) {
+	a := assert.New(t)
+
+	result := Code(ErrMixedIndent)
+
+	a.Equal("H0104", result)
+}
+
+func TestCodeWrapped(t *testing.T) {
+	a := assert.New(t)
+
+	result := Code(fmt.Errorf("wrapped: %w", ErrBadEscape))
+
+	a.Equal(CodeBadEscape, result)
+}
+
+func TestCodeDiagnostic(t *testing.T) {
+	a := assert.New(t)
+	diag := &Diagnostic{Code: "H9999", Err: ErrBadEscape}
+
+	result := Code(fmt.Errorf("wrapped: %w", diag))
+
+	a.Equal("H9999", result)
+}
+
+func TestCodeUnknown(t *testing.T) {
+	a := assert.New(t)
+
+	result := Code(assert.AnError)
+
+	a.Equal(CodeUnknown, result)
+}
+
+func TestDiagnoseError(t *testing.T) {
+	a := assert.New(t)
+	loc := Location{File: "file", B: FilePos{3, 5}, E: FilePos{3, 9}}
+
+	result := Diagnose(ErrUnclosedStr, loc)
+
+	a.Equal(&Diagnostic{
+		Code:     CodeUnclosedStr,
+		Severity: SevError,
+		Msg:      "unclosed string literal",
+		Loc:      loc,
+		Err:      ErrUnclosedStr,
+	}, result)
+}
+
+func TestDiagnoseDiagnostic(t *testing.T) {
+	a := assert.New(t)
+	loc := Location{File: "file", B: FilePos{3, 5}, E: FilePos{3, 9}}
+	diag := ErrNoOpen(&Symbol{Name: ")"})
+
+	result := Diagnose(diag, loc)
+
+	testutils.AssertPtrNotEqual(a, diag, result)
+	a.Equal(loc, result.Loc)
+	a.Equal(Location{}, diag.Loc)
+	a.Equal(CodeUnopenedOp, result.Code)
+}
+
+func TestDiagnoseDiagnosticWithLocation(t *testing.T) {
+	a := assert.New(t)
+	loc := Location{File: "file", B: FilePos{3, 5}, E: FilePos{3, 9}}
+	tok := &Token{
+		Sym: &Symbol{Name: "(", Close: ")"},
+		Loc: Location{File: "file", B: FilePos{1, 1}, E: FilePos{1, 2}},
+	}
+	diag := ErrDanglingOpen(tok)
+
+	result := Diagnose(diag, loc)
+
+	a.Equal(tok.Loc, result.Loc)
+}
+
+func TestWarning(t *testing.T) {
+	a := assert.New(t)
+	loc := Location{File: "file", B: FilePos{3, 5}, E: FilePos{3, 9}}
+
+	result := Warning(WarnTrailingWS, loc)
+
+	a.Equal(&Diagnostic{
+		Code:     CodeTrailingWS,
+		Severity: SevWarning,
+		Msg:      "trailing whitespace",
+		Loc:      loc,
+		Err:      WarnTrailingWS,
+	}, result)
+}
@@ -19,15 +19,33 @@
 // particular, allows for relatively easy versioning of the Hydra
 // language.
 //
-// Character classes are in classes.go; common errors, in errors.go.
-// The Location class exists in locations.go; and options, which
-// houses the Profile, is in options.go.  The Profile itself is
-// defined in profile.go, and basic interfaces, such as the one
-// defining a scanner, are in interfaces.go.
+// Character classes are in classes.go; common errors, in errors.go;
+// and the Diagnostic, which wraps an error with a stable code, a
+// severity, and a location, in diagnostics.go.  Diagnostics may be
+// rendered for display, along with the source lines they refer to,
+// using render.go, which relies on the Source in source.go, and may
+// carry suggested fixes, described in fixes.go.  Diagnostic messages
+// may be translated using the catalog in messages.go.  Lexical lint
+// checks, which produce warnings, are described in lints.go.  Trivia,
+// the source text between tokens, is described in trivia.go.  The
+// Location class exists in locations.go; and options, which houses
+// the Profile, is in options.go.  The Profile itself is defined in
+// profile.go, and basic interfaces, such as the one defining a
+// scanner, are in interfaces.go.  The LexState, a snapshot of the
+// state of a lexer from which lexing may be resumed, is in
+// lexstate.go.
 //
 // The basic tokens are defined in tokens.go, with identifiers.go,
 // operators.go, and strings.go containing the code for describing
 // those token types.  (The identifiers.go file contains code
 // associated with keywords, which are recognized by the identifiers
-// recognizer in the lexer.)
+// recognizer in the lexer.)  The symbol table built by the Profile,
+// which assigns each symbol a dense integer ID, is in symbols.go.
+// The precedence table for operators, also part of the Profile, is
+// described in bindings.go, and the registry of recognizers, which
+// selects the recognizer the lexer applies to each token, is in
+// recognizers.go.  Lexical modes, which recognizers may push to lex
+// nested constructs such as interpolated strings, are described in
+// modes.go.  Tokens may be serialized in JSON Lines
+// format, and read back, using tokenio.go.
 package common
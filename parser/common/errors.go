@@ -17,6 +17,8 @@ package common
 import (
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 )
 
 // Various errors that may occur during parsing.
@@ -32,22 +34,142 @@ var (
 	ErrBadStrChar        = errors.New("invalid character for string")
 	ErrUnclosedStr       = errors.New("unclosed string literal")
 	ErrBadIdent          = errors.New("bad identifier character")
+	ErrUnclosedOp        = errors.New("unclosed open operator")
+	ErrUnopenedOp        = errors.New("unexpected close operator")
+	ErrMismatchedOp      = errors.New("mismatched close operator")
+	ErrBadEdit           = errors.New("edit location not in source")
+	ErrOverlappingEdits  = errors.New("overlapping edits")
+	ErrUnknownSymbol     = errors.New("unknown token symbol")
+	ErrBadTokenValue     = errors.New("bad token value")
+	ErrSymbolKind        = errors.New("symbol used inconsistently with its kind")
+	ErrBadBinding        = errors.New("bad operator binding")
+	ErrBadOpName         = errors.New("empty operator name")
+	ErrDuplicateOp       = errors.New("operator already added")
+	ErrOpConflict        = errors.New("conflicting operator with the same name")
+	ErrNoSuchOp          = errors.New("no such operator")
+	ErrNonASCIIOp        = errors.New("non-ASCII operator spelling")
+	ErrFrozen            = errors.New("profile is frozen")
+	ErrStatePos          = errors.New("lexer state does not match source position")
+	ErrBadRecog          = errors.New("invalid recognizer")
+	ErrDuplicateRecog    = errors.New("recognizer already added")
+	ErrNoSuchRecog       = errors.New("no such recognizer")
+	ErrNoMode            = errors.New("no lexical mode to pop")
+	ErrUnclosedMode      = errors.New("unterminated lexical mode")
+	ErrFStringBrace      = errors.New("single \"}\" is not allowed in f-string")
+	ErrFStringBytes      = errors.New("f-string may not be a byte string")
+	ErrFStringField      = errors.New("unclosed f-string replacement field")
+	ErrAmbiguousIndent   = errors.New("indentation is ambiguous across tab sizes")
+	ErrTabIndent         = errors.New("tab in indentation")
+	ErrSpaceIndent       = errors.New("space in indentation")
+	ErrBackslashCont     = errors.New("backslash continuation is not allowed")
+)
+
+// Various warnings that may be reported during parsing.
+var (
+	WarnTrailingWS    = errors.New("trailing whitespace")
+	WarnFormFeed      = errors.New("form feed in the middle of a line")
+	WarnTabAfterSpace = errors.New("tab after space in continuation line indentation")
+	WarnBackslash     = errors.New("redundant backslash continuation inside brackets")
+	WarnOctalEscape   = errors.New("legacy octal escape sequence")
+	WarnNonASCIIOp    = errors.New("non-ASCII operator spelling")
+	WarnBackslashWS   = errors.New("whitespace after backslash continuation")
 )
 
 // ErrDanglingOpen generates an error for a dangling open operator
-// with no corresponding close operator.
-func ErrDanglingOpen(tok *Token) error {
-	return fmt.Errorf("unexpected EOF; expected \"%s\"", tok.Sym.Close)
+// with no corresponding close operator.  The open operator token is
+// available as the FieldOpen field of the diagnostic.
+func ErrDanglingOpen(tok *Token) *Diagnostic {
+	return &Diagnostic{
+		Code:     CodeUnclosedOp,
+		Severity: SevError,
+		Msg:      fmt.Sprintf(msgUnclosedOp, tok.Sym.Close),
+		Loc:      tok.Loc,
+		Args:     []interface{}{tok.Sym.Close},
+		Fields: map[string]interface{}{
+			FieldOpen: tok,
+		},
+		Err: ErrUnclosedOp,
+	}
 }
 
 // ErrNoOpen generates an error for a close operator with no
-// corresponding open operator.
-func ErrNoOpen(sym *Symbol) error {
-	return fmt.Errorf("unexpected close operator \"%s\"", sym.Name)
+// corresponding open operator.  The close operator symbol is
+// available as the FieldClose field of the diagnostic.
+func ErrNoOpen(sym *Symbol) *Diagnostic {
+	return &Diagnostic{
+		Code:     CodeUnopenedOp,
+		Severity: SevError,
+		Msg:      fmt.Sprintf(msgUnopenedOp, sym.Name),
+		Args:     []interface{}{sym.Name},
+		Fields: map[string]interface{}{
+			FieldClose: sym,
+		},
+		Err: ErrUnopenedOp,
+	}
+}
+
+// spelling returns the spelling of an open operator token for use in
+// messages.  The names of structural symbols, such as TokFExprStart,
+// mean nothing to users, so such tokens are described by their
+// source text, if it is known.
+func spelling(tok *Token) string {
+	if tok.Sym.Kind == KindStructural && tok.Text != "" {
+		return tok.Text
+	}
+
+	return tok.Sym.Name
 }
 
 // ErrOpMismatch generates an error for a close operator that doesn't
-// match the open operator.
-func ErrOpMismatch(openTok *Token, close *Symbol) error {
-	return fmt.Errorf("close operator \"%s\" does not match open operator \"%s\" at %s", close.Name, openTok.Sym.Name, openTok.Loc)
+// match the open operator.  The open operator token and close
+// operator symbol are available as the FieldOpen and FieldClose
+// fields of the diagnostic.
+func ErrOpMismatch(openTok *Token, close *Symbol) *Diagnostic {
+	open := spelling(openTok)
+	return &Diagnostic{
+		Code:     CodeMismatchedOp,
+		Severity: SevError,
+		Msg:      fmt.Sprintf(msgMismatchedOp, close.Name, open, openTok.Loc),
+		Args:     []interface{}{close.Name, open, openTok.Loc.String()},
+		Fields: map[string]interface{}{
+			FieldOpen:  openTok,
+			FieldClose: close,
+		},
+		Labels: []Label{
+			{
+				Loc: openTok.Loc,
+				Msg: fmt.Sprintf("open operator \"%s\" is here", open),
+			},
+		},
+		Err: ErrMismatchedOp,
+	}
+}
+
+// ErrIndentMismatch generates an error for a dedent to a column that
+// does not match any enclosing indentation level.  The expected
+// columns, typically the indentation levels on either side of the
+// column, are available as the FieldExpected field of the diagnostic,
+// and the column itself as the FieldColumn field.
+func ErrIndentMismatch(col int, expected ...int) *Diagnostic {
+	// Describe the candidate columns
+	cands := make([]string, len(expected))
+	for i, exp := range expected {
+		cands[i] = strconv.Itoa(exp)
+	}
+	desc := strings.Join(cands, " or ")
+	if len(cands) > 2 {
+		desc = strings.Join(cands[:len(cands)-1], ", ") + ", or " + cands[len(cands)-1]
+	}
+
+	return &Diagnostic{
+		Code:     CodeBadIndent,
+		Severity: SevError,
+		Msg:      fmt.Sprintf(msgBadIndent, desc, col),
+		Args:     []interface{}{desc, col},
+		Fields: map[string]interface{}{
+			FieldExpected: expected,
+			FieldColumn:   col,
+		},
+		Err: ErrBadIndent,
+	}
 }
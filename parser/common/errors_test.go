@@ -15,6 +15,7 @@
 package common
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -27,6 +28,11 @@ func TestErrDanglingOpen(t *testing.T) {
 	result := ErrDanglingOpen(tok)
 
 	a.EqualError(result, "unexpected EOF; expected \")\"")
+	a.True(errors.Is(result, ErrUnclosedOp))
+	a.Equal(CodeUnclosedOp, result.Code)
+	a.Equal(tok.Loc, result.Loc)
+	a.Equal([]interface{}{")"}, result.Args)
+	a.Equal(tok, result.Fields[FieldOpen])
 }
 
 func TestErrNoOpen(t *testing.T) {
@@ -36,6 +42,10 @@ func TestErrNoOpen(t *testing.T) {
 	result := ErrNoOpen(sym)
 
 	a.EqualError(result, "unexpected close operator \")\"")
+	a.True(errors.Is(result, ErrUnopenedOp))
+	a.Equal(CodeUnopenedOp, result.Code)
+	a.Equal([]interface{}{")"}, result.Args)
+	a.Equal(sym, result.Fields[FieldClose])
 }
 
 func TestErrOpMismatch(t *testing.T) {
@@ -53,4 +63,77 @@ func TestErrOpMismatch(t *testing.T) {
 	result := ErrOpMismatch(tok, sym)
 
 	a.EqualError(result, "close operator \")\" does not match open operator \"[\" at file:3:2")
+	a.True(errors.Is(result, ErrMismatchedOp))
+	a.Equal(CodeMismatchedOp, result.Code)
+	a.Equal([]interface{}{")", "[", "file:3:2"}, result.Args)
+	a.Equal(tok, result.Fields[FieldOpen])
+	a.Equal(sym, result.Fields[FieldClose])
+	a.Equal([]Label{{Loc: tok.Loc, Msg: "open operator \"[\" is here"}}, result.Labels)
+}
+
+func TestSpellingOperator(t *testing.T) {
+	a := assert.New(t)
+	tok := &Token{Sym: &Symbol{Name: "[", Kind: KindOperator}, Text: "["}
+
+	result := spelling(tok)
+
+	a.Equal("[", result)
+}
+
+func TestSpellingStructural(t *testing.T) {
+	a := assert.New(t)
+	tok := &Token{Sym: TokFExprStart, Text: "{"}
+
+	result := spelling(tok)
+
+	a.Equal("{", result)
+}
+
+func TestSpellingStructuralNoText(t *testing.T) {
+	a := assert.New(t)
+	tok := &Token{Sym: TokFExprStart}
+
+	result := spelling(tok)
+
+	a.Equal("<FExprStart>", result)
+}
+
+func TestErrOpMismatchStructural(t *testing.T) {
+	a := assert.New(t)
+	tok := &Token{
+		Sym: TokFExprStart,
+		Loc: Location{
+			File: "file",
+			B:    FilePos{L: 1, C: 3},
+			E:    FilePos{L: 1, C: 4},
+		},
+		Text: "{",
+	}
+
+	result := ErrOpMismatch(tok, &Symbol{Name: "]"})
+
+	a.EqualError(result, "close operator \"]\" does not match open operator \"{\" at file:1:3")
+	a.Equal([]Label{{Loc: tok.Loc, Msg: "open operator \"{\" is here"}}, result.Labels)
+}
+
+func TestErrIndentMismatch(t *testing.T) {
+	a := assert.New(t)
+
+	result := ErrIndentMismatch(7, 5, 9)
+
+	a.EqualError(result, "inconsistent indentation: expected column 5 or 9, got 7")
+	a.True(errors.Is(result, ErrBadIndent))
+	a.Equal(CodeBadIndent, result.Code)
+	a.Equal([]interface{}{"5 or 9", 7}, result.Args)
+	a.Equal([]int{5, 9}, result.Fields[FieldExpected])
+	a.Equal(7, result.Fields[FieldColumn])
+}
+
+func TestErrIndentMismatchMany(t *testing.T) {
+	a := assert.New(t)
+
+	result := ErrIndentMismatch(7, 1, 5, 9)
+
+	a.EqualError(result, "inconsistent indentation: expected column 1, 5, or 9, got 7")
+	a.Equal([]interface{}{"1, 5, or 9", 7}, result.Args)
 }
@@ -0,0 +1,100 @@
+// Copyright (c) 2019 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package common
+
+import (
+	"bytes"
+	"sort"
+)
+
+// Edit describes a single change to the source text: the text within
+// the location is replaced by the replacement text.  A location whose
+// beginning and end are the same describes an insertion.
+type Edit struct {
+	Loc  Location // The location of the text to replace
+	Text string   // The replacement text
+}
+
+// Fix describes a suggested fix for a diagnostic, consisting of a
+// message describing the fix and the edits that implement it.  The
+// edits of a single fix do not overlap.
+type Fix struct {
+	Msg   string // Description of the fix
+	Edits []Edit // The edits to apply
+}
+
+// span is an edit resolved to byte offsets within the source.
+type span struct {
+	b, e int    // The beginning and end offsets
+	text string // The replacement text
+}
+
+// ApplyEdits applies a set of edits to the source text, returning the
+// edited text.  The tab stop should match the one used when lexing
+// the source.  Edits may be given in any order, but must not overlap;
+// insertions at the same position are applied in the order given.
+// Returns ErrBadEdit if an edit location does not exist in the
+// source, or ErrOverlappingEdits if edits overlap.
+func ApplyEdits(source []byte, edits []Edit, tabStop int) ([]byte, error) {
+	src := NewSource(source, tabStop)
+
+	// Resolve the edits to byte offsets
+	spans := make([]span, len(edits))
+	for i, edit := range edits {
+		b, ok := src.Offset(edit.Loc.B)
+		if !ok {
+			return nil, ErrBadEdit
+		}
+		e, ok := src.Offset(edit.Loc.E)
+		if !ok || e < b {
+			return nil, ErrBadEdit
+		}
+		spans[i] = span{b, e, edit.Text}
+	}
+
+	// Sort the spans and check for overlaps
+	sort.SliceStable(spans, func(i, j int) bool {
+		if spans[i].b == spans[j].b {
+			return spans[i].e < spans[j].e
+		}
+		return spans[i].b < spans[j].b
+	})
+	for i := 1; i < len(spans); i++ {
+		if spans[i].b < spans[i-1].e {
+			return nil, ErrOverlappingEdits
+		}
+	}
+
+	// Construct the result
+	buf := &bytes.Buffer{}
+	pos := 0
+	for _, sp := range spans {
+		buf.Write(source[pos:sp.b])
+		buf.WriteString(sp.text)
+		pos = sp.e
+	}
+	buf.Write(source[pos:])
+
+	return buf.Bytes(), nil
+}
+
+// Insert constructs an Edit that inserts text at the beginning of the
+// specified location.
+func Insert(loc Location, text string) Edit {
+	return Edit{
+		Loc:  loc.Thru(loc),
+		Text: text,
+	}
+}
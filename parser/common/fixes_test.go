@@ -0,0 +1,178 @@
+// Copyright (c) 2019 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInsert(t *testing.T) {
+	a := assert.New(t)
+	loc := Location{
+		File: "file",
+		B:    FilePos{L: 3, C: 2},
+		E:    FilePos{L: 3, C: 5},
+	}
+
+	result := Insert(loc, "text")
+
+	a.Equal(Edit{
+		Loc: Location{
+			File: "file",
+			B:    FilePos{L: 3, C: 2},
+			E:    FilePos{L: 3, C: 2},
+		},
+		Text: "text",
+	}, result)
+}
+
+func TestApplyEdits(t *testing.T) {
+	a := assert.New(t)
+	source := []byte("a = 'abc\n\tb = (1,\n")
+	edits := []Edit{
+		{
+			Loc: Location{
+				B: FilePos{L: 3, C: 1},
+				E: FilePos{L: 3, C: 1},
+			},
+			Text: ")",
+		},
+		{
+			Loc: Location{
+				B: FilePos{L: 2, C: 1},
+				E: FilePos{L: 2, C: 9},
+			},
+			Text: "    ",
+		},
+		{
+			Loc: Location{
+				B: FilePos{L: 1, C: 9},
+				E: FilePos{L: 1, C: 9},
+			},
+			Text: "'",
+		},
+		{
+			Loc: Location{
+				B: FilePos{L: 3, C: 1},
+				E: FilePos{L: 3, C: 1},
+			},
+			Text: "]",
+		},
+	}
+
+	result, err := ApplyEdits(source, edits, 0)
+
+	a.NoError(err)
+	a.Equal("a = 'abc'\n    b = (1,\n)]", string(result))
+}
+
+func TestApplyEditsBadLocation(t *testing.T) {
+	a := assert.New(t)
+	source := []byte("a\tb\n")
+	edits := []Edit{
+		{
+			Loc: Location{
+				B: FilePos{L: 1, C: 3},
+				E: FilePos{L: 1, C: 3},
+			},
+			Text: "x",
+		},
+	}
+
+	result, err := ApplyEdits(source, edits, 0)
+
+	a.Equal(ErrBadEdit, err)
+	a.Nil(result)
+}
+
+func TestApplyEditsReversed(t *testing.T) {
+	a := assert.New(t)
+	source := []byte("abcdef")
+	edits := []Edit{
+		{
+			Loc: Location{
+				B: FilePos{L: 1, C: 4},
+				E: FilePos{L: 1, C: 2},
+			},
+			Text: "x",
+		},
+	}
+
+	result, err := ApplyEdits(source, edits, 0)
+
+	a.Equal(ErrBadEdit, err)
+	a.Nil(result)
+}
+
+func TestApplyEditsOverlapping(t *testing.T) {
+	a := assert.New(t)
+	source := []byte("abcdef")
+	edits := []Edit{
+		{
+			Loc: Location{
+				B: FilePos{L: 1, C: 2},
+				E: FilePos{L: 1, C: 4},
+			},
+			Text: "x",
+		},
+		{
+			Loc: Location{
+				B: FilePos{L: 1, C: 3},
+				E: FilePos{L: 1, C: 3},
+			},
+			Text: "y",
+		},
+	}
+
+	result, err := ApplyEdits(source, edits, 0)
+
+	a.Equal(ErrOverlappingEdits, err)
+	a.Nil(result)
+}
+
+func TestApplyEditsAdjacent(t *testing.T) {
+	a := assert.New(t)
+	source := []byte("abcdef")
+	edits := []Edit{
+		{
+			Loc: Location{
+				B: FilePos{L: 1, C: 2},
+				E: FilePos{L: 1, C: 4},
+			},
+			Text: "x",
+		},
+		{
+			Loc: Location{
+				B: FilePos{L: 1, C: 2},
+				E: FilePos{L: 1, C: 2},
+			},
+			Text: "y",
+		},
+		{
+			Loc: Location{
+				B: FilePos{L: 1, C: 4},
+				E: FilePos{L: 1, C: 5},
+			},
+			Text: "z",
+		},
+	}
+
+	result, err := ApplyEdits(source, edits, 0)
+
+	a.NoError(err)
+	a.Equal("ayxzef", string(result))
+}
@@ -14,8 +14,13 @@
 
 package common
 
+import "strings"
+
 // Keywords is a map mapping identifier strings to the symbols to use
-// for keyword tokens.
+// for keyword tokens.  A keyword may consist of multiple words
+// separated by single spaces, such as "not in"; the lexer fuses such
+// a sequence of words, separated by any whitespace other than a
+// newline, into a single keyword token.
 type Keywords map[string]*Symbol
 
 // Copy produces a new copy of a Keywords object.
@@ -46,3 +51,31 @@ func (k Keywords) Remove(sym *Symbol) {
 		delete(k, sym.Name)
 	}
 }
+
+// Prefixes returns the set of word prefixes of the multi-word
+// keywords: for "is not in", the set contains "is" and "is not".  A
+// set constructed once may be consulted in place of Continues, which
+// must examine every keyword.
+func (k Keywords) Prefixes() map[string]bool {
+	prefixes := map[string]bool{}
+	for text := range k {
+		for i := strings.LastIndexByte(text, ' '); i > 0; i = strings.LastIndexByte(text[:i], ' ') {
+			prefixes[text[:i]] = true
+		}
+	}
+
+	return prefixes
+}
+
+// Continues tests whether the specified words, separated by single
+// spaces, are the beginning of a multi-word keyword.
+func (k Keywords) Continues(words string) bool {
+	prefix := words + " "
+	for text := range k {
+		if strings.HasPrefix(text, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
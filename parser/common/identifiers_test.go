@@ -90,3 +90,56 @@ func TestKeywordsRemoveAbsent(t *testing.T) {
 		"kw2": &Symbol{Name: "kw2"},
 	}, obj)
 }
+
+func TestKeywordsPrefixes(t *testing.T) {
+	a := assert.New(t)
+	obj := Keywords{
+		"not":       &Symbol{Name: "not"},
+		"not in":    &Symbol{Name: "not in"},
+		"is not in": &Symbol{Name: "is not in"},
+	}
+
+	result := obj.Prefixes()
+
+	a.Equal(map[string]bool{
+		"not":    true,
+		"is":     true,
+		"is not": true,
+	}, result)
+}
+
+func TestKeywordsContinuesTrue(t *testing.T) {
+	a := assert.New(t)
+	obj := Keywords{
+		"not":    &Symbol{Name: "not"},
+		"not in": &Symbol{Name: "not in"},
+	}
+
+	result := obj.Continues("not")
+
+	a.True(result)
+}
+
+func TestKeywordsContinuesFalse(t *testing.T) {
+	a := assert.New(t)
+	obj := Keywords{
+		"not":    &Symbol{Name: "not"},
+		"not in": &Symbol{Name: "not in"},
+	}
+
+	result := obj.Continues("no")
+
+	a.False(result)
+}
+
+func TestKeywordsContinuesComplete(t *testing.T) {
+	a := assert.New(t)
+	obj := Keywords{
+		"not":    &Symbol{Name: "not"},
+		"not in": &Symbol{Name: "not in"},
+	}
+
+	result := obj.Continues("not in")
+
+	a.False(result)
+}
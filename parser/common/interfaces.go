@@ -35,12 +35,83 @@ type Lexer interface {
 	// Next retrieves the next token from the scanner.  If the end
 	// of file is reached, an EOF token is returned; if an error
 	// occurs while scanning or lexically analyzing the file, an
-	// error token is returned with the error as the token's
-	// semantic value.  After either an EOF token or an error
-	// token, nil will be returned.
+	// error token is returned with a *Diagnostic describing the
+	// error as the token's semantic value.  After either an EOF
+	// token or an error token, nil will be returned.
 	Next() *Token
 
+	// Peek retrieves a token from the lexer without consuming it.
+	// The argument is the number of tokens to look ahead; Peek(0)
+	// returns the token that the next call to Next will return.
+	// If there are not enough tokens, such as when looking past
+	// an EOF token or an error token, nil is returned.
+	Peek(n int) *Token
+
+	// Snapshot captures the state of the lexer, including the
+	// position of the next character to lex.
+	Snapshot() *LexState
+
+	// Restore restores the lexer to a state captured by Snapshot.
+	// The next character of the lexer's source must be at the
+	// position of the snapshot.
+	Restore(state *LexState) error
+
 	// Push pushes a single token back onto the lexer.  Any number
 	// of tokens may be pushed back.
 	Push(tok *Token)
 }
+
+// LexContext is an interface describing the lexer, as seen by a
+// recognizer.  It exposes the scanner the lexer is reading from,
+// along with the means of pushing tokens and errors onto the lexer's
+// token queue.
+type LexContext interface {
+	// Scanner returns the scanner for the source.  This will be
+	// nil if token processing has been halted, such as after an
+	// error when the lexer is not recovering from errors.
+	Scanner() Scanner
+
+	// Options returns the parser options the lexer was
+	// constructed with.
+	Options() *Options
+
+	// PushTok pushes a token onto the end of the lexer's token
+	// queue, applying indentation as necessary.  Returns the
+	// token, or nil if the token was elided.
+	PushTok(sym *Symbol, loc Location, val interface{}) *Token
+
+	// PushErr pushes an error token onto the end of the lexer's
+	// token queue.  Unless the lexer is recovering from errors,
+	// this halts token processing.
+	PushErr(loc Location, err error)
+
+	// Warn reports a warning, if the specified lint check is
+	// enabled in the profile.
+	Warn(lint uint16, loc Location, err error)
+
+	// Mode returns the active lexical mode, or nil if no mode has
+	// been pushed.
+	Mode() *Mode
+
+	// PushMode pushes a lexical mode onto the lexer's mode stack,
+	// making it the active mode.
+	PushMode(mode *Mode)
+
+	// PopMode pops the active lexical mode off the lexer's mode
+	// stack.  Returns ErrNoMode if no mode has been pushed.
+	PopMode() error
+}
+
+// Recognizer is an interface describing recognizers.  A recognizer
+// is initialized with the lexer context and implements the logic
+// necessary to recognize a sequence of characters from the scanner.
+type Recognizer interface {
+	// Recognize is called to recognize a lexical construct.  Will
+	// be called with the first character, and should push zero or
+	// more tokens onto the lexer's tokens queue.
+	Recognize(ch AugChar)
+}
+
+// RecogInit is a function that initializes a recognizer.  It will be
+// passed the lexer context, and must return a Recognizer.
+type RecogInit func(lc LexContext) Recognizer
@@ -0,0 +1,104 @@
+// Copyright (c) 2019 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package common
+
+import "reflect"
+
+// LexState is a snapshot of the state of a lexer, obtained from the
+// lexer's Snapshot method.  A lexer may be restarted from a snapshot
+// by constructing a lexer over the source beginning at the position
+// of the snapshot (see the Start option) and passing the snapshot to
+// its Restore method.
+type LexState struct {
+	Pos     FilePos  // Position of the next character to lex
+	Indent  []int    // The indentation stack, innermost last
+	Leads   []string // Leading whitespace of the indentation levels
+	Pairs   []*Token // The unclosed open operators, innermost last
+	Modes   []*Mode  // The lexical mode stack, innermost last
+	Pending []*Token // Tokens lexed but not yet returned
+	Prev    *Token   // The last token returned, if any
+}
+
+// sameToken tests whether two tokens have the same symbol and
+// semantic value.  The locations are not compared.
+func sameToken(a, b *Token) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Sym == b.Sym && reflect.DeepEqual(a.Val, b.Val)
+}
+
+// sameMode tests whether two lexical modes are the same, or have the
+// same name, recognizers, flags, and data.
+func sameMode(a, b *Mode) bool {
+	if a == b {
+		return true
+	}
+
+	return a.Name == b.Name && a.Recognizers == b.Recognizers && a.Flags == b.Flags && reflect.DeepEqual(a.Data, b.Data)
+}
+
+// sameTokens tests whether two lists of tokens have the same symbols
+// and semantic values.
+func sameTokens(a, b []*Token) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i, tok := range a {
+		if !sameToken(tok, b[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Matches tests whether a lexer in this state would lex the
+// remainder of a source the same way as a lexer in the other state.
+// The positions of the states and of their tokens are not compared,
+// so that states at different lines may match.
+func (ls *LexState) Matches(other *LexState) bool {
+	// Compare the indentation stacks
+	if len(ls.Indent) != len(other.Indent) {
+		return false
+	}
+	for i, col := range ls.Indent {
+		if col != other.Indent[i] {
+			return false
+		}
+	}
+	if len(ls.Leads) != len(other.Leads) {
+		return false
+	}
+	for i, lead := range ls.Leads {
+		if lead != other.Leads[i] {
+			return false
+		}
+	}
+
+	// Compare the mode stacks
+	if len(ls.Modes) != len(other.Modes) {
+		return false
+	}
+	for i, mode := range ls.Modes {
+		if !sameMode(mode, other.Modes[i]) {
+			return false
+		}
+	}
+
+	return sameTokens(ls.Pairs, other.Pairs) && sameTokens(ls.Pending, other.Pending) && sameToken(ls.Prev, other.Prev)
+}
@@ -0,0 +1,221 @@
+// Copyright (c) 2019 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSameTokenSame(t *testing.T) {
+	a := assert.New(t)
+	tok1 := &Token{Sym: TokString, Loc: Location{B: FilePos{L: 1, C: 1}}, Val: []byte("abc")}
+	tok2 := &Token{Sym: TokString, Loc: Location{B: FilePos{L: 2, C: 1}}, Val: []byte("abc")}
+
+	result := sameToken(tok1, tok2)
+
+	a.True(result)
+}
+
+func TestSameTokenDifferentSym(t *testing.T) {
+	a := assert.New(t)
+	tok1 := &Token{Sym: TokString, Val: "abc"}
+	tok2 := &Token{Sym: TokIdent, Val: "abc"}
+
+	result := sameToken(tok1, tok2)
+
+	a.False(result)
+}
+
+func TestSameTokenDifferentVal(t *testing.T) {
+	a := assert.New(t)
+	tok1 := &Token{Sym: TokIdent, Val: "abc"}
+	tok2 := &Token{Sym: TokIdent, Val: "abd"}
+
+	result := sameToken(tok1, tok2)
+
+	a.False(result)
+}
+
+func TestSameTokenNil(t *testing.T) {
+	a := assert.New(t)
+
+	a.True(sameToken(nil, nil))
+	a.False(sameToken(&Token{Sym: TokIdent}, nil))
+	a.False(sameToken(nil, &Token{Sym: TokIdent}))
+}
+
+func TestSameTokensSame(t *testing.T) {
+	a := assert.New(t)
+	toks1 := []*Token{{Sym: TokIdent, Val: "a"}, {Sym: TokNewline}}
+	toks2 := []*Token{{Sym: TokIdent, Val: "a"}, {Sym: TokNewline}}
+
+	result := sameTokens(toks1, toks2)
+
+	a.True(result)
+}
+
+func TestSameTokensLength(t *testing.T) {
+	a := assert.New(t)
+	toks1 := []*Token{{Sym: TokIdent, Val: "a"}, {Sym: TokNewline}}
+	toks2 := []*Token{{Sym: TokIdent, Val: "a"}}
+
+	result := sameTokens(toks1, toks2)
+
+	a.False(result)
+}
+
+func TestSameTokensDifferent(t *testing.T) {
+	a := assert.New(t)
+	toks1 := []*Token{{Sym: TokIdent, Val: "a"}, {Sym: TokNewline}}
+	toks2 := []*Token{{Sym: TokIdent, Val: "b"}, {Sym: TokNewline}}
+
+	result := sameTokens(toks1, toks2)
+
+	a.False(result)
+}
+
+func TestLexStateMatchesTrue(t *testing.T) {
+	a := assert.New(t)
+	open := &Symbol{Name: "(", Close: ")"}
+	state1 := &LexState{
+		Pos:    FilePos{L: 3, C: 1},
+		Indent: []int{1, 5},
+		Pairs:  []*Token{{Sym: open, Loc: Location{B: FilePos{L: 2, C: 7}}}},
+		Prev:   &Token{Sym: TokNewline},
+	}
+	state2 := &LexState{
+		Pos:    FilePos{L: 7, C: 1},
+		Indent: []int{1, 5},
+		Pairs:  []*Token{{Sym: open, Loc: Location{B: FilePos{L: 6, C: 7}}}},
+		Prev:   &Token{Sym: TokNewline},
+	}
+
+	result := state1.Matches(state2)
+
+	a.True(result)
+}
+
+func TestLexStateMatchesIndentLength(t *testing.T) {
+	a := assert.New(t)
+	state1 := &LexState{Indent: []int{1, 5}}
+	state2 := &LexState{Indent: []int{1}}
+
+	result := state1.Matches(state2)
+
+	a.False(result)
+}
+
+func TestLexStateMatchesIndent(t *testing.T) {
+	a := assert.New(t)
+	state1 := &LexState{Indent: []int{1, 5}}
+	state2 := &LexState{Indent: []int{1, 3}}
+
+	result := state1.Matches(state2)
+
+	a.False(result)
+}
+
+func TestLexStateMatchesLeadsLength(t *testing.T) {
+	a := assert.New(t)
+	state1 := &LexState{Indent: []int{1}, Leads: []string{""}}
+	state2 := &LexState{Indent: []int{1}}
+
+	result := state1.Matches(state2)
+
+	a.False(result)
+}
+
+func TestLexStateMatchesLeads(t *testing.T) {
+	a := assert.New(t)
+	state1 := &LexState{Indent: []int{1, 9}, Leads: []string{"", "\t"}}
+	state2 := &LexState{Indent: []int{1, 9}, Leads: []string{"", "        "}}
+
+	result := state1.Matches(state2)
+
+	a.False(result)
+}
+
+func TestLexStateMatchesPairs(t *testing.T) {
+	a := assert.New(t)
+	state1 := &LexState{Indent: []int{1}, Pairs: []*Token{{Sym: &Symbol{Name: "("}}}}
+	state2 := &LexState{Indent: []int{1}}
+
+	result := state1.Matches(state2)
+
+	a.False(result)
+}
+
+func TestLexStateMatchesModesLength(t *testing.T) {
+	a := assert.New(t)
+	state1 := &LexState{Indent: []int{1}, Modes: []*Mode{{Name: "mode"}}}
+	state2 := &LexState{Indent: []int{1}}
+
+	result := state1.Matches(state2)
+
+	a.False(result)
+}
+
+func TestLexStateMatchesModes(t *testing.T) {
+	a := assert.New(t)
+	state1 := &LexState{Indent: []int{1}, Modes: []*Mode{{Name: "mode", Data: 1}}}
+	state2 := &LexState{Indent: []int{1}, Modes: []*Mode{{Name: "mode", Data: 2}}}
+
+	result := state1.Matches(state2)
+
+	a.False(result)
+}
+
+func TestLexStateMatchesEqualModes(t *testing.T) {
+	a := assert.New(t)
+	state1 := &LexState{Indent: []int{1}, Modes: []*Mode{{Name: "mode", Data: 1}}}
+	state2 := &LexState{Indent: []int{1}, Modes: []*Mode{{Name: "mode", Data: 1}}}
+
+	result := state1.Matches(state2)
+
+	a.True(result)
+}
+
+func TestLexStateMatchesSameModes(t *testing.T) {
+	a := assert.New(t)
+	mode := &Mode{Name: "mode"}
+	state1 := &LexState{Indent: []int{1}, Modes: []*Mode{mode}}
+	state2 := &LexState{Indent: []int{1}, Modes: []*Mode{mode}}
+
+	result := state1.Matches(state2)
+
+	a.True(result)
+}
+
+func TestLexStateMatchesPending(t *testing.T) {
+	a := assert.New(t)
+	state1 := &LexState{Indent: []int{1}, Pending: []*Token{{Sym: TokIndent}}}
+	state2 := &LexState{Indent: []int{1}}
+
+	result := state1.Matches(state2)
+
+	a.False(result)
+}
+
+func TestLexStateMatchesPrev(t *testing.T) {
+	a := assert.New(t)
+	state1 := &LexState{Indent: []int{1}, Prev: &Token{Sym: TokNewline}}
+	state2 := &LexState{Indent: []int{1}}
+
+	result := state1.Matches(state2)
+
+	a.False(result)
+}
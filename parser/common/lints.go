@@ -0,0 +1,49 @@
+// Copyright (c) 2019 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package common
+
+import (
+	"github.com/hydralang/hydra/utils"
+)
+
+// Defined lint checks.  These are lexical checks for questionable
+// constructs that are nevertheless legal; the checks to apply are
+// selected by the Lints field of the Profile.
+const (
+	LintTrailingWS    uint16 = 1 << iota // Trailing whitespace
+	LintFormFeed                         // Form feed in the middle of a line
+	LintTabAfterSpace                    // Tab after space in continuation line
+	LintBackslash                        // Redundant backslash in brackets
+	LintOctalEscape                      // Legacy octal escape
+	LintNonASCIIOp                       // Non-ASCII operator spelling
+	LintBackslashWS                      // Whitespace after backslash
+
+	LintAll = LintTrailingWS | LintFormFeed | LintTabAfterSpace | LintBackslash | LintOctalEscape | LintNonASCIIOp | LintBackslashWS
+)
+
+// LintFlags is a mapping of lint flags to names.
+var LintFlags = utils.FlagSet16{
+	LintTrailingWS:    "trailing whitespace",
+	LintFormFeed:      "mid-line form feed",
+	LintTabAfterSpace: "tab after space",
+	LintBackslash:     "redundant backslash",
+	LintOctalEscape:   "octal escape",
+	LintNonASCIIOp:    "non-ASCII operator",
+	LintBackslashWS:   "whitespace after backslash",
+}
+
+// WarnHook is the type of a function that receives warnings.  It is
+// called with a Diagnostic having warning severity.
+type WarnHook func(diag *Diagnostic)
@@ -0,0 +1,37 @@
+// Copyright (c) 2019 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLintFlags(t *testing.T) {
+	a := assert.New(t)
+
+	result := LintFlags.Flags(LintAll)
+
+	a.Equal([]string{
+		"trailing whitespace",
+		"mid-line form feed",
+		"tab after space",
+		"redundant backslash",
+		"octal escape",
+		"non-ASCII operator",
+		"whitespace after backslash",
+	}, result)
+}
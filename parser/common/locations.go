@@ -16,6 +16,7 @@ package common
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -103,3 +104,165 @@ func (l Location) String() string {
 
 	return text.String()
 }
+
+// Compare compares two file positions.  It returns -1 if this
+// position is before the other position, 1 if it is after, and 0 if
+// the two positions are the same.
+func (p FilePos) Compare(other FilePos) int {
+	switch {
+	case p.L < other.L:
+		return -1
+	case p.L > other.L:
+		return 1
+	case p.C < other.C:
+		return -1
+	case p.C > other.C:
+		return 1
+	}
+
+	return 0
+}
+
+// Before tests if this position comes strictly before another
+// position.
+func (p FilePos) Before(other FilePos) bool {
+	return p.Compare(other) < 0
+}
+
+// Compare compares two locations, providing a total ordering.
+// Locations are ordered first by file name, then by the beginning
+// of the range, and finally by the ending of the range.  It returns
+// -1, 0, or 1, as for FilePos.Compare.
+func (l Location) Compare(other Location) int {
+	switch {
+	case l.File < other.File:
+		return -1
+	case l.File > other.File:
+		return 1
+	}
+
+	if cmp := l.B.Compare(other.B); cmp != 0 {
+		return cmp
+	}
+
+	return l.E.Compare(other.E)
+}
+
+// Less tests if this location sorts before another location, using
+// the total ordering provided by Compare.
+func (l Location) Less(other Location) bool {
+	return l.Compare(other) < 0
+}
+
+// Before tests if this location ends at or before the beginning of
+// another location in the same file; that is, the two ranges do not
+// overlap and this location comes first.
+func (l Location) Before(other Location) bool {
+	return l.File == other.File && !other.B.Before(l.E)
+}
+
+// Contains tests if the specified position falls within the
+// location.  Since the end of a location is exclusive, a position
+// equal to the end is not contained.
+func (l Location) Contains(pos FilePos) bool {
+	return !pos.Before(l.B) && pos.Before(l.E)
+}
+
+// Overlaps tests if this location shares at least one position with
+// another location in the same file.
+func (l Location) Overlaps(other Location) bool {
+	return l.File == other.File && l.B.Before(other.E) && other.B.Before(l.E)
+}
+
+// Union creates a new Location that covers both this location and
+// another Location, including anything that lies between them.
+func (l Location) Union(other Location) Location {
+	// Location can't range across files
+	if l.File != other.File {
+		panic(ErrSplitEntity)
+	}
+
+	// Select the earliest beginning and the latest ending
+	result := l
+	if other.B.Before(result.B) {
+		result.B = other.B
+	}
+	if result.E.Before(other.E) {
+		result.E = other.E
+	}
+
+	return result
+}
+
+// Len computes the length of the location, expressed as the offset
+// that would have to be passed to Advance to move from the beginning
+// of the location to the end.
+func (l Location) Len() FilePos {
+	// Handle locations on a single line
+	if l.B.L == l.E.L {
+		return FilePos{C: l.E.C - l.B.C}
+	}
+
+	// Advance resets the column to 1 on a line change
+	return FilePos{L: l.E.L - l.B.L, C: l.E.C - 1}
+}
+
+// Located is an interface describing an object that has a location,
+// such as a token or an AST node.
+type Located interface {
+	// Location retrieves the location of the object.
+	Location() Location
+}
+
+// LocIndex is a sorted index of located objects.  It is used to map
+// a position within a file, such as a cursor position, to the
+// innermost object containing that position.  Objects in the index
+// are expected to be properly nested, as tokens and AST nodes are.
+type LocIndex struct {
+	objs []Located // The objects, sorted by location
+}
+
+// NewLocIndex constructs a LocIndex from a list of located objects.
+func NewLocIndex(objs ...Located) *LocIndex {
+	idx := &LocIndex{
+		objs: make([]Located, len(objs)),
+	}
+	copy(idx.objs, objs)
+
+	// Sort by beginning; enclosing objects sort before the
+	// objects they enclose
+	sort.SliceStable(idx.objs, func(i, j int) bool {
+		li := idx.objs[i].Location()
+		lj := idx.objs[j].Location()
+		if cmp := li.B.Compare(lj.B); cmp != 0 {
+			return cmp < 0
+		}
+		return lj.E.Before(li.E)
+	})
+
+	return idx
+}
+
+// Len returns the number of objects in the index.
+func (idx *LocIndex) Len() int {
+	return len(idx.objs)
+}
+
+// Lookup finds the innermost object containing the specified
+// position.  Returns nil if no object contains the position.
+func (idx *LocIndex) Lookup(pos FilePos) Located {
+	// Find the first object beginning after the position
+	i := sort.Search(len(idx.objs), func(i int) bool {
+		return pos.Before(idx.objs[i].Location().B)
+	})
+
+	// Scan backwards for the first object containing the
+	// position; due to the sort order, that's the innermost one
+	for i--; i >= 0; i-- {
+		if idx.objs[i].Location().Contains(pos) {
+			return idx.objs[i]
+		}
+	}
+
+	return nil
+}
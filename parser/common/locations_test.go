@@ -175,3 +175,169 @@ func TestLocationString2Lines(t *testing.T) {
 
 	a.Equal("file:3:2-4:2", result)
 }
+
+func TestFilePosCompare(t *testing.T) {
+	a := assert.New(t)
+
+	a.Equal(-1, FilePos{3, 2}.Compare(FilePos{4, 1}))
+	a.Equal(1, FilePos{4, 1}.Compare(FilePos{3, 2}))
+	a.Equal(-1, FilePos{3, 2}.Compare(FilePos{3, 3}))
+	a.Equal(1, FilePos{3, 3}.Compare(FilePos{3, 2}))
+	a.Equal(0, FilePos{3, 2}.Compare(FilePos{3, 2}))
+}
+
+func TestFilePosBefore(t *testing.T) {
+	a := assert.New(t)
+
+	a.True(FilePos{3, 2}.Before(FilePos{3, 3}))
+	a.False(FilePos{3, 2}.Before(FilePos{3, 2}))
+	a.False(FilePos{3, 3}.Before(FilePos{3, 2}))
+}
+
+func TestLocationCompare(t *testing.T) {
+	a := assert.New(t)
+	loc := Location{File: "file", B: FilePos{3, 2}, E: FilePos{3, 5}}
+
+	a.Equal(0, loc.Compare(loc))
+	a.Equal(-1, loc.Compare(Location{File: "other", B: FilePos{1, 1}, E: FilePos{1, 2}}))
+	a.Equal(1, loc.Compare(Location{File: "a", B: FilePos{3, 2}, E: FilePos{3, 5}}))
+	a.Equal(-1, loc.Compare(Location{File: "file", B: FilePos{3, 3}, E: FilePos{3, 4}}))
+	a.Equal(1, loc.Compare(Location{File: "file", B: FilePos{3, 1}, E: FilePos{3, 9}}))
+	a.Equal(-1, loc.Compare(Location{File: "file", B: FilePos{3, 2}, E: FilePos{3, 6}}))
+	a.Equal(1, loc.Compare(Location{File: "file", B: FilePos{3, 2}, E: FilePos{3, 4}}))
+}
+
+func TestLocationLess(t *testing.T) {
+	a := assert.New(t)
+	loc1 := Location{File: "file", B: FilePos{3, 2}, E: FilePos{3, 5}}
+	loc2 := Location{File: "file", B: FilePos{3, 3}, E: FilePos{3, 4}}
+
+	a.True(loc1.Less(loc2))
+	a.False(loc2.Less(loc1))
+	a.False(loc1.Less(loc1))
+}
+
+func TestLocationBefore(t *testing.T) {
+	a := assert.New(t)
+	loc := Location{File: "file", B: FilePos{3, 2}, E: FilePos{3, 5}}
+
+	a.True(loc.Before(Location{File: "file", B: FilePos{3, 5}, E: FilePos{3, 6}}))
+	a.True(loc.Before(Location{File: "file", B: FilePos{4, 1}, E: FilePos{4, 2}}))
+	a.False(loc.Before(Location{File: "file", B: FilePos{3, 4}, E: FilePos{3, 6}}))
+	a.False(loc.Before(Location{File: "file", B: FilePos{1, 1}, E: FilePos{1, 2}}))
+	a.False(loc.Before(Location{File: "other", B: FilePos{4, 1}, E: FilePos{4, 2}}))
+}
+
+func TestLocationContains(t *testing.T) {
+	a := assert.New(t)
+	loc := Location{File: "file", B: FilePos{3, 2}, E: FilePos{4, 3}}
+
+	a.False(loc.Contains(FilePos{3, 1}))
+	a.True(loc.Contains(FilePos{3, 2}))
+	a.True(loc.Contains(FilePos{3, 80}))
+	a.True(loc.Contains(FilePos{4, 2}))
+	a.False(loc.Contains(FilePos{4, 3}))
+}
+
+func TestLocationOverlaps(t *testing.T) {
+	a := assert.New(t)
+	loc := Location{File: "file", B: FilePos{3, 2}, E: FilePos{3, 5}}
+
+	a.True(loc.Overlaps(loc))
+	a.True(loc.Overlaps(Location{File: "file", B: FilePos{3, 4}, E: FilePos{3, 8}}))
+	a.True(loc.Overlaps(Location{File: "file", B: FilePos{3, 1}, E: FilePos{3, 3}}))
+	a.True(loc.Overlaps(Location{File: "file", B: FilePos{3, 3}, E: FilePos{3, 4}}))
+	a.False(loc.Overlaps(Location{File: "file", B: FilePos{3, 5}, E: FilePos{3, 8}}))
+	a.False(loc.Overlaps(Location{File: "file", B: FilePos{3, 1}, E: FilePos{3, 2}}))
+	a.False(loc.Overlaps(Location{File: "other", B: FilePos{3, 3}, E: FilePos{3, 4}}))
+}
+
+func TestLocationUnionBase(t *testing.T) {
+	a := assert.New(t)
+	loc1 := Location{File: "file", B: FilePos{3, 2}, E: FilePos{3, 5}}
+	loc2 := Location{File: "file", B: FilePos{4, 1}, E: FilePos{4, 3}}
+
+	a.Equal(Location{File: "file", B: FilePos{3, 2}, E: FilePos{4, 3}}, loc1.Union(loc2))
+	a.Equal(Location{File: "file", B: FilePos{3, 2}, E: FilePos{4, 3}}, loc2.Union(loc1))
+}
+
+func TestLocationUnionNested(t *testing.T) {
+	a := assert.New(t)
+	loc1 := Location{File: "file", B: FilePos{3, 2}, E: FilePos{3, 9}}
+	loc2 := Location{File: "file", B: FilePos{3, 4}, E: FilePos{3, 6}}
+
+	a.Equal(loc1, loc1.Union(loc2))
+	a.Equal(loc1, loc2.Union(loc1))
+}
+
+func TestLocationUnionSplit(t *testing.T) {
+	a := assert.New(t)
+	loc1 := Location{File: "file", B: FilePos{3, 2}, E: FilePos{3, 3}}
+	loc2 := Location{File: "other", B: FilePos{3, 5}, E: FilePos{3, 6}}
+
+	a.PanicsWithValue(ErrSplitEntity, func() { loc1.Union(loc2) })
+}
+
+func TestLocationLenColumns(t *testing.T) {
+	a := assert.New(t)
+	loc := Location{File: "file", B: FilePos{3, 2}, E: FilePos{3, 5}}
+
+	result := loc.Len()
+
+	a.Equal(FilePos{C: 3}, result)
+}
+
+func TestLocationLenLines(t *testing.T) {
+	a := assert.New(t)
+	loc := Location{File: "file", B: FilePos{3, 2}, E: FilePos{5, 4}}
+
+	result := loc.Len()
+
+	a.Equal(FilePos{L: 2, C: 3}, result)
+	check := Location{File: "file", E: loc.B}
+	check.Advance(result)
+	a.Equal(loc.E, check.E)
+}
+
+type locatedTest struct {
+	name string
+	loc  Location
+}
+
+func (l *locatedTest) Location() Location {
+	return l.loc
+}
+
+func TestNewLocIndex(t *testing.T) {
+	a := assert.New(t)
+	inner := &locatedTest{"inner", Location{File: "file", B: FilePos{1, 5}, E: FilePos{1, 7}}}
+	outer := &locatedTest{"outer", Location{File: "file", B: FilePos{1, 5}, E: FilePos{1, 9}}}
+	first := &locatedTest{"first", Location{File: "file", B: FilePos{1, 1}, E: FilePos{1, 2}}}
+
+	result := NewLocIndex(inner, outer, first)
+
+	a.Equal(3, result.Len())
+	a.Equal([]Located{first, outer, inner}, result.objs)
+}
+
+func TestLocIndexLookup(t *testing.T) {
+	a := assert.New(t)
+	stmt := &locatedTest{"stmt", Location{File: "file", B: FilePos{1, 1}, E: FilePos{2, 5}}}
+	call := &locatedTest{"call", Location{File: "file", B: FilePos{1, 5}, E: FilePos{1, 12}}}
+	name := &locatedTest{"name", Location{File: "file", B: FilePos{1, 5}, E: FilePos{1, 8}}}
+	arg := &locatedTest{"arg", Location{File: "file", B: FilePos{1, 9}, E: FilePos{1, 11}}}
+	tail := &locatedTest{"tail", Location{File: "file", B: FilePos{2, 1}, E: FilePos{2, 3}}}
+	idx := NewLocIndex(tail, arg, name, call, stmt)
+
+	a.Equal(stmt, idx.Lookup(FilePos{1, 1}))
+	a.Equal(name, idx.Lookup(FilePos{1, 5}))
+	a.Equal(name, idx.Lookup(FilePos{1, 7}))
+	a.Equal(call, idx.Lookup(FilePos{1, 8}))
+	a.Equal(arg, idx.Lookup(FilePos{1, 10}))
+	a.Equal(call, idx.Lookup(FilePos{1, 11}))
+	a.Equal(stmt, idx.Lookup(FilePos{1, 40}))
+	a.Equal(tail, idx.Lookup(FilePos{2, 2}))
+	a.Equal(stmt, idx.Lookup(FilePos{2, 4}))
+	a.Nil(idx.Lookup(FilePos{2, 5}))
+	a.Nil(idx.Lookup(FilePos{3, 1}))
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2019 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/message/catalog"
+)
+
+// Message formats for diagnostics that take arguments.
+const (
+	msgBadIndent    = "inconsistent indentation: expected column %s, got %d"
+	msgUnclosedOp   = "unexpected EOF; expected \"%s\""
+	msgUnopenedOp   = "unexpected close operator \"%s\""
+	msgMismatchedOp = "close operator \"%s\" does not match open operator \"%s\" at %s"
+)
+
+// messages maps diagnostic codes to the English message formats.
+// Messages for diagnostics that take no arguments are the text of the
+// corresponding sentinel error, and are added by init.
+var messages = map[string]string{
+	CodeBadIndent:    msgBadIndent,
+	CodeUnclosedOp:   msgUnclosedOp,
+	CodeUnopenedOp:   msgUnopenedOp,
+	CodeMismatchedOp: msgMismatchedOp,
+}
+
+// Messages is the catalog of diagnostic messages, keyed by diagnostic
+// code.  It contains the English messages; translations may be added
+// using LoadMessages, or directly using the catalog.Builder methods.
+var Messages = catalog.NewBuilder(catalog.Fallback(language.English))
+
+// init completes the messages map and populates the Messages catalog
+// with the English messages.
+func init() {
+	for _, entry := range codes {
+		if _, ok := messages[entry.code]; !ok {
+			messages[entry.code] = entry.err.Error()
+		}
+	}
+
+	for code, msg := range messages {
+		Messages.SetString(language.English, code, msg)
+	}
+}
+
+// Localize returns the message of the diagnostic, translated into the
+// specified language using the Messages catalog.  If the catalog does
+// not contain the diagnostic code, the diagnostic message is returned
+// unchanged.  The message is also returned unchanged if it has no
+// arguments but differs from the catalog message, e.g., if it was
+// built by wrapping a sentinel error with additional detail, so that
+// the detail is not lost.
+func (d *Diagnostic) Localize(tag language.Tag) string {
+	if msg, ok := messages[d.Code]; !ok || len(d.Args) == 0 && d.Msg != msg {
+		return d.Msg
+	}
+
+	// The printer returns the key if there is no translation for
+	// the selected language; fall back to English in that case
+	p := message.NewPrinter(tag, message.Catalog(Messages))
+	if msg := p.Sprintf(d.Code, d.Args...); msg != d.Code {
+		return msg
+	}
+
+	return fmt.Sprintf(messages[d.Code], d.Args...)
+}
+
+// catalogEntry describes a single message in a catalog file.
+type catalogEntry struct {
+	ID          string `json:"id"`          // The diagnostic code
+	Message     string `json:"message"`     // The English message
+	Translation string `json:"translation"` // The translated message
+}
+
+// catalogFile describes a catalog file.  The format is compatible
+// with the message files used by the gotext tool.
+type catalogFile struct {
+	Language string         `json:"language"` // The language tag
+	Messages []catalogEntry `json:"messages"` // The messages
+}
+
+// ExtractMessages writes the diagnostic messages to the specified
+// writer as a JSON catalog file for translation into the specified
+// language.  Each entry contains the diagnostic code as its ID and
+// the English message; the translations are filled in only if the
+// language is English.
+func ExtractMessages(w io.Writer, tag language.Tag) error {
+	// Sort the codes for a stable output
+	ids := make([]string, 0, len(messages))
+	for code := range messages {
+		ids = append(ids, code)
+	}
+	sort.Strings(ids)
+
+	// Construct the catalog file
+	file := catalogFile{Language: tag.String()}
+	for _, id := range ids {
+		entry := catalogEntry{ID: id, Message: messages[id]}
+		if tag == language.English {
+			entry.Translation = entry.Message
+		}
+		file.Messages = append(file.Messages, entry)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+	return enc.Encode(file)
+}
+
+// LoadMessages reads a JSON catalog file, as produced by
+// ExtractMessages and filled in by a translator, and adds its
+// translations to the Messages catalog.  Entries with no translation
+// are ignored.
+func LoadMessages(r io.Reader) error {
+	var file catalogFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return err
+	}
+
+	tag, err := language.Parse(file.Language)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range file.Messages {
+		if entry.Translation == "" {
+			continue
+		}
+		if err := Messages.SetString(tag, entry.ID, entry.Translation); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
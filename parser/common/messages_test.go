@@ -0,0 +1,153 @@
+// Copyright (c) 2019 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
+)
+
+func TestMessages(t *testing.T) {
+	a := assert.New(t)
+
+	a.Equal(msgUnclosedOp, messages[CodeUnclosedOp])
+	a.Equal("unclosed string literal", messages[CodeUnclosedStr])
+	a.Len(messages, len(codes))
+}
+
+func TestDiagnosticLocalizeEnglish(t *testing.T) {
+	a := assert.New(t)
+	diag := ErrNoOpen(&Symbol{Name: ")"})
+
+	result := diag.Localize(language.English)
+
+	a.Equal("unexpected close operator \")\"", result)
+}
+
+func TestDiagnosticLocalizeFallback(t *testing.T) {
+	a := assert.New(t)
+	diag := Diagnose(ErrBadOp, Location{})
+
+	result := diag.Localize(language.Japanese)
+
+	a.Equal("bad operator character", result)
+}
+
+func TestDiagnosticLocalizeTranslated(t *testing.T) {
+	a := assert.New(t)
+	Messages.SetString(language.German, CodeUnopenedOp, "unerwarteter schließender Operator \"%s\"")
+	diag := ErrNoOpen(&Symbol{Name: ")"})
+
+	result := diag.Localize(language.German)
+
+	a.Equal("unerwarteter schließender Operator \")\"", result)
+}
+
+func TestDiagnosticLocalizeWrapped(t *testing.T) {
+	a := assert.New(t)
+	diag := Diagnose(fmt.Errorf("%w %q", ErrBadIdent, "x$"), Location{})
+
+	result := diag.Localize(language.English)
+
+	a.Equal("bad identifier character \"x$\"", result)
+}
+
+func TestDiagnosticLocalizeUnknown(t *testing.T) {
+	a := assert.New(t)
+	diag := Diagnose(assert.AnError, Location{})
+
+	result := diag.Localize(language.English)
+
+	a.Equal(assert.AnError.Error(), result)
+}
+
+func TestExtractMessagesEnglish(t *testing.T) {
+	a := assert.New(t)
+	buf := &bytes.Buffer{}
+
+	err := ExtractMessages(buf, language.English)
+
+	a.NoError(err)
+	a.Contains(buf.String(), `"language": "en"`)
+	a.Contains(buf.String(), `{
+            "id": "H0109",
+            "message": "unclosed string literal",
+            "translation": "unclosed string literal"
+        }`)
+	a.True(strings.Index(buf.String(), `"H0001"`) < strings.Index(buf.String(), `"H0101"`))
+}
+
+func TestExtractMessagesOther(t *testing.T) {
+	a := assert.New(t)
+	buf := &bytes.Buffer{}
+
+	err := ExtractMessages(buf, language.Spanish)
+
+	a.NoError(err)
+	a.Contains(buf.String(), `"language": "es"`)
+	a.Contains(buf.String(), `{
+            "id": "H0109",
+            "message": "unclosed string literal",
+            "translation": ""
+        }`)
+}
+
+func TestLoadMessages(t *testing.T) {
+	a := assert.New(t)
+	src := strings.NewReader(`{
+    "language": "it",
+    "messages": [
+        {
+            "id": "H0109",
+            "message": "unclosed string literal",
+            "translation": "stringa letterale non chiusa"
+        },
+        {
+            "id": "H0110",
+            "message": "bad identifier character",
+            "translation": ""
+        }
+    ]
+}`)
+
+	err := LoadMessages(src)
+
+	a.NoError(err)
+	a.Equal("stringa letterale non chiusa", Diagnose(ErrUnclosedStr, Location{}).Localize(language.Italian))
+	a.Equal("bad identifier character", Diagnose(ErrBadIdent, Location{}).Localize(language.Italian))
+}
+
+func TestLoadMessagesBadJSON(t *testing.T) {
+	a := assert.New(t)
+	src := strings.NewReader(`{"language": `)
+
+	err := LoadMessages(src)
+
+	a.Error(err)
+}
+
+func TestLoadMessagesBadLanguage(t *testing.T) {
+	a := assert.New(t)
+	src := strings.NewReader(`{"language": "not a language!", "messages": []}`)
+
+	err := LoadMessages(src)
+
+	a.Error(err)
+}
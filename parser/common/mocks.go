@@ -44,8 +44,9 @@ type MockLexer struct {
 // Next retrieves the next token from the scanner.  If the end of file
 // is reached, an EOF token is returned; if an error occurs while
 // scanning or lexically analyzing the file, an error token is
-// returned with the error as the token's semantic value.  After
-// either an EOF token or an error token, nil will be returned.
+// returned with a *Diagnostic describing the error as the token's
+// semantic value.  After either an EOF token or an error token, nil
+// will be returned.
 func (m *MockLexer) Next() *Token {
 	args := m.MethodCalled("Next")
 
@@ -57,6 +58,44 @@ func (m *MockLexer) Next() *Token {
 	return tok.(*Token)
 }
 
+// Peek retrieves a token from the lexer without consuming it.  The
+// argument is the number of tokens to look ahead; Peek(0) returns the
+// token that the next call to Next will return.  If there are not
+// enough tokens, such as when looking past an EOF token or an error
+// token, nil is returned.
+func (m *MockLexer) Peek(n int) *Token {
+	args := m.MethodCalled("Peek", n)
+
+	tok := args.Get(0)
+	if tok == nil {
+		return nil
+	}
+
+	return tok.(*Token)
+}
+
+// Snapshot captures the state of the lexer, including the position
+// of the next character to lex.
+func (m *MockLexer) Snapshot() *LexState {
+	args := m.MethodCalled("Snapshot")
+
+	state := args.Get(0)
+	if state == nil {
+		return nil
+	}
+
+	return state.(*LexState)
+}
+
+// Restore restores the lexer to a state captured by Snapshot.  The
+// next character of the lexer's source must be at the position of
+// the snapshot.
+func (m *MockLexer) Restore(state *LexState) error {
+	args := m.MethodCalled("Restore", state)
+
+	return args.Error(0)
+}
+
 // Push pushes a single token back onto the lexer.  Any number of
 // tokens may be pushed back.
 func (m *MockLexer) Push(tok *Token) {
@@ -70,6 +70,61 @@ func TestMockLexerNextNil(t *testing.T) {
 	l.AssertExpectations(t)
 }
 
+func TestMockLexerPeekToken(t *testing.T) {
+	a := assert.New(t)
+	l := &MockLexer{}
+	l.On("Peek", 2).Return(&Token{Sym: &Symbol{Name: "sym"}})
+
+	result := l.Peek(2)
+
+	a.Equal(&Token{Sym: &Symbol{Name: "sym"}}, result)
+	l.AssertExpectations(t)
+}
+
+func TestMockLexerPeekNil(t *testing.T) {
+	a := assert.New(t)
+	l := &MockLexer{}
+	l.On("Peek", 2).Return(nil)
+
+	result := l.Peek(2)
+
+	a.Nil(result)
+	l.AssertExpectations(t)
+}
+
+func TestMockLexerSnapshotState(t *testing.T) {
+	a := assert.New(t)
+	l := &MockLexer{}
+	l.On("Snapshot").Return(&LexState{Indent: []int{1}})
+
+	result := l.Snapshot()
+
+	a.Equal(&LexState{Indent: []int{1}}, result)
+	l.AssertExpectations(t)
+}
+
+func TestMockLexerSnapshotNil(t *testing.T) {
+	a := assert.New(t)
+	l := &MockLexer{}
+	l.On("Snapshot").Return(nil)
+
+	result := l.Snapshot()
+
+	a.Nil(result)
+	l.AssertExpectations(t)
+}
+
+func TestMockLexerRestore(t *testing.T) {
+	a := assert.New(t)
+	l := &MockLexer{}
+	l.On("Restore", &LexState{Indent: []int{1}}).Return(assert.AnError)
+
+	err := l.Restore(&LexState{Indent: []int{1}})
+
+	a.Equal(assert.AnError, err)
+	l.AssertExpectations(t)
+}
+
 func TestMockLexerPush(t *testing.T) {
 	l := &MockLexer{}
 	l.On("Push", &Token{Sym: &Symbol{Name: "sym"}})
@@ -0,0 +1,47 @@
+// Copyright (c) 2019 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package common
+
+// Mode flags.  These alter the way the lexer handles newlines,
+// indentation, and whitespace while the mode is active.
+const (
+	ModeIgnoreNL uint8 = 1 << iota // Newlines are whitespace
+	ModeNoIndent                   // Indentation is not tracked
+	ModeKeepWS                     // Whitespace goes to recognizers
+	ModeStopNL                     // Newlines go to recognizers, even in brackets
+)
+
+// Mode describes a lexical mode.  A recognizer may push a mode onto
+// the lexer's mode stack, such as when it recognizes the beginning of
+// an interpolated string or an embedded template, and pop it when it
+// recognizes the terminator.  While the mode is active, the lexer
+// selects recognizers from the mode's registry, falling back to the
+// profile's registry for characters to which none of the mode's
+// recognizers apply, and handles newlines, indentation, and
+// whitespace according to the mode's flags.  The mode may carry data
+// for its recognizers, such as the quote character of an
+// interpolated string; the data should be a comparable value, rather
+// than a pointer, so that lexer states may be compared.
+type Mode struct {
+	Name        string       // Name of the mode, for errors
+	Recognizers *Recognizers // Recognizer registry; may be nil
+	Flags       uint8        // Mode flags
+	Data        interface{}  // Data for the recognizers
+}
+
+// String returns the name of the mode.
+func (m *Mode) String() string {
+	return m.Name
+}
@@ -16,6 +16,7 @@ package common
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"unicode/utf8"
 
@@ -24,24 +25,31 @@ import (
 
 // Operators is a structure for describing an operator tree.  The
 // lexer uses the operator tree to match operators, while allowing for
-// backtracking; this enables selecting the longest match.
+// backtracking; this enables selecting the longest match.  An
+// operator may also be reachable through aliases, alternate spellings
+// that produce the same symbol.
 type Operators struct {
 	prefix   string              // The operator prefix at this node
 	Sym      *Symbol             // The operator at this node
 	root     *Operators          // Root of the operator tree
 	parent   *Operators          // Parent of this node
 	children map[rune]*Operators // Tree node children
+	frozen   bool                // Tree may not be modified
 }
 
 // NewOperators constructs an Operators tree with all the specified
-// operators.
+// operators.  This is intended for constructing static operator
+// tables, and so panics with the error if an operator can't be
+// added.
 func NewOperators(ops ...*Symbol) *Operators {
 	// Construct the root
 	root := &Operators{children: map[rune]*Operators{}}
 
 	// Add each of the symbols to it
 	for _, op := range ops {
-		root.Add(op)
+		if err := root.Add(op); err != nil {
+			panic(err)
+		}
 	}
 
 	return root
@@ -74,11 +82,32 @@ func (o *Operators) doCopy(root, parent *Operators) *Operators {
 
 // Copy constructs a copy of this Operators tree.  The copy will
 // contain just the subtree rooted at this node, if this node is not
-// the root.
+// the root.  The copy is never frozen.
 func (o *Operators) Copy() *Operators {
 	return o.doCopy(nil, nil)
 }
 
+// Freeze freezes the operator tree, so that it may no longer be
+// modified.  A frozen operator tree may be safely used by several
+// lexers at once.
+func (o *Operators) Freeze() {
+	if o.root != nil {
+		o.root.Freeze()
+		return
+	}
+
+	o.frozen = true
+}
+
+// Frozen tests whether the operator tree has been frozen.
+func (o *Operators) Frozen() bool {
+	if o.root != nil {
+		return o.root.Frozen()
+	}
+
+	return o.frozen
+}
+
 // prune removes empty nodes of the operator tree.
 func (o *Operators) prune() {
 	// Step through the tree towards the root
@@ -100,27 +129,28 @@ func (o *Operators) prune() {
 	}
 }
 
-// Add adds an operator to the operator tree.
-func (o *Operators) Add(op *Symbol) {
-	// Delegate to the root
-	if o.root != nil {
-		o.root.Add(op)
-		return
+// checkName checks that an operator name is valid.
+func checkName(name string) error {
+	if name == "" {
+		return ErrBadOpName
+	} else if !utf8.ValidString(name) {
+		return ErrBadRune
 	}
 
-	// Scan through symbol name rune by rune
-	pos := 0
-	node := o
-	for pos < len(op.Name) {
-		// Grab next rune
-		r, w := utf8.DecodeRuneInString(op.Name[pos:])
-		if r == utf8.RuneError && w == 1 {
-			panic(ErrBadRune)
-		}
+	return nil
+}
 
-		// Advance the text position
-		pos += w
+// add adds an operator to the operator tree under the specified
+// name, which is either the operator name or an alias.
+func (o *Operators) add(name string, op *Symbol) error {
+	// Check the name
+	if err := checkName(name); err != nil {
+		return err
+	}
 
+	// Scan through the name rune by rune
+	node := o
+	for pos, r := range name {
 		// Make sure the children map exists
 		if node.children == nil {
 			node.children = map[rune]*Operators{}
@@ -132,7 +162,7 @@ func (o *Operators) Add(op *Symbol) {
 		} else {
 			// Construct a new one
 			tmp = &Operators{
-				prefix:   op.Name[:pos],
+				prefix:   name[:pos+utf8.RuneLen(r)],
 				root:     o,
 				parent:   node,
 				children: map[rune]*Operators{},
@@ -143,64 +173,147 @@ func (o *Operators) Add(op *Symbol) {
 	}
 
 	// Is the operator already set?
-	if node.Sym != nil {
-		return
+	if node.Sym == op {
+		return ErrDuplicateOp
+	} else if node.Sym != nil {
+		return ErrOpConflict
 	}
 
 	// Save the symbol
 	node.Sym = op
+	return nil
 }
 
-// Remove removes an operator from the operator tree.
-func (o *Operators) Remove(op *Symbol) {
+// Add adds an operator to the operator tree.  Returns ErrBadOpName if
+// the operator name is empty, ErrBadRune if it is not valid UTF-8,
+// ErrDuplicateOp if the operator has already been added,
+// ErrOpConflict if a different operator with the same name has been
+// added, or ErrFrozen if the tree has been frozen.
+func (o *Operators) Add(op *Symbol) error {
 	// Delegate to the root
 	if o.root != nil {
-		o.root.Remove(op)
-		return
+		return o.root.Add(op)
+	} else if o.frozen {
+		return ErrFrozen
+	}
+
+	return o.add(op.Name, op)
+}
+
+// AddAlias adds an alias for an operator to the operator tree.  An
+// alias is an alternate spelling of the operator, such as "≤" for
+// "<="; the lexer produces the operator's symbol when it recognizes
+// the alias.  Returns ErrNoSuchOp if the operator has not been added
+// to the tree, or the same errors as Add.
+func (o *Operators) AddAlias(alias string, op *Symbol) error {
+	// Delegate to the root
+	if o.root != nil {
+		return o.root.AddAlias(alias, op)
+	} else if o.frozen {
+		return ErrFrozen
 	}
 
-	// Scan through symbol name rune by rune
-	pos := 0
+	// Make sure the operator is in the tree
 	node := o
-	for pos < len(op.Name) {
-		// Grab next rune
-		r, w := utf8.DecodeRuneInString(op.Name[pos:])
-		if r == utf8.RuneError && w == 1 {
-			panic(ErrBadRune)
+	for _, r := range op.Name {
+		if node = node.Next(r); node == nil {
+			return ErrNoSuchOp
 		}
+	}
+	if node.Sym != op {
+		return ErrNoSuchOp
+	}
 
-		// Advance the text position
-		pos += w
+	return o.add(alias, op)
+}
 
-		// Make sure the children map exists
-		if node.children == nil {
-			node.children = map[rune]*Operators{}
-		}
+// remove removes an operator from the operator tree under the
+// specified name, which is either the operator name or an alias.
+func (o *Operators) remove(name string, op *Symbol) error {
+	// Check the name
+	if err := checkName(name); err != nil {
+		return err
+	}
 
+	// Scan through the name rune by rune
+	node := o
+	for _, r := range name {
 		// Find the next node
-		if tmp, ok := node.children[r]; ok {
-			node = tmp
-		} else {
+		if node = node.Next(r); node == nil {
 			// Operator isn't in tree
-			return
+			return ErrNoSuchOp
 		}
 	}
 
+	// Make sure it's the right operator
+	if node.Sym == nil {
+		return ErrNoSuchOp
+	} else if op != nil && node.Sym != op {
+		return ErrOpConflict
+	}
+
 	// Blank the operator
 	node.Sym = nil
 
 	// Prune the node back
 	node.prune()
+	return nil
+}
+
+// Remove removes an operator, along with all its aliases, from the
+// operator tree.  Returns ErrBadOpName if the operator name is empty,
+// ErrBadRune if it is not valid UTF-8, ErrNoSuchOp if the operator is
+// not in the tree, ErrOpConflict if a different operator with the
+// same name is in the tree, or ErrFrozen if the tree has been frozen.
+func (o *Operators) Remove(op *Symbol) error {
+	// Delegate to the root
+	if o.root != nil {
+		return o.root.Remove(op)
+	} else if o.frozen {
+		return ErrFrozen
+	}
+
+	if err := o.remove(op.Name, op); err != nil {
+		return err
+	}
+
+	// Remove the aliases
+	for _, alias := range o.Aliases(op) {
+		o.remove(alias, op)
+	}
+
+	return nil
+}
+
+// RemoveAlias removes an alias from the operator tree.  Returns
+// ErrBadOpName if the alias is empty, ErrBadRune if it is not valid
+// UTF-8, ErrNoSuchOp if the alias is not in the tree, or ErrFrozen if
+// the tree has been frozen.
+func (o *Operators) RemoveAlias(alias string) error {
+	// Delegate to the root
+	if o.root != nil {
+		return o.root.RemoveAlias(alias)
+	} else if o.frozen {
+		return ErrFrozen
+	}
+
+	// Make sure it's an alias
+	node := o
+	for _, r := range alias {
+		if node = node.Next(r); node == nil {
+			return ErrNoSuchOp
+		}
+	}
+	if !node.IsAlias() {
+		return ErrNoSuchOp
+	}
+
+	return o.remove(alias, nil)
 }
 
 // Next looks up the next node in the tree, given an operator rune.
 // Returns nil if no corresponding node exists in the tree.
 func (o *Operators) Next(r rune) *Operators {
-	// Make sure the children map exists
-	if o.children == nil {
-		o.children = map[rune]*Operators{}
-	}
-
 	// See if the rune's in the tree
 	child, ok := o.children[r]
 	if !ok {
@@ -210,6 +323,94 @@ func (o *Operators) Next(r rune) *Operators {
 	return child
 }
 
+// sortedChildren returns the children of the node, sorted by rune.
+func (o *Operators) sortedChildren() []*Operators {
+	runes := make([]rune, 0, len(o.children))
+	for r := range o.children {
+		runes = append(runes, r)
+	}
+	sort.Slice(runes, func(i, j int) bool {
+		return runes[i] < runes[j]
+	})
+
+	result := make([]*Operators, len(runes))
+	for i, r := range runes {
+		result[i] = o.children[r]
+	}
+
+	return result
+}
+
+// walkNodes calls a function with each node of the tree rooted at
+// this node that has a symbol, in order by spelling.  If the function
+// returns an error, the walk is stopped and the error returned.
+func (o *Operators) walkNodes(fn func(node *Operators) error) error {
+	if o.Sym != nil {
+		if err := fn(o); err != nil {
+			return err
+		}
+	}
+
+	for _, child := range o.sortedChildren() {
+		if err := child.walkNodes(fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Walk calls a function with each operator in the tree rooted at this
+// node, in order by name.  Aliases are not included.  If the function
+// returns an error, the walk is stopped and the error returned.
+func (o *Operators) Walk(fn func(sym *Symbol) error) error {
+	return o.walkNodes(func(node *Operators) error {
+		if node.IsAlias() {
+			return nil
+		}
+
+		return fn(node.Sym)
+	})
+}
+
+// All returns a list of the operators in the tree rooted at this
+// node, in order by name.
+func (o *Operators) All() []*Symbol {
+	var result []*Symbol
+	o.Walk(func(sym *Symbol) error {
+		result = append(result, sym)
+		return nil
+	})
+
+	return result
+}
+
+// Aliases returns a list of the aliases of an operator in the tree
+// rooted at this node, in order.
+func (o *Operators) Aliases(op *Symbol) []string {
+	var result []string
+	o.walkNodes(func(node *Operators) error {
+		if node.Sym == op && node.IsAlias() {
+			result = append(result, node.prefix)
+		}
+		return nil
+	})
+
+	return result
+}
+
+// Spelling returns the spelling of the operator prefix at this node.
+// For a node with a symbol, this is the name of the operator, or the
+// alias.
+func (o *Operators) Spelling() string {
+	return o.prefix
+}
+
+// IsAlias tests whether the node is for an alias of its operator.
+func (o *Operators) IsAlias() bool {
+	return o.Sym != nil && o.prefix != o.Sym.Name
+}
+
 // String outputs the operator tree node as a string.
 func (o *Operators) String() string {
 	text := &strings.Builder{}
@@ -231,21 +432,15 @@ func (o *Operators) String() string {
 }
 
 // Children implements the utils.Visitable interface, allowing an
-// operator tree to be visualized using utils.Visualize().
+// operator tree to be visualized using utils.Visualize().  The
+// children are returned in order by rune, so that the visualization
+// is deterministic.
 func (o *Operators) Children() []utils.Visitable {
-	// Make sure the children map exists
-	if o.children == nil {
-		o.children = map[rune]*Operators{}
-	}
-
 	// Construct the returned visitables
-	result := make([]utils.Visitable, len(o.children))
-
-	// Add the children to the list
-	i := 0
-	for _, child := range o.children {
+	children := o.sortedChildren()
+	result := make([]utils.Visitable, len(children))
+	for i, child := range children {
 		result[i] = child
-		i++
 	}
 
 	return result
@@ -55,6 +55,14 @@ func TestNewOperators(t *testing.T) {
 	a.Equal(0, len(op1.children))
 }
 
+func TestNewOperatorsConflict(t *testing.T) {
+	a := assert.New(t)
+
+	a.PanicsWithValue(ErrOpConflict, func() {
+		NewOperators(&Symbol{Name: "="}, &Symbol{Name: "="})
+	})
+}
+
 func TestOperatorsCopy(t *testing.T) {
 	a := assert.New(t)
 	sym1 := &Symbol{Name: "<"}
@@ -224,8 +232,9 @@ func TestOperatorsAddAbsent(t *testing.T) {
 	node.root = tree
 	node.parent = tree
 
-	tree.Add(sym)
+	err := tree.Add(sym)
 
+	a.NoError(err)
 	a.Equal("", tree.prefix)
 	a.Nil(tree.Sym)
 	a.Equal(map[rune]*Operators{
@@ -264,8 +273,9 @@ func TestOperatorsAddPresent(t *testing.T) {
 	node.root = tree
 	node.parent = tree
 
-	tree.Add(sym)
+	err := tree.Add(sym)
 
+	a.Equal(ErrOpConflict, err)
 	a.Equal("", tree.prefix)
 	a.Nil(tree.Sym)
 	a.Equal(map[rune]*Operators{
@@ -280,15 +290,39 @@ func TestOperatorsAddPresent(t *testing.T) {
 	a.NotEqual(sym, child.Sym)
 }
 
+func TestOperatorsAddDuplicate(t *testing.T) {
+	a := assert.New(t)
+	sym := &Symbol{Name: "=="}
+	tree := NewOperators(sym)
+
+	err := tree.Add(sym)
+
+	a.Equal(ErrDuplicateOp, err)
+	a.Equal([]*Symbol{sym}, tree.All())
+}
+
 func TestOperatorsAddBadRune(t *testing.T) {
 	a := assert.New(t)
 	sym := &Symbol{Name: string([]byte{'=', '\xff'})}
 	tree := &Operators{}
 
-	a.PanicsWithValue(ErrBadRune, func() { tree.Add(sym) })
+	err := tree.Add(sym)
 
+	a.Equal(ErrBadRune, err)
 	a.Equal("", tree.prefix)
 	a.Nil(tree.Sym)
+	a.Nil(tree.children)
+}
+
+func TestOperatorsAddEmpty(t *testing.T) {
+	a := assert.New(t)
+	sym := &Symbol{}
+	tree := &Operators{}
+
+	err := tree.Add(sym)
+
+	a.Equal(ErrBadOpName, err)
+	a.Nil(tree.Sym)
 }
 
 func TestOperatorsAddDelegate(t *testing.T) {
@@ -305,8 +339,9 @@ func TestOperatorsAddDelegate(t *testing.T) {
 	node.root = tree
 	node.parent = tree
 
-	node.Add(sym)
+	err := node.Add(sym)
 
+	a.NoError(err)
 	a.Equal("", tree.prefix)
 	a.Nil(tree.Sym)
 	a.Equal(map[rune]*Operators{
@@ -337,8 +372,9 @@ func TestOperatorsRemoveAbsent(t *testing.T) {
 	node.root = tree
 	node.parent = tree
 
-	tree.Remove(sym)
+	err := tree.Remove(sym)
 
+	a.Equal(ErrNoSuchOp, err)
 	a.Equal("", tree.prefix)
 	a.Nil(tree.Sym)
 	a.Equal(map[rune]*Operators{
@@ -346,8 +382,7 @@ func TestOperatorsRemoveAbsent(t *testing.T) {
 	}, tree.children)
 	a.Equal("=", node.prefix)
 	a.Equal(&Symbol{Name: "="}, node.Sym)
-	a.NotNil(node.children)
-	a.Equal(map[rune]*Operators{}, node.children)
+	a.Nil(node.children)
 }
 
 func TestOperatorsRemovePresent(t *testing.T) {
@@ -374,8 +409,9 @@ func TestOperatorsRemovePresent(t *testing.T) {
 	node.root = tree
 	node.parent = tree
 
-	tree.Remove(sym)
+	err := tree.Remove(sym)
 
+	a.NoError(err)
 	a.Equal("", tree.prefix)
 	a.Nil(tree.Sym)
 	a.Equal(map[rune]*Operators{
@@ -410,8 +446,9 @@ func TestOperatorsRemovePrune(t *testing.T) {
 	node.root = tree
 	node.parent = tree
 
-	tree.Remove(sym)
+	err := tree.Remove(sym)
 
+	a.NoError(err)
 	a.Equal("", tree.prefix)
 	a.Nil(tree.Sym)
 	a.Equal(map[rune]*Operators{}, tree.children)
@@ -431,12 +468,44 @@ func TestRemoveBadRune(t *testing.T) {
 	node.root = tree
 	node.parent = tree
 
-	a.PanicsWithValue(ErrBadRune, func() { tree.Remove(sym) })
+	err := tree.Remove(sym)
 
+	a.Equal(ErrBadRune, err)
 	a.Equal("", tree.prefix)
 	a.Nil(tree.Sym)
 }
 
+func TestOperatorsRemoveEmpty(t *testing.T) {
+	a := assert.New(t)
+	tree := NewOperators(&Symbol{Name: "="})
+
+	err := tree.Remove(&Symbol{})
+
+	a.Equal(ErrBadOpName, err)
+}
+
+func TestOperatorsRemoveNoSym(t *testing.T) {
+	a := assert.New(t)
+	sym := &Symbol{Name: "="}
+	tree := NewOperators(&Symbol{Name: "=="})
+
+	err := tree.Remove(sym)
+
+	a.Equal(ErrNoSuchOp, err)
+	a.Len(tree.All(), 1)
+}
+
+func TestOperatorsRemoveConflict(t *testing.T) {
+	a := assert.New(t)
+	sym := &Symbol{Name: "=="}
+	tree := NewOperators(&Symbol{Name: "=="})
+
+	err := tree.Remove(sym)
+
+	a.Equal(ErrOpConflict, err)
+	a.Len(tree.All(), 1)
+}
+
 func TestOperatorsRemoveDelegate(t *testing.T) {
 	a := assert.New(t)
 	sym := &Symbol{Name: "=="}
@@ -461,8 +530,9 @@ func TestOperatorsRemoveDelegate(t *testing.T) {
 	node.root = tree
 	node.parent = tree
 
-	node.Remove(sym)
+	err := node.Remove(sym)
 
+	a.NoError(err)
 	a.Equal("", tree.prefix)
 	a.Nil(tree.Sym)
 	a.Equal(map[rune]*Operators{
@@ -474,6 +544,137 @@ func TestOperatorsRemoveDelegate(t *testing.T) {
 	a.Equal(map[rune]*Operators{}, node.children)
 }
 
+func TestOperatorsRemoveAliases(t *testing.T) {
+	a := assert.New(t)
+	le := &Symbol{Name: "<="}
+	lt := &Symbol{Name: "<"}
+	tree := NewOperators(le, lt)
+	tree.AddAlias("≤", le)
+	tree.AddAlias("=<", le)
+
+	err := tree.Remove(le)
+
+	a.NoError(err)
+	a.Equal([]*Symbol{lt}, tree.All())
+	a.Nil(tree.Next('≤'))
+	a.Nil(tree.Next('='))
+}
+
+func TestOperatorsAddAliasBase(t *testing.T) {
+	a := assert.New(t)
+	le := &Symbol{Name: "<="}
+	tree := NewOperators(le)
+
+	err := tree.AddAlias("≤", le)
+
+	a.NoError(err)
+	node := tree.Next('≤')
+	a.Equal(le, node.Sym)
+	a.Equal("≤", node.prefix)
+	a.Equal([]*Symbol{le}, tree.All())
+}
+
+func TestOperatorsAddAliasConflict(t *testing.T) {
+	a := assert.New(t)
+	le := &Symbol{Name: "<="}
+	lt := &Symbol{Name: "<"}
+	tree := NewOperators(le, lt)
+
+	err := tree.AddAlias("<", le)
+
+	a.Equal(ErrOpConflict, err)
+	a.Equal(lt, tree.Next('<').Sym)
+}
+
+func TestOperatorsAddAliasNoSuchOp(t *testing.T) {
+	a := assert.New(t)
+	le := &Symbol{Name: "<="}
+	tree := NewOperators(&Symbol{Name: "<"})
+
+	err := tree.AddAlias("≤", le)
+
+	a.Equal(ErrNoSuchOp, err)
+	a.Nil(tree.Next('≤'))
+}
+
+func TestOperatorsAddAliasOtherOp(t *testing.T) {
+	a := assert.New(t)
+	le := &Symbol{Name: "<="}
+	tree := NewOperators(&Symbol{Name: "<="})
+
+	err := tree.AddAlias("≤", le)
+
+	a.Equal(ErrNoSuchOp, err)
+	a.Nil(tree.Next('≤'))
+}
+
+func TestOperatorsAddAliasFrozen(t *testing.T) {
+	a := assert.New(t)
+	le := &Symbol{Name: "<="}
+	tree := NewOperators(le)
+	tree.Freeze()
+
+	err := tree.AddAlias("≤", le)
+
+	a.Equal(ErrFrozen, err)
+}
+
+func TestOperatorsAddAliasDelegate(t *testing.T) {
+	a := assert.New(t)
+	le := &Symbol{Name: "<="}
+	tree := NewOperators(le)
+
+	err := tree.Next('<').AddAlias("≤", le)
+
+	a.NoError(err)
+	a.Equal(le, tree.Next('≤').Sym)
+}
+
+func TestOperatorsRemoveAliasBase(t *testing.T) {
+	a := assert.New(t)
+	le := &Symbol{Name: "<="}
+	tree := NewOperators(le)
+	tree.AddAlias("≤", le)
+
+	err := tree.RemoveAlias("≤")
+
+	a.NoError(err)
+	a.Nil(tree.Next('≤'))
+	a.Equal([]*Symbol{le}, tree.All())
+}
+
+func TestOperatorsRemoveAliasNotAlias(t *testing.T) {
+	a := assert.New(t)
+	le := &Symbol{Name: "<="}
+	tree := NewOperators(le)
+
+	err := tree.RemoveAlias("<=")
+
+	a.Equal(ErrNoSuchOp, err)
+	a.Equal([]*Symbol{le}, tree.All())
+}
+
+func TestOperatorsRemoveAliasAbsent(t *testing.T) {
+	a := assert.New(t)
+	tree := NewOperators(&Symbol{Name: "<="})
+
+	err := tree.RemoveAlias("≤")
+
+	a.Equal(ErrNoSuchOp, err)
+}
+
+func TestOperatorsRemoveAliasDelegate(t *testing.T) {
+	a := assert.New(t)
+	le := &Symbol{Name: "<="}
+	tree := NewOperators(le)
+	tree.AddAlias("≤", le)
+
+	err := tree.Next('<').RemoveAlias("≤")
+
+	a.NoError(err)
+	a.Nil(tree.Next('≤'))
+}
+
 func TestOperatorsNextPresent(t *testing.T) {
 	a := assert.New(t)
 	child := &Operators{}
@@ -511,8 +712,147 @@ func TestOperatorsNextNoChildren(t *testing.T) {
 	next := node.Next('=')
 
 	a.Nil(next)
-	a.NotNil(node.children)
-	a.Equal(map[rune]*Operators{}, node.children)
+	a.Nil(node.children)
+}
+
+func TestOperatorsWalk(t *testing.T) {
+	a := assert.New(t)
+	add := &Symbol{Name: "+"}
+	aug := &Symbol{Name: "+="}
+	lt := &Symbol{Name: "<"}
+	ops := NewOperators(lt, aug, add)
+	var result []*Symbol
+
+	err := ops.Walk(func(sym *Symbol) error {
+		result = append(result, sym)
+		return nil
+	})
+
+	a.NoError(err)
+	a.Equal([]*Symbol{add, aug, lt}, result)
+}
+
+func TestOperatorsWalkError(t *testing.T) {
+	a := assert.New(t)
+	add := &Symbol{Name: "+"}
+	aug := &Symbol{Name: "+="}
+	lt := &Symbol{Name: "<"}
+	ops := NewOperators(lt, aug, add)
+	var result []*Symbol
+
+	err := ops.Walk(func(sym *Symbol) error {
+		result = append(result, sym)
+		if sym == aug {
+			return assert.AnError
+		}
+		return nil
+	})
+
+	a.Equal(assert.AnError, err)
+	a.Equal([]*Symbol{add, aug}, result)
+}
+
+func TestOperatorsAll(t *testing.T) {
+	a := assert.New(t)
+	add := &Symbol{Name: "+"}
+	aug := &Symbol{Name: "+="}
+	lt := &Symbol{Name: "<"}
+	ops := NewOperators(lt, aug, add)
+
+	result := ops.All()
+
+	a.Equal([]*Symbol{add, aug, lt}, result)
+}
+
+func TestOperatorsAllEmpty(t *testing.T) {
+	a := assert.New(t)
+	ops := NewOperators()
+
+	result := ops.All()
+
+	a.Nil(result)
+}
+
+func TestOperatorsWalkAliases(t *testing.T) {
+	a := assert.New(t)
+	le := &Symbol{Name: "<="}
+	tree := NewOperators(le)
+	tree.AddAlias("≤", le)
+	var result []*Symbol
+
+	err := tree.Walk(func(sym *Symbol) error {
+		result = append(result, sym)
+		return nil
+	})
+
+	a.NoError(err)
+	a.Equal([]*Symbol{le}, result)
+}
+
+func TestOperatorsAliases(t *testing.T) {
+	a := assert.New(t)
+	le := &Symbol{Name: "<="}
+	ge := &Symbol{Name: ">="}
+	tree := NewOperators(le, ge)
+	tree.AddAlias("≤", le)
+	tree.AddAlias("=<", le)
+	tree.AddAlias("≥", ge)
+
+	result := tree.Aliases(le)
+
+	a.Equal([]string{"=<", "≤"}, result)
+}
+
+func TestOperatorsAliasesNone(t *testing.T) {
+	a := assert.New(t)
+	le := &Symbol{Name: "<="}
+	tree := NewOperators(le)
+
+	result := tree.Aliases(le)
+
+	a.Nil(result)
+}
+
+func TestOperatorsSpelling(t *testing.T) {
+	a := assert.New(t)
+	node := &Operators{prefix: "≤"}
+
+	result := node.Spelling()
+
+	a.Equal("≤", result)
+}
+
+func TestOperatorsIsAliasTrue(t *testing.T) {
+	a := assert.New(t)
+	node := &Operators{
+		prefix: "≤",
+		Sym:    &Symbol{Name: "<="},
+	}
+
+	result := node.IsAlias()
+
+	a.True(result)
+}
+
+func TestOperatorsIsAliasFalse(t *testing.T) {
+	a := assert.New(t)
+	node := &Operators{
+		prefix: "<=",
+		Sym:    &Symbol{Name: "<="},
+	}
+
+	result := node.IsAlias()
+
+	a.False(result)
+}
+
+func TestOperatorsIsAliasNoSym(t *testing.T) {
+	a := assert.New(t)
+	node := &Operators{prefix: "<"}
+
+	result := node.IsAlias()
+
+	a.False(result)
 }
 
 func TestOperatorsStringRoot(t *testing.T) {
@@ -563,18 +903,52 @@ func TestOperatorsChildrenBase(t *testing.T) {
 
 	result := tree.Children()
 
-	a.Equal(2, len(result))
-	a.Contains(result, node1)
-	a.Contains(result, node2)
+	a.Equal([]utils.Visitable{node2, node1}, result)
 }
 
-func TestOperatorsChildrenCreateChildren(t *testing.T) {
+func TestOperatorsChildrenNoChildren(t *testing.T) {
 	a := assert.New(t)
 	tree := &Operators{}
 
 	result := tree.Children()
 
 	a.Equal(0, len(result))
-	a.NotNil(tree.children)
-	a.Equal(map[rune]*Operators{}, tree.children)
+	a.Nil(tree.children)
+}
+
+func TestOperatorsFreeze(t *testing.T) {
+	a := assert.New(t)
+	tree := NewOperators(&Symbol{Name: "<="})
+
+	tree.Next('<').Freeze()
+
+	a.True(tree.frozen)
+	a.True(tree.Next('<').Frozen())
+	a.True(tree.Frozen())
+}
+
+func TestOperatorsFrozenCopy(t *testing.T) {
+	a := assert.New(t)
+	tree := NewOperators(&Symbol{Name: "<="})
+	tree.Freeze()
+
+	result := tree.Copy()
+
+	a.False(result.Frozen())
+	a.NoError(result.Add(&Symbol{Name: "<"}))
+}
+
+func TestOperatorsFrozenModify(t *testing.T) {
+	a := assert.New(t)
+	le := &Symbol{Name: "<="}
+	tree := NewOperators(le)
+	tree.AddAlias("≤", le)
+	tree.Freeze()
+
+	a.Equal(ErrFrozen, tree.Add(&Symbol{Name: "<"}))
+	a.Equal(ErrFrozen, tree.AddAlias("=<", le))
+	a.Equal(ErrFrozen, tree.Remove(le))
+	a.Equal(ErrFrozen, tree.RemoveAlias("≤"))
+	a.Equal([]*Symbol{le}, tree.All())
+	a.Equal([]string{"≤"}, tree.Aliases(le))
 }
@@ -18,6 +18,8 @@ import (
 	"io"
 	"regexp"
 	"unicode/utf8"
+
+	"golang.org/x/text/language"
 )
 
 // guessBlock is a block size for guessing a file encoding based on
@@ -35,6 +37,22 @@ const defaultEncoding = "utf-8"
 // defaultTabStop is the default size of a tab.
 const defaultTabStop = 8
 
+// Indentation policies for the Indent option.
+const (
+	IndentDefault    IndentPolicy = iota // Tabs or spaces, not mixed on a line
+	IndentSpaces                         // Indent with spaces only
+	IndentTabs                           // Indent with tabs only
+	IndentConsistent                     // Consistent for any tab size
+)
+
+// IndentPolicy describes how the lexer checks the whitespace used
+// for indentation.  With the default policy, the indentation of a
+// line may use tabs or spaces, but not both.  The IndentConsistent
+// policy permits mixing tabs and spaces, but, like the Python
+// tabnanny module, requires that the indentation levels compare the
+// same way regardless of the size of a tab stop.
+type IndentPolicy uint8
+
 // encodingRE is a regular expression that matches a coding
 // declaration comment.
 var encodingRE = regexp.MustCompile(
@@ -69,11 +87,18 @@ func guessEncoding(source []byte) string {
 
 // Options contains the options for the parser.
 type Options struct {
-	Source   io.Reader // The source from which to read
-	Filename string    // The name of the file being parsed
-	Encoding string    // The encoding of the source
-	Prof     *Profile  // The profile
-	TabStop  int       // The size of a tab stop
+	Source   io.Reader    // The source from which to read
+	Filename string       // The name of the file being parsed
+	Encoding string       // The encoding of the source
+	Prof     *Profile     // The profile
+	TabStop  int          // The size of a tab stop
+	Indent   IndentPolicy // The indentation policy
+	Recover  bool         // Continue lexing after lexical errors
+	Lang     language.Tag // The language for diagnostic messages
+	Warn     WarnHook     // Hook to report warnings
+	Trivia   bool         // Preserve trivia on tokens
+	ASCIIOps bool         // Reject non-ASCII operator spellings
+	Start    FilePos      // Position of the beginning of the source
 }
 
 // namer is an interface with a single Name() method.  This matches
@@ -172,3 +197,70 @@ func TabStop(tabstop int) Option {
 		opts.TabStop = tabstop
 	}
 }
+
+// Indentation sets the indentation policy.  If not set, the
+// indentation of a line may use tabs or spaces, but not both.
+func Indentation(policy IndentPolicy) Option {
+	return func(opts *Options) {
+		opts.Indent = policy
+	}
+}
+
+// ErrorRecovery enables or disables error recovery.  When error
+// recovery is enabled, the lexer emits an error token for each
+// lexical error, then resynchronizes at a sensible point and
+// continues producing tokens, rather than halting at the first error.
+func ErrorRecovery(enable bool) Option {
+	return func(opts *Options) {
+		opts.Recover = enable
+	}
+}
+
+// Language sets the language for diagnostic messages.  Messages are
+// translated using the Messages catalog; if not set, or if no
+// translation is available, messages are in English.
+func Language(tag language.Tag) Option {
+	return func(opts *Options) {
+		opts.Lang = tag
+	}
+}
+
+// Warn sets a hook to be called with warnings, such as those produced
+// by the lint checks enabled in the profile.  If not set, warnings
+// are not reported.
+func Warn(hook WarnHook) Option {
+	return func(opts *Options) {
+		opts.Warn = hook
+	}
+}
+
+// PreserveTrivia enables or disables trivia preservation.  When
+// enabled, the lexer records the whitespace, comments, line
+// continuations, and blank lines surrounding each token, so that the
+// source may be reconstructed exactly from the tokens.
+func PreserveTrivia(enable bool) Option {
+	return func(opts *Options) {
+		opts.Trivia = enable
+	}
+}
+
+// ASCIIOperators enables or disables the rejection of non-ASCII
+// operator spellings.  When enabled, an operator alias such as "≤"
+// for "<=" is reported as an error rather than producing the
+// operator; use the LintNonASCIIOp lint check to report such
+// spellings as warnings instead.
+func ASCIIOperators(enable bool) Option {
+	return func(opts *Options) {
+		opts.ASCIIOps = enable
+	}
+}
+
+// Start sets the position of the beginning of the source.  This is
+// used when the source is the remainder of a file, beginning at the
+// position of a lexer state snapshot; see LexState.  If not set, the
+// source begins at line 1, column 1.
+func Start(pos FilePos) Option {
+	return func(opts *Options) {
+		opts.Start = pos
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
 )
 
 func TestGuessEncodingBOM(t *testing.T) {
@@ -185,11 +186,12 @@ func TestOptionsParseOptions(t *testing.T) {
 	a := assert.New(t)
 	obj := &Options{}
 
-	obj.Parse(Filename("file"), Encoding("other"), TabStop(4))
+	obj.Parse(Filename("file"), Encoding("other"), TabStop(4), ErrorRecovery(true))
 
 	a.Equal("file", obj.Filename)
 	a.Equal("other", obj.Encoding)
 	a.Equal(4, obj.TabStop)
+	a.True(obj.Recover)
 }
 
 func TestFilename(t *testing.T) {
@@ -221,3 +223,78 @@ func TestTabStop(t *testing.T) {
 
 	a.Equal(4, opts.TabStop)
 }
+
+func TestIndentation(t *testing.T) {
+	a := assert.New(t)
+	opts := &Options{}
+
+	opt := Indentation(IndentConsistent)
+	opt(opts)
+
+	a.Equal(IndentConsistent, opts.Indent)
+}
+
+func TestErrorRecovery(t *testing.T) {
+	a := assert.New(t)
+	opts := &Options{}
+
+	opt := ErrorRecovery(true)
+	opt(opts)
+
+	a.True(opts.Recover)
+}
+
+func TestLanguage(t *testing.T) {
+	a := assert.New(t)
+	opts := &Options{}
+
+	opt := Language(language.French)
+	opt(opts)
+
+	a.Equal(language.French, opts.Lang)
+}
+
+func TestWarn(t *testing.T) {
+	a := assert.New(t)
+	opts := &Options{}
+	var warnings []*Diagnostic
+	hook := func(diag *Diagnostic) {
+		warnings = append(warnings, diag)
+	}
+
+	opt := Warn(hook)
+	opt(opts)
+
+	opts.Warn(&Diagnostic{})
+	a.Len(warnings, 1)
+}
+
+func TestPreserveTrivia(t *testing.T) {
+	a := assert.New(t)
+	opts := &Options{}
+
+	opt := PreserveTrivia(true)
+	opt(opts)
+
+	a.True(opts.Trivia)
+}
+
+func TestASCIIOperators(t *testing.T) {
+	a := assert.New(t)
+	opts := &Options{}
+
+	opt := ASCIIOperators(true)
+	opt(opts)
+
+	a.True(opts.ASCIIOps)
+}
+
+func TestStart(t *testing.T) {
+	a := assert.New(t)
+	opts := &Options{}
+
+	opt := Start(FilePos{L: 5, C: 1})
+	opt(opts)
+
+	a.Equal(FilePos{L: 5, C: 1}, opts.Start)
+}
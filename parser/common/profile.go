@@ -15,36 +15,233 @@
 package common
 
 import (
+	"fmt"
+	"sort"
+
 	"golang.org/x/text/runes"
 	"golang.org/x/text/unicode/norm"
 )
 
+// Line continuation policies for the Continuation field of the
+// Profile.
+const (
+	ContStrict  ContPolicy = iota // Backslash must precede the newline
+	ContLenient                   // Whitespace or comment may follow
+	ContNone                      // Backslash continuation is not allowed
+)
+
+// ContPolicy describes how the lexer treats a backslash used to
+// continue a logical line onto the next physical line.  With the
+// strict policy, the backslash must be immediately followed by the
+// newline.  The lenient policy also permits whitespace and a comment
+// to follow the backslash; trailing whitespace, which is invisible in
+// most editors, is reported with a warning.
+type ContPolicy uint8
+
 // Profile describes a profile for the parser.  A profile is simply
 // the version-specific rules, with desired options applied, and
 // covers such things as the sets of identifier characters, etc.
 type Profile struct {
-	IDStart   runes.Set          // Set of valid identifier start chars
-	IDCont    runes.Set          // Set of valid identifier continue chars
-	StrFlags  map[rune]uint8     // Valid string flags
-	Quotes    map[rune]uint8     // Valid quote characters
-	Escapes   map[rune]StrEscape // String escapes
-	Keywords  Keywords           // Mapping of keywords
-	Norm      norm.Form          // Normalization for identifiers
-	Operators *Operators         // Recognized operators
+	IDStart      runes.Set          // Set of valid identifier start chars
+	IDCont       runes.Set          // Set of valid identifier continue chars
+	StrFlags     map[rune]uint8     // Valid string flags
+	Quotes       map[rune]uint8     // Valid quote characters
+	Escapes      map[rune]StrEscape // String escapes
+	Keywords     Keywords           // Mapping of keywords
+	Norm         norm.Form          // Normalization for identifiers
+	Operators    *Operators         // Recognized operators
+	Recognizers  *Recognizers       // Recognizer registry; nil for default
+	Lints        uint16             // Enabled lint checks
+	Continuation ContPolicy         // Line continuation policy
+	InfixCont    bool               // Lines ending in infix operator continue
+	Bindings     Bindings           // Operator precedence table
+	Symbols      *SymTab            // Symbol table; set by Build
+	kwPrefixes   map[string]bool    // Multi-word keyword prefixes; set by Build
+	frozen       bool               // Profile may not be modified
 }
 
 // Copy generates a copy of a profile.  An Options structure always
 // contains a profile copy, to enable it to be mutated by options
-// without accidentally changing the master profile.
+// without accidentally changing the master profile.  The symbol table
+// is not copied; Build must be called on the copy to rebuild it.  The
+// copy is never frozen.
 func (p *Profile) Copy() *Profile {
 	return &Profile{
-		IDStart:   p.IDStart,
-		IDCont:    p.IDCont,
-		StrFlags:  p.StrFlags,
-		Quotes:    p.Quotes,
-		Escapes:   p.Escapes,
-		Keywords:  p.Keywords.Copy(),
-		Norm:      p.Norm,
-		Operators: p.Operators.Copy(),
+		IDStart:      p.IDStart,
+		IDCont:       p.IDCont,
+		StrFlags:     p.StrFlags,
+		Quotes:       p.Quotes,
+		Escapes:      p.Escapes,
+		Keywords:     p.Keywords.Copy(),
+		Norm:         p.Norm,
+		Operators:    p.Operators.Copy(),
+		Recognizers:  p.Recognizers.Copy(),
+		Lints:        p.Lints,
+		Continuation: p.Continuation,
+		InfixCont:    p.InfixCont,
+		Bindings:     p.Bindings.Copy(),
+	}
+}
+
+// sortSymbols sorts a list of symbols by name.
+func sortSymbols(syms []*Symbol) {
+	sort.Slice(syms, func(i, j int) bool {
+		return syms[i].Name < syms[j].Name
+	})
+}
+
+// checkKind records the kind of a symbol defined by the profile in
+// the kinds map, without modifying the symbol.  The kind recorded is
+// the kind the symbol already has, if any, or the one it was first
+// checked against.  Returns ErrSymbolKind if that kind differs from
+// the specified one.
+func checkKind(kinds map[*Symbol]SymKind, sym *Symbol, kind SymKind) error {
+	cur, ok := kinds[sym]
+	if !ok {
+		cur = sym.Kind
+	}
+	if cur == KindUnknown {
+		kinds[sym] = kind
+	} else if cur != kind {
+		return fmt.Errorf("%w: %q is of kind %s, not %s", ErrSymbolKind, sym.Name, cur, kind)
+	} else {
+		kinds[sym] = cur
+	}
+
+	return nil
+}
+
+// Build validates the profile and constructs its symbol table, which
+// assigns a dense integer ID to each symbol.  The standard symbols
+// come first, followed by the keywords, the operators, and the
+// symbols produced by the recognizers, each sorted by name.  Keywords
+// and operators whose kind has not been set are given the
+// appropriate kind, but only once the whole profile has been
+// validated, so a failed Build leaves the symbols untouched.  Build
+// also collects the prefixes of the multi-word keywords for
+// ContinuesKeyword.  Build must be called again after the keywords,
+// operators, or bindings are changed.  Returns
+// ErrSymbolKind if a symbol is used inconsistently with its kind, or
+// ErrBadBinding if a binding is inconsistent or is for a symbol that
+// is neither a keyword nor an operator, or ErrFrozen if the profile
+// has been frozen.
+func (p *Profile) Build() error {
+	if p.frozen {
+		return ErrFrozen
+	}
+
+	tab := newSymTab()
+	kinds := map[*Symbol]SymKind{}
+
+	// Add the keywords
+	kws := make([]*Symbol, 0, len(p.Keywords))
+	for _, sym := range p.Keywords {
+		kws = append(kws, sym)
+	}
+	sortSymbols(kws)
+	for _, sym := range kws {
+		if err := checkKind(kinds, sym, KindKeyword); err != nil {
+			return err
+		}
+		tab.add(sym)
+	}
+
+	// Add the operators
+	var ops []*Symbol
+	if p.Operators != nil {
+		ops = p.Operators.All()
+	}
+	for _, sym := range ops {
+		if err := checkKind(kinds, sym, KindOperator); err != nil {
+			return err
+		}
+		tab.add(sym)
+	}
+
+	// Add the symbols produced by recognizers
+	var recSyms []*Symbol
+	if p.Recognizers != nil {
+		for _, entry := range p.Recognizers.All() {
+			recSyms = append(recSyms, entry.Symbols...)
+		}
 	}
+	sortSymbols(recSyms)
+	for _, sym := range recSyms {
+		tab.add(sym)
+	}
+
+	// Validate the bindings, in order by name so the error
+	// reported doesn't vary
+	bound := make([]*Symbol, 0, len(p.Bindings))
+	for sym := range p.Bindings {
+		bound = append(bound, sym)
+	}
+	sortSymbols(bound)
+	for _, sym := range bound {
+		if kind := kinds[sym]; kind != KindKeyword && kind != KindOperator {
+			return fmt.Errorf("%w: %q is not an operator or keyword", ErrBadBinding, sym.Name)
+		} else if err := p.Bindings[sym].validate(); err != nil {
+			return fmt.Errorf("%w for %q", err, sym.Name)
+		}
+	}
+
+	// Assign the kinds; symbols that already have their kind are
+	// left alone, so profiles sharing symbols don't race here
+	for sym, kind := range kinds {
+		if sym.Kind == KindUnknown {
+			sym.Kind = kind
+		}
+	}
+
+	p.Symbols = tab
+	p.kwPrefixes = p.Keywords.Prefixes()
+	return nil
+}
+
+// ContinuesKeyword tests whether the specified words, separated by
+// single spaces, are the beginning of a multi-word keyword.  Once the
+// profile has been built, this is a single lookup in the set of
+// keyword prefixes; otherwise, it falls back to Keywords.Continues.
+func (p *Profile) ContinuesKeyword(words string) bool {
+	if p.kwPrefixes != nil {
+		return p.kwPrefixes[words]
+	}
+
+	return p.Keywords.Continues(words)
+}
+
+// Freeze builds the profile, if it has not already been built, and
+// freezes it, so that it may be safely shared by several lexers
+// running at once.  The operator tree and the recognizer registry
+// are frozen as well, and Build will refuse to rebuild a frozen
+// profile, but the keywords and bindings are plain maps, and must not
+// be modified; use Copy to obtain a profile that may be modified.
+// Freezing a frozen profile does nothing.  Returns any error from
+// Build.
+func (p *Profile) Freeze() error {
+	if p.frozen {
+		return nil
+	}
+
+	// Build the symbol table
+	if p.Symbols == nil {
+		if err := p.Build(); err != nil {
+			return err
+		}
+	}
+
+	if p.Operators != nil {
+		p.Operators.Freeze()
+	}
+	if p.Recognizers != nil {
+		p.Recognizers.Freeze()
+	}
+	p.frozen = true
+
+	return nil
+}
+
+// Frozen tests whether the profile has been frozen.
+func (p *Profile) Frozen() bool {
+	return p.frozen
 }
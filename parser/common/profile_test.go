@@ -15,6 +15,7 @@
 package common
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -114,6 +115,10 @@ var (
 		Keywords:  testKeywords,
 		Norm:      norm.NFKC,
 		Operators: testOperators,
+		Lints:     LintTrailingWS | LintOctalEscape,
+		Bindings: Bindings{
+			testKeywords["kw1"]: {Fixity: FixPrefix, Prefix: 1},
+		},
 	}
 )
 
@@ -132,4 +137,324 @@ func TestProfileCopy(t *testing.T) {
 	a.Equal(testProfile.Norm, result.Norm)
 	a.Equal(testOperators, result.Operators)
 	testutils.AssertPtrNotEqual(a, testProfile.Operators, result.Operators)
+	a.Nil(result.Recognizers)
+	a.Equal(testProfile.Lints, result.Lints)
+	a.Equal(testProfile.Bindings, result.Bindings)
+	testutils.AssertPtrNotEqual(a, testProfile.Bindings, result.Bindings)
+	a.Nil(result.Symbols)
+}
+
+func TestProfileCopyRecognizers(t *testing.T) {
+	a := assert.New(t)
+	entry := &RecogEntry{Name: "e1", Class: CharQuote, Init: testRecogInit}
+	prof := &Profile{
+		Operators:   NewOperators(),
+		Recognizers: NewRecognizers(entry),
+	}
+
+	result := prof.Copy()
+
+	testutils.AssertPtrNotEqual(a, prof.Recognizers, result.Recognizers)
+	a.Equal([]*RecogEntry{entry}, result.Recognizers.All())
+}
+
+func TestProfileCopyContinuation(t *testing.T) {
+	a := assert.New(t)
+	prof := &Profile{
+		Operators:    NewOperators(),
+		Continuation: ContLenient,
+		InfixCont:    true,
+	}
+
+	result := prof.Copy()
+
+	a.Equal(ContLenient, result.Continuation)
+	a.True(result.InfixCont)
+}
+
+func TestProfileBuild(t *testing.T) {
+	a := assert.New(t)
+	kw1 := &Symbol{Name: "kw1"}
+	kw2 := &Symbol{Name: "kw2", Kind: KindKeyword}
+	add := &Symbol{Name: "+"}
+	aug := &Symbol{Name: "+="}
+	lt := &Symbol{Name: "<"}
+	prof := &Profile{
+		Keywords:  Keywords{"kw2": kw2, "kw1": kw1},
+		Operators: NewOperators(lt, aug, add),
+	}
+
+	err := prof.Build()
+
+	a.NoError(err)
+	a.Equal(IDFirstProfile+5, prof.Symbols.Len())
+	a.Equal([]*Symbol{kw1, kw2, add, aug, lt}, prof.Symbols.Symbols()[IDFirstProfile:])
+	a.Equal(KindKeyword, kw1.Kind)
+	a.Equal(KindOperator, add.Kind)
+}
+
+func TestProfileBuildRecognizers(t *testing.T) {
+	a := assert.New(t)
+	kw := &Symbol{Name: "kw"}
+	re := &Symbol{Name: "<Regexp>"}
+	sigil := &Symbol{Name: "<Sigil>"}
+	prof := &Profile{
+		Keywords: Keywords{"kw": kw},
+		Recognizers: NewRecognizers(&RecogEntry{
+			Name:    "sigil",
+			Class:   CharQuote,
+			Init:    testRecogInit,
+			Symbols: []*Symbol{sigil, re},
+		}),
+	}
+
+	err := prof.Build()
+
+	a.NoError(err)
+	a.Equal([]*Symbol{kw, re, sigil}, prof.Symbols.Symbols()[IDFirstProfile:])
+	a.Equal(re, prof.Symbols.Lookup("<Regexp>"))
+}
+
+func TestProfileBuildNoOperators(t *testing.T) {
+	a := assert.New(t)
+	prof := &Profile{}
+
+	err := prof.Build()
+
+	a.NoError(err)
+	a.Equal(IDFirstProfile, prof.Symbols.Len())
+}
+
+func TestProfileBuildBadKind(t *testing.T) {
+	a := assert.New(t)
+	prof := &Profile{
+		Keywords: Keywords{"kw": &Symbol{Name: "kw", Kind: KindOperator}},
+	}
+
+	err := prof.Build()
+
+	a.True(errors.Is(err, ErrSymbolKind))
+	a.EqualError(err, "symbol used inconsistently with its kind: \"kw\" is of kind operator, not keyword")
+	a.Nil(prof.Symbols)
+}
+
+func TestProfileBuildBadKindUnmodified(t *testing.T) {
+	a := assert.New(t)
+	kw := &Symbol{Name: "kw"}
+	op := &Symbol{Name: "+", Kind: KindKeyword}
+	prof := &Profile{
+		Keywords:  Keywords{"kw": kw},
+		Operators: NewOperators(op),
+	}
+
+	err := prof.Build()
+
+	a.True(errors.Is(err, ErrSymbolKind))
+	a.Equal(KindUnknown, kw.Kind)
+	a.Nil(prof.Symbols)
+}
+
+func TestProfileBuildKeywordOperator(t *testing.T) {
+	a := assert.New(t)
+	sym := &Symbol{Name: "in"}
+	prof := &Profile{
+		Keywords:  Keywords{"in": sym},
+		Operators: NewOperators(sym),
+	}
+
+	err := prof.Build()
+
+	a.EqualError(err, "symbol used inconsistently with its kind: \"in\" is of kind keyword, not operator")
+	a.Equal(KindUnknown, sym.Kind)
+}
+
+func TestProfileBuildBindings(t *testing.T) {
+	a := assert.New(t)
+	not := &Symbol{Name: "not"}
+	sub := &Symbol{Name: "-"}
+	prof := &Profile{
+		Keywords:  Keywords{"not": not},
+		Operators: NewOperators(sub),
+		Bindings: Bindings{
+			not: {Fixity: FixPrefix, Prefix: 3},
+			sub: {Fixity: FixPrefix | FixInfix, Prefix: 10, Infix: 5, Assoc: AssocLeft},
+		},
+	}
+
+	err := prof.Build()
+
+	a.NoError(err)
+	a.NotNil(prof.Symbols)
+}
+
+func TestProfileBuildBindingUnknownSymbol(t *testing.T) {
+	a := assert.New(t)
+	prof := &Profile{
+		Bindings: Bindings{
+			&Symbol{Name: "-"}: {Fixity: FixInfix, Infix: 5},
+		},
+	}
+
+	err := prof.Build()
+
+	a.True(errors.Is(err, ErrBadBinding))
+	a.EqualError(err, "bad operator binding: \"-\" is not an operator or keyword")
+	a.Nil(prof.Symbols)
+}
+
+func TestProfileBuildBindingOrder(t *testing.T) {
+	a := assert.New(t)
+	prof := &Profile{
+		Bindings: Bindings{
+			&Symbol{Name: "z"}: {Fixity: FixInfix, Infix: 5},
+			&Symbol{Name: "m"}: {Fixity: FixInfix, Infix: 5},
+			&Symbol{Name: "a"}: {Fixity: FixInfix, Infix: 5},
+			&Symbol{Name: "q"}: {Fixity: FixInfix, Infix: 5},
+		},
+	}
+
+	for i := 0; i < 10; i++ {
+		err := prof.Build()
+
+		a.EqualError(err, "bad operator binding: \"a\" is not an operator or keyword")
+	}
+}
+
+func TestProfileBuildBindingStandardSymbol(t *testing.T) {
+	a := assert.New(t)
+	prof := &Profile{
+		Bindings: Bindings{
+			TokInt: {Fixity: FixInfix, Infix: 5},
+		},
+	}
+
+	err := prof.Build()
+
+	a.EqualError(err, "bad operator binding: \"<Int>\" is not an operator or keyword")
+}
+
+func TestProfileBuildBindingInvalid(t *testing.T) {
+	a := assert.New(t)
+	sub := &Symbol{Name: "-"}
+	prof := &Profile{
+		Operators: NewOperators(sub),
+		Bindings: Bindings{
+			sub: {Fixity: FixInfix},
+		},
+	}
+
+	err := prof.Build()
+
+	a.True(errors.Is(err, ErrBadBinding))
+	a.EqualError(err, "bad operator binding: infix binding power must be positive for \"-\"")
+	a.Equal(KindUnknown, sub.Kind)
+}
+
+func TestProfileBuildFrozen(t *testing.T) {
+	a := assert.New(t)
+	prof := &Profile{frozen: true}
+
+	err := prof.Build()
+
+	a.Equal(ErrFrozen, err)
+	a.Nil(prof.Symbols)
+}
+
+func TestProfileBuildKeywordPrefixes(t *testing.T) {
+	a := assert.New(t)
+	prof := &Profile{
+		Keywords: Keywords{
+			"not":    &Symbol{Name: "not"},
+			"not in": &Symbol{Name: "not in"},
+		},
+	}
+
+	err := prof.Build()
+
+	a.NoError(err)
+	a.Equal(map[string]bool{"not": true}, prof.kwPrefixes)
+}
+
+func TestProfileContinuesKeywordBuilt(t *testing.T) {
+	a := assert.New(t)
+	prof := &Profile{
+		Keywords:   Keywords{"not in": &Symbol{Name: "not in"}},
+		kwPrefixes: map[string]bool{"is": true},
+	}
+
+	a.True(prof.ContinuesKeyword("is"))
+	a.False(prof.ContinuesKeyword("not"))
+}
+
+func TestProfileContinuesKeywordUnbuilt(t *testing.T) {
+	a := assert.New(t)
+	prof := &Profile{
+		Keywords: Keywords{"not in": &Symbol{Name: "not in"}},
+	}
+
+	a.True(prof.ContinuesKeyword("not"))
+	a.False(prof.ContinuesKeyword("is"))
+}
+
+func TestProfileFreeze(t *testing.T) {
+	a := assert.New(t)
+	prof := &Profile{
+		Keywords:    Keywords{"kw": &Symbol{Name: "kw"}},
+		Operators:   NewOperators(&Symbol{Name: "+"}),
+		Recognizers: NewRecognizers(),
+	}
+
+	err := prof.Freeze()
+
+	a.NoError(err)
+	a.True(prof.Frozen())
+	a.True(prof.Operators.Frozen())
+	a.True(prof.Recognizers.Frozen())
+	a.Equal(IDFirstProfile+2, prof.Symbols.Len())
+}
+
+func TestProfileFreezeBuilt(t *testing.T) {
+	a := assert.New(t)
+	prof := &Profile{}
+	prof.Build()
+	tab := prof.Symbols
+
+	err := prof.Freeze()
+
+	a.NoError(err)
+	a.True(prof.Frozen())
+	testutils.AssertPtrEqual(a, tab, prof.Symbols)
+}
+
+func TestProfileFreezeFrozen(t *testing.T) {
+	a := assert.New(t)
+	prof := &Profile{frozen: true}
+
+	err := prof.Freeze()
+
+	a.NoError(err)
+	a.Nil(prof.Symbols)
+}
+
+func TestProfileFreezeError(t *testing.T) {
+	a := assert.New(t)
+	prof := &Profile{
+		Keywords: Keywords{"kw": &Symbol{Name: "kw", Kind: KindOperator}},
+	}
+
+	err := prof.Freeze()
+
+	a.True(errors.Is(err, ErrSymbolKind))
+	a.False(prof.Frozen())
+}
+
+func TestProfileCopyFrozen(t *testing.T) {
+	a := assert.New(t)
+	prof := &Profile{Operators: NewOperators()}
+	prof.Freeze()
+
+	result := prof.Copy()
+
+	a.False(result.Frozen())
+	a.False(result.Operators.Frozen())
 }
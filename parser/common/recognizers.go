@@ -0,0 +1,166 @@
+// Copyright (c) 2019 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package common
+
+import (
+	"sort"
+)
+
+// RecogEntry describes a recognizer in the recognizer registry.  An
+// entry applies to a character if the character has any of the
+// classes in Class, or if the Match predicate returns true for the
+// character.  The Match predicate may read ahead from the scanner to
+// examine a prefix of the source, but must push back every character
+// it reads.  Symbols lists the symbols, other than the standard
+// symbols, that the recognizer produces; Profile.Build adds them to
+// the symbol table.
+type RecogEntry struct {
+	Name     string                           // Name of the recognizer
+	Priority int                              // Lower priorities are tried first
+	Class    uint16                           // Character classes to apply to
+	Match    func(ch AugChar, s Scanner) bool // Prefix predicate
+	Init     RecogInit                        // Initializes the recognizer
+	Symbols  []*Symbol                        // Symbols the recognizer produces
+}
+
+// Applies tests whether the entry applies to the specified character.
+func (e *RecogEntry) Applies(ch AugChar, s Scanner) bool {
+	if e.Class != 0 && ch.Class&e.Class != 0 {
+		return true
+	}
+
+	return e.Match != nil && e.Match(ch, s)
+}
+
+// Recognizers is a registry of recognizers.  The lexer consults the
+// registry to select the recognizer for the first character of each
+// token, trying the entries in priority order; entries with the same
+// priority are tried in the order they were added.  This enables a
+// dialect to add token types, such as regular expression literals,
+// without modifying the lexer.
+type Recognizers struct {
+	entries []*RecogEntry // Entries in priority order
+	frozen  bool          // Registry may not be modified
+}
+
+// NewRecognizers constructs a Recognizers registry with all the
+// specified entries.  This is intended for constructing static
+// registries, and so panics with the error if an entry can't be
+// added.
+func NewRecognizers(entries ...*RecogEntry) *Recognizers {
+	r := &Recognizers{}
+
+	for _, entry := range entries {
+		if err := r.Add(entry); err != nil {
+			panic(err)
+		}
+	}
+
+	return r
+}
+
+// Copy constructs a copy of the registry.  The entries themselves are
+// shared.  The copy is never frozen.
+func (r *Recognizers) Copy() *Recognizers {
+	if r == nil {
+		return nil
+	}
+
+	return &Recognizers{
+		entries: append([]*RecogEntry(nil), r.entries...),
+	}
+}
+
+// Freeze freezes the registry, so that it may no longer be modified.
+func (r *Recognizers) Freeze() {
+	r.frozen = true
+}
+
+// Frozen tests whether the registry has been frozen.
+func (r *Recognizers) Frozen() bool {
+	return r.frozen
+}
+
+// Add adds an entry to the registry.  Returns ErrBadRecog if the
+// entry has no name, no Init function, or neither a Class nor a Match
+// predicate, ErrDuplicateRecog if an entry with the same name has
+// been added, or ErrFrozen if the registry has been frozen.
+func (r *Recognizers) Add(entry *RecogEntry) error {
+	if r.frozen {
+		return ErrFrozen
+	} else if entry.Name == "" || entry.Init == nil || (entry.Class == 0 && entry.Match == nil) {
+		return ErrBadRecog
+	} else if r.Get(entry.Name) != nil {
+		return ErrDuplicateRecog
+	}
+
+	// Insert the entry after any of the same priority
+	i := sort.Search(len(r.entries), func(i int) bool {
+		return r.entries[i].Priority > entry.Priority
+	})
+	r.entries = append(r.entries, nil)
+	copy(r.entries[i+1:], r.entries[i:])
+	r.entries[i] = entry
+
+	return nil
+}
+
+// Remove removes the named entry from the registry.  Returns
+// ErrNoSuchRecog if there is no such entry, or ErrFrozen if the
+// registry has been frozen.
+func (r *Recognizers) Remove(name string) error {
+	if r.frozen {
+		return ErrFrozen
+	}
+
+	for i, entry := range r.entries {
+		if entry.Name == name {
+			r.entries = append(r.entries[:i], r.entries[i+1:]...)
+			return nil
+		}
+	}
+
+	return ErrNoSuchRecog
+}
+
+// Get looks up the named entry in the registry.  Returns nil if there
+// is no such entry.
+func (r *Recognizers) Get(name string) *RecogEntry {
+	for _, entry := range r.entries {
+		if entry.Name == name {
+			return entry
+		}
+	}
+
+	return nil
+}
+
+// All returns a list of the entries in the registry, in priority
+// order.
+func (r *Recognizers) All() []*RecogEntry {
+	return append([]*RecogEntry(nil), r.entries...)
+}
+
+// Lookup selects the entry for the recognizer that applies to the
+// specified character.  Returns nil if no recognizer applies.
+func (r *Recognizers) Lookup(ch AugChar, s Scanner) *RecogEntry {
+	for _, entry := range r.entries {
+		if entry.Applies(ch, s) {
+			return entry
+		}
+	}
+
+	return nil
+}
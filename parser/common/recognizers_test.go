@@ -0,0 +1,307 @@
+// Copyright (c) 2019 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hydralang/hydra/testutils"
+)
+
+func testRecogInit(lc LexContext) Recognizer {
+	return nil
+}
+
+func TestRecogEntryAppliesClass(t *testing.T) {
+	a := assert.New(t)
+	entry := &RecogEntry{Class: CharQuote}
+
+	result := entry.Applies(AugChar{C: '"', Class: CharQuote}, nil)
+
+	a.True(result)
+}
+
+func TestRecogEntryAppliesClassMismatch(t *testing.T) {
+	a := assert.New(t)
+	entry := &RecogEntry{Class: CharQuote}
+
+	result := entry.Applies(AugChar{C: 'a', Class: CharIDStart}, nil)
+
+	a.False(result)
+}
+
+func TestRecogEntryAppliesMatch(t *testing.T) {
+	a := assert.New(t)
+	s := &MockScanner{}
+	ch := AugChar{C: '/'}
+	entry := &RecogEntry{
+		Match: func(c AugChar, sc Scanner) bool {
+			a.Equal(ch, c)
+			testutils.AssertPtrEqual(a, s, sc)
+			return true
+		},
+	}
+
+	result := entry.Applies(ch, s)
+
+	a.True(result)
+}
+
+func TestRecogEntryAppliesMatchFalse(t *testing.T) {
+	a := assert.New(t)
+	entry := &RecogEntry{
+		Class: CharQuote,
+		Match: func(c AugChar, sc Scanner) bool {
+			return false
+		},
+	}
+
+	result := entry.Applies(AugChar{C: '/'}, nil)
+
+	a.False(result)
+}
+
+func TestNewRecognizers(t *testing.T) {
+	a := assert.New(t)
+	e1 := &RecogEntry{Name: "e1", Priority: 2, Class: CharQuote, Init: testRecogInit}
+	e2 := &RecogEntry{Name: "e2", Priority: 1, Class: CharQuote, Init: testRecogInit}
+
+	result := NewRecognizers(e1, e2)
+
+	a.Equal([]*RecogEntry{e2, e1}, result.entries)
+	a.False(result.frozen)
+}
+
+func TestNewRecognizersPanics(t *testing.T) {
+	a := assert.New(t)
+
+	a.PanicsWithValue(ErrBadRecog, func() {
+		NewRecognizers(&RecogEntry{Name: "e1"})
+	})
+}
+
+func TestRecognizersCopy(t *testing.T) {
+	a := assert.New(t)
+	e1 := &RecogEntry{Name: "e1"}
+	r := &Recognizers{entries: []*RecogEntry{e1}, frozen: true}
+
+	result := r.Copy()
+
+	testutils.AssertPtrNotEqual(a, r, result)
+	a.Equal([]*RecogEntry{e1}, result.entries)
+	a.False(result.frozen)
+	result.entries[0] = nil
+	a.Equal([]*RecogEntry{e1}, r.entries)
+}
+
+func TestRecognizersCopyNil(t *testing.T) {
+	a := assert.New(t)
+	var r *Recognizers
+
+	result := r.Copy()
+
+	a.Nil(result)
+}
+
+func TestRecognizersFreeze(t *testing.T) {
+	a := assert.New(t)
+	r := &Recognizers{}
+
+	r.Freeze()
+
+	a.True(r.frozen)
+	a.True(r.Frozen())
+}
+
+func TestRecognizersAdd(t *testing.T) {
+	a := assert.New(t)
+	e1 := &RecogEntry{Name: "e1", Priority: 1}
+	e2 := &RecogEntry{Name: "e2", Priority: 2}
+	e3 := &RecogEntry{Name: "e3", Priority: 2, Class: CharQuote, Init: testRecogInit}
+	r := &Recognizers{entries: []*RecogEntry{e1, e2}}
+
+	err := r.Add(e3)
+
+	a.NoError(err)
+	a.Equal([]*RecogEntry{e1, e2, e3}, r.entries)
+}
+
+func TestRecognizersAddFirst(t *testing.T) {
+	a := assert.New(t)
+	e1 := &RecogEntry{Name: "e1", Priority: 1}
+	e2 := &RecogEntry{Name: "e2", Priority: 2}
+	e3 := &RecogEntry{Name: "e3", Priority: 0, Match: func(ch AugChar, s Scanner) bool { return true }, Init: testRecogInit}
+	r := &Recognizers{entries: []*RecogEntry{e1, e2}}
+
+	err := r.Add(e3)
+
+	a.NoError(err)
+	a.Equal([]*RecogEntry{e3, e1, e2}, r.entries)
+}
+
+func TestRecognizersAddMiddle(t *testing.T) {
+	a := assert.New(t)
+	e1 := &RecogEntry{Name: "e1", Priority: 1}
+	e2 := &RecogEntry{Name: "e2", Priority: 3}
+	e3 := &RecogEntry{Name: "e3", Priority: 2, Class: CharQuote, Init: testRecogInit}
+	r := &Recognizers{entries: []*RecogEntry{e1, e2}}
+
+	err := r.Add(e3)
+
+	a.NoError(err)
+	a.Equal([]*RecogEntry{e1, e3, e2}, r.entries)
+}
+
+func TestRecognizersAddNoName(t *testing.T) {
+	a := assert.New(t)
+	r := &Recognizers{}
+
+	err := r.Add(&RecogEntry{Class: CharQuote, Init: testRecogInit})
+
+	a.Equal(ErrBadRecog, err)
+	a.Nil(r.entries)
+}
+
+func TestRecognizersAddNoInit(t *testing.T) {
+	a := assert.New(t)
+	r := &Recognizers{}
+
+	err := r.Add(&RecogEntry{Name: "e1", Class: CharQuote})
+
+	a.Equal(ErrBadRecog, err)
+	a.Nil(r.entries)
+}
+
+func TestRecognizersAddNoSelector(t *testing.T) {
+	a := assert.New(t)
+	r := &Recognizers{}
+
+	err := r.Add(&RecogEntry{Name: "e1", Init: testRecogInit})
+
+	a.Equal(ErrBadRecog, err)
+	a.Nil(r.entries)
+}
+
+func TestRecognizersAddDuplicate(t *testing.T) {
+	a := assert.New(t)
+	e1 := &RecogEntry{Name: "e1", Priority: 1}
+	r := &Recognizers{entries: []*RecogEntry{e1}}
+
+	err := r.Add(&RecogEntry{Name: "e1", Class: CharQuote, Init: testRecogInit})
+
+	a.Equal(ErrDuplicateRecog, err)
+	a.Equal([]*RecogEntry{e1}, r.entries)
+}
+
+func TestRecognizersAddFrozen(t *testing.T) {
+	a := assert.New(t)
+	r := &Recognizers{frozen: true}
+
+	err := r.Add(&RecogEntry{Name: "e1", Class: CharQuote, Init: testRecogInit})
+
+	a.Equal(ErrFrozen, err)
+	a.Nil(r.entries)
+}
+
+func TestRecognizersRemove(t *testing.T) {
+	a := assert.New(t)
+	e1 := &RecogEntry{Name: "e1"}
+	e2 := &RecogEntry{Name: "e2"}
+	e3 := &RecogEntry{Name: "e3"}
+	r := &Recognizers{entries: []*RecogEntry{e1, e2, e3}}
+
+	err := r.Remove("e2")
+
+	a.NoError(err)
+	a.Equal([]*RecogEntry{e1, e3}, r.entries)
+}
+
+func TestRecognizersRemoveMissing(t *testing.T) {
+	a := assert.New(t)
+	e1 := &RecogEntry{Name: "e1"}
+	r := &Recognizers{entries: []*RecogEntry{e1}}
+
+	err := r.Remove("e2")
+
+	a.Equal(ErrNoSuchRecog, err)
+	a.Equal([]*RecogEntry{e1}, r.entries)
+}
+
+func TestRecognizersRemoveFrozen(t *testing.T) {
+	a := assert.New(t)
+	e1 := &RecogEntry{Name: "e1"}
+	r := &Recognizers{entries: []*RecogEntry{e1}, frozen: true}
+
+	err := r.Remove("e1")
+
+	a.Equal(ErrFrozen, err)
+	a.Equal([]*RecogEntry{e1}, r.entries)
+}
+
+func TestRecognizersGet(t *testing.T) {
+	a := assert.New(t)
+	e1 := &RecogEntry{Name: "e1"}
+	e2 := &RecogEntry{Name: "e2"}
+	r := &Recognizers{entries: []*RecogEntry{e1, e2}}
+
+	result := r.Get("e2")
+
+	testutils.AssertPtrEqual(a, e2, result)
+}
+
+func TestRecognizersGetMissing(t *testing.T) {
+	a := assert.New(t)
+	r := &Recognizers{entries: []*RecogEntry{{Name: "e1"}}}
+
+	result := r.Get("e2")
+
+	a.Nil(result)
+}
+
+func TestRecognizersAll(t *testing.T) {
+	a := assert.New(t)
+	e1 := &RecogEntry{Name: "e1"}
+	e2 := &RecogEntry{Name: "e2"}
+	r := &Recognizers{entries: []*RecogEntry{e1, e2}}
+
+	result := r.All()
+
+	a.Equal([]*RecogEntry{e1, e2}, result)
+	result[0] = nil
+	a.Equal([]*RecogEntry{e1, e2}, r.entries)
+}
+
+func TestRecognizersLookup(t *testing.T) {
+	a := assert.New(t)
+	e1 := &RecogEntry{Name: "e1", Class: CharComment}
+	e2 := &RecogEntry{Name: "e2", Class: CharQuote}
+	e3 := &RecogEntry{Name: "e3", Class: CharQuote}
+	r := &Recognizers{entries: []*RecogEntry{e1, e2, e3}}
+
+	result := r.Lookup(AugChar{C: '"', Class: CharQuote}, nil)
+
+	testutils.AssertPtrEqual(a, e2, result)
+}
+
+func TestRecognizersLookupMissing(t *testing.T) {
+	a := assert.New(t)
+	r := &Recognizers{entries: []*RecogEntry{{Name: "e1", Class: CharComment}}}
+
+	result := r.Lookup(AugChar{C: '"', Class: CharQuote}, nil)
+
+	a.Nil(result)
+}
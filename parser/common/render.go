@@ -0,0 +1,338 @@
+// Copyright (c) 2019 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package common
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ANSI escape sequences used when rendering in color.
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiRed    = "\x1b[1;31m"
+	ansiYellow = "\x1b[1;33m"
+	ansiCyan   = "\x1b[1;36m"
+	ansiBlue   = "\x1b[1;34m"
+)
+
+// sevColors maps severities to the colors used to render them.
+var sevColors = map[Severity]string{
+	SevError:   ansiRed,
+	SevWarning: ansiYellow,
+	SevNote:    ansiCyan,
+}
+
+// Characters used to underline spans.
+const (
+	primaryMark   = '^' // Underline for the diagnostic location
+	secondaryMark = '-' // Underline for labels
+)
+
+// elision is the text used to indicate elided lines or columns.
+const elision = "..."
+
+// defaultSpanLines is the default maximum number of lines of a
+// multi-line span to display.
+const defaultSpanLines = 4
+
+// renderCtxt is the context for rendering a diagnostic.
+type renderCtxt struct {
+	buf       *strings.Builder // Buffer for the output
+	color     bool             // Whether to use ANSI colors
+	width     int              // Maximum width of source text
+	tabStop   int              // The size of a tab stop
+	spanLines int              // Maximum lines of a span to display
+}
+
+// RenderOption is an option function for RenderDiagnostic.
+type RenderOption func(ctxt *renderCtxt)
+
+// RenderColor enables or disables the use of ANSI color escapes when
+// rendering a diagnostic.  Color is disabled by default.
+func RenderColor(enable bool) RenderOption {
+	return func(ctxt *renderCtxt) {
+		ctxt.color = enable
+	}
+}
+
+// RenderWidth sets the maximum number of columns of source text to
+// display on a line.  Longer lines are truncated, keeping the
+// underlined portion in view.  A width of 0, the default, indicates
+// no limit.
+func RenderWidth(width int) RenderOption {
+	return func(ctxt *renderCtxt) {
+		ctxt.width = width
+	}
+}
+
+// RenderTabStop sets the size of a tab stop.  This should match the
+// tab stop used when lexing the source, so that columns line up.  If
+// not set, it defaults to 8.
+func RenderTabStop(tabStop int) RenderOption {
+	return func(ctxt *renderCtxt) {
+		ctxt.tabStop = tabStop
+	}
+}
+
+// RenderSpanLines sets the maximum number of lines of a multi-line
+// span to display; the remaining lines are elided.  If not set, it
+// defaults to 4.
+func RenderSpanLines(lines int) RenderOption {
+	return func(ctxt *renderCtxt) {
+		ctxt.spanLines = lines
+	}
+}
+
+// paint wraps text in the specified color, if color is enabled.
+func (c *renderCtxt) paint(color, text string) string {
+	if !c.color || text == "" {
+		return text
+	}
+
+	return color + text + ansiReset
+}
+
+// mark describes a portion of a single line to underline.
+type mark struct {
+	line  int    // The line number
+	b     int    // The first column to underline
+	e     int    // The column after the last to underline
+	ch    rune   // The underline character
+	color string // The color for the underline
+	msg   string // The label message
+}
+
+// firstCol returns the first column of a line that isn't whitespace.
+func firstCol(cols []rune) int {
+	for i, r := range cols {
+		if r != ' ' {
+			return i + 1
+		}
+	}
+
+	return len(cols) + 1
+}
+
+// marks splits a location into marks for each line it covers.  It
+// returns the marks and the list of lines to be displayed.
+func (c *renderCtxt) marks(src *Source, loc Location, ch rune, color, msg string) ([]*mark, []int) {
+	// A location ending at the beginning of a line really ends
+	// with the line ending of the previous line
+	bPos, ePos := loc.B, loc.E
+	if ePos.L > bPos.L && ePos.C == 1 {
+		ePos.L--
+		ePos.C = len(src.Columns(ePos.L)) + 2
+	}
+
+	// Handle single-line locations
+	if bPos.L >= ePos.L {
+		if ePos.C <= bPos.C {
+			ePos.C = bPos.C + 1
+		}
+
+		return []*mark{{bPos.L, bPos.C, ePos.C, ch, color, msg}}, []int{bPos.L}
+	}
+
+	// Select the lines to display
+	var result []*mark
+	var lines []int
+	for n := bPos.L; n <= ePos.L; n++ {
+		// Elide lines from the middle of the span
+		if c.spanLines > 1 && n >= bPos.L+c.spanLines-1 && n < ePos.L {
+			continue
+		}
+		lines = append(lines, n)
+
+		// Compute the span of the mark on this line
+		cols := src.Columns(n)
+		m := &mark{line: n, b: firstCol(cols), e: len(cols) + 1, ch: ch, color: color}
+		if n == bPos.L {
+			m.b = bPos.C
+		}
+		if n == ePos.L {
+			m.e = ePos.C
+			m.msg = msg
+		}
+
+		// Skip empty marks, unless a message must be displayed
+		if m.e <= m.b {
+			if m.msg == "" {
+				continue
+			}
+			m.e = m.b + 1
+		}
+		result = append(result, m)
+	}
+
+	return result, lines
+}
+
+// window selects the range of columns of a line to display, given
+// the marks on the line.  It returns the display text and the offset
+// of the first displayed column.
+func (c *renderCtxt) window(cols []rune, marks []*mark) (string, int) {
+	// Check if there's a width limit in effect
+	if c.width <= len(elision)*2 || len(cols) <= c.width {
+		return string(cols), 0
+	}
+
+	// Select an offset that brings the first mark into view
+	off := 0
+	if len(marks) > 0 && marks[0].b > c.width-len(elision) {
+		off = marks[0].b - 1 - c.width/4
+
+		// Leave room for the elision before the mark
+		if ctx := marks[0].b - 1 - len(elision); off > ctx {
+			off = ctx
+		}
+
+		// Don't scroll past the end of the line; the mark may
+		// be at or past the end, e.g., for an unexpected EOF
+		if end := len(cols) - c.width + len(elision); off > end {
+			off = end
+		}
+		if off < 0 {
+			off = 0
+		}
+	}
+
+	// Construct the text
+	text := cols[off:]
+	if off > 0 {
+		text = append([]rune(elision), text[len(elision):]...)
+	}
+	if len(text) > c.width {
+		text = append(text[:c.width-len(elision)], []rune(elision)...)
+	}
+
+	return string(text), off
+}
+
+// RenderDiagnostic renders a diagnostic to the specified writer.  The
+// rendering includes the diagnostic severity, code, and message,
+// followed by the relevant lines of the source with the location of
+// the diagnostic underlined, along with any secondary labels and
+// suggested fixes.  The source is the text of the file the diagnostic
+// applies to.
+func RenderDiagnostic(w io.Writer, diag *Diagnostic, source []byte, opts ...RenderOption) error {
+	// Initialize the rendering context
+	c := &renderCtxt{
+		buf:       &strings.Builder{},
+		tabStop:   defaultTabStop,
+		spanLines: defaultSpanLines,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	src := NewSource(source, c.tabStop)
+	sevColor := sevColors[diag.Severity]
+
+	// Collect the marks and the lines to display
+	marks, lines := c.marks(src, diag.Loc, primaryMark, sevColor, "")
+	var notes []Label
+	for _, label := range diag.Labels {
+		if label.Loc.File != diag.Loc.File {
+			notes = append(notes, label)
+			continue
+		}
+
+		lMarks, lLines := c.marks(src, label.Loc, secondaryMark, ansiBlue, label.Msg)
+		marks = append(marks, lMarks...)
+		lines = append(lines, lLines...)
+	}
+
+	// Sort the lines and marks
+	sort.Ints(lines)
+	sort.SliceStable(marks, func(i, j int) bool {
+		return marks[i].line < marks[j].line
+	})
+
+	// Compute the gutter
+	gutter := strings.Repeat(" ", len(strconv.Itoa(lines[len(lines)-1])))
+	bar := c.paint(ansiBlue, "|")
+
+	// Render the header
+	c.buf.WriteString(c.paint(sevColor, fmt.Sprintf("%s[%s]", diag.Severity, diag.Code)))
+	c.buf.WriteString(c.paint(ansiBold, ": "+diag.Msg))
+	c.buf.WriteString("\n")
+	c.buf.WriteString(fmt.Sprintf("%s%s %s\n", gutter, c.paint(ansiBlue, "-->"), diag.Loc))
+	c.buf.WriteString(fmt.Sprintf("%s %s\n", gutter, bar))
+
+	// Render the lines
+	prev := 0
+	for _, n := range lines {
+		// Skip duplicates and note gaps
+		if n == prev {
+			continue
+		} else if prev != 0 && n > prev+1 {
+			c.buf.WriteString(c.paint(ansiBlue, elision) + "\n")
+		}
+		prev = n
+
+		// Find the marks for the line
+		var lineMarks []*mark
+		for _, m := range marks {
+			if m.line == n {
+				lineMarks = append(lineMarks, m)
+			}
+		}
+
+		// Render the source line
+		text, off := c.window(src.Columns(n), lineMarks)
+		num := strconv.Itoa(n)
+		line := fmt.Sprintf("%s%s %s %s", gutter[len(num):], c.paint(ansiBlue, num), bar, text)
+		c.buf.WriteString(strings.TrimRight(line, " ") + "\n")
+
+		// Render the underlines
+		for _, m := range lineMarks {
+			b, e := m.b-off, m.e-off
+			if b < 1 {
+				b = 1
+			} else if c.width > 0 && b > c.width {
+				b = c.width
+			}
+			if c.width > 0 && e > c.width+1 {
+				e = c.width + 1
+			}
+			if e <= b {
+				e = b + 1
+			}
+
+			under := strings.Repeat(string(m.ch), e-b)
+			if m.msg != "" {
+				under += " " + m.msg
+			}
+			c.buf.WriteString(fmt.Sprintf("%s %s %s%s\n", gutter, bar, strings.Repeat(" ", b-1), c.paint(m.color, under)))
+		}
+	}
+
+	// Render labels that refer to other files
+	for _, label := range notes {
+		c.buf.WriteString(fmt.Sprintf("%s %s note: %s: %s\n", gutter, c.paint(ansiBlue, "="), label.Loc, label.Msg))
+	}
+
+	// Render the suggested fixes
+	for _, fix := range diag.Fixes {
+		c.buf.WriteString(fmt.Sprintf("%s %s help: %s\n", gutter, c.paint(ansiBlue, "="), fix.Msg))
+	}
+
+	_, err := io.WriteString(w, c.buf.String())
+	return err
+}
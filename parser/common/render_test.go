@@ -0,0 +1,352 @@
+// Copyright (c) 2019 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package common
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderColor(t *testing.T) {
+	a := assert.New(t)
+	ctxt := &renderCtxt{}
+
+	opt := RenderColor(true)
+	opt(ctxt)
+
+	a.Equal(&renderCtxt{color: true}, ctxt)
+}
+
+func TestRenderWidth(t *testing.T) {
+	a := assert.New(t)
+	ctxt := &renderCtxt{}
+
+	opt := RenderWidth(40)
+	opt(ctxt)
+
+	a.Equal(&renderCtxt{width: 40}, ctxt)
+}
+
+func TestRenderTabStop(t *testing.T) {
+	a := assert.New(t)
+	ctxt := &renderCtxt{}
+
+	opt := RenderTabStop(4)
+	opt(ctxt)
+
+	a.Equal(&renderCtxt{tabStop: 4}, ctxt)
+}
+
+func TestRenderSpanLines(t *testing.T) {
+	a := assert.New(t)
+	ctxt := &renderCtxt{}
+
+	opt := RenderSpanLines(2)
+	opt(ctxt)
+
+	a.Equal(&renderCtxt{spanLines: 2}, ctxt)
+}
+
+func TestRenderCtxtPaintDisabled(t *testing.T) {
+	a := assert.New(t)
+	ctxt := &renderCtxt{}
+
+	result := ctxt.paint(ansiRed, "text")
+
+	a.Equal("text", result)
+}
+
+func TestRenderCtxtPaintEnabled(t *testing.T) {
+	a := assert.New(t)
+	ctxt := &renderCtxt{color: true}
+
+	result := ctxt.paint(ansiRed, "text")
+
+	a.Equal(ansiRed+"text"+ansiReset, result)
+}
+
+func TestRenderCtxtPaintEmpty(t *testing.T) {
+	a := assert.New(t)
+	ctxt := &renderCtxt{color: true}
+
+	result := ctxt.paint(ansiRed, "")
+
+	a.Equal("", result)
+}
+
+func TestFirstCol(t *testing.T) {
+	a := assert.New(t)
+
+	a.Equal(3, firstCol([]rune("  ab")))
+	a.Equal(3, firstCol([]rune("  ")))
+}
+
+func TestRenderDiagnosticBase(t *testing.T) {
+	a := assert.New(t)
+	diag := Diagnose(ErrBadOp, Location{
+		File: "file",
+		B:    FilePos{L: 2, C: 5},
+		E:    FilePos{L: 2, C: 7},
+	})
+	buf := &bytes.Buffer{}
+
+	err := RenderDiagnostic(buf, diag, []byte("a = 1\nb = $$ 2\n"))
+
+	a.NoError(err)
+	a.Equal(`error[H0103]: bad operator character
+ --> file:2:5-7
+  |
+2 | b = $$ 2
+  |     ^^
+`, buf.String())
+}
+
+func TestRenderDiagnosticLabels(t *testing.T) {
+	a := assert.New(t)
+	openTok := &Token{
+		Sym: &Symbol{Name: "("},
+		Loc: Location{
+			File: "file",
+			B:    FilePos{L: 1, C: 5},
+			E:    FilePos{L: 1, C: 6},
+		},
+	}
+	diag := ErrOpMismatch(openTok, &Symbol{Name: "]"})
+	diag.Loc = Location{
+		File: "file",
+		B:    FilePos{L: 10, C: 4},
+		E:    FilePos{L: 10, C: 5},
+	}
+	buf := &bytes.Buffer{}
+
+	err := RenderDiagnostic(buf, diag, []byte("x = (\n  1,\n  2,\n  3,\n  4,\n  5,\n  6,\n  7,\n  8,\n  9]\n"))
+
+	a.NoError(err)
+	a.Equal(`error[H0113]: close operator "]" does not match open operator "(" at file:1:5
+  --> file:10:4
+   |
+ 1 | x = (
+   |     - open operator "(" is here
+...
+10 |   9]
+   |    ^
+`, buf.String())
+}
+
+func TestRenderDiagnosticOtherFile(t *testing.T) {
+	a := assert.New(t)
+	diag := Diagnose(ErrBadOp, Location{
+		File: "file",
+		B:    FilePos{L: 1, C: 3},
+		E:    FilePos{L: 1, C: 4},
+	})
+	diag.Labels = []Label{
+		{
+			Loc: Location{
+				File: "other",
+				B:    FilePos{L: 1, C: 1},
+				E:    FilePos{L: 1, C: 2},
+			},
+			Msg: "defined here",
+		},
+	}
+	buf := &bytes.Buffer{}
+
+	err := RenderDiagnostic(buf, diag, []byte("a $ b"))
+
+	a.NoError(err)
+	a.Equal(`error[H0103]: bad operator character
+ --> file:1:3
+  |
+1 | a $ b
+  |   ^
+  = note: other:1:1: defined here
+`, buf.String())
+}
+
+func TestRenderDiagnosticLineEnding(t *testing.T) {
+	a := assert.New(t)
+	diag := Diagnose(ErrUnclosedStr, Location{
+		File: "file",
+		B:    FilePos{L: 1, C: 5},
+		E:    FilePos{L: 2, C: 1},
+	})
+	buf := &bytes.Buffer{}
+
+	err := RenderDiagnostic(buf, diag, []byte("s = 'abc\nt = 1\n"))
+
+	a.NoError(err)
+	a.Equal(`error[H0109]: unclosed string literal
+ --> file:1:5-2:1
+  |
+1 | s = 'abc
+  |     ^^^^^
+`, buf.String())
+}
+
+func TestRenderDiagnosticMultiLine(t *testing.T) {
+	a := assert.New(t)
+	diag := Diagnose(ErrUnclosedStr, Location{
+		File: "file",
+		B:    FilePos{L: 1, C: 5},
+		E:    FilePos{L: 6, C: 7},
+	})
+	buf := &bytes.Buffer{}
+
+	err := RenderDiagnostic(buf, diag, []byte("s = '''abc\n\n\tdef\nghi\njkl\n  mnop"), RenderTabStop(4))
+
+	a.NoError(err)
+	a.Equal(`error[H0109]: unclosed string literal
+ --> file:1:5-6:7
+  |
+1 | s = '''abc
+  |     ^^^^^^
+2 |
+3 |     def
+  |     ^^^
+...
+6 |   mnop
+  |   ^^^^
+`, buf.String())
+}
+
+func TestRenderDiagnosticWidth(t *testing.T) {
+	a := assert.New(t)
+	diag := Diagnose(ErrBadOp, Location{
+		File: "file",
+		B:    FilePos{L: 1, C: 31},
+		E:    FilePos{L: 1, C: 32},
+	})
+	buf := &bytes.Buffer{}
+
+	err := RenderDiagnostic(buf, diag, []byte("abcdefghijklmnopqrstuvwxyz0123$56789abcdefghij\n"), RenderWidth(20))
+
+	a.NoError(err)
+	a.Equal(`error[H0103]: bad operator character
+ --> file:1:31
+  |
+1 | ...23$56789abcdef...
+  |      ^
+`, buf.String())
+}
+
+func TestRenderDiagnosticWidthEndOfLine(t *testing.T) {
+	a := assert.New(t)
+	diag := Diagnose(ErrUnclosedStr, Location{
+		File: "file",
+		B:    FilePos{L: 1, C: 32},
+		E:    FilePos{L: 2, C: 1},
+	})
+	buf := &bytes.Buffer{}
+
+	err := RenderDiagnostic(buf, diag, []byte("abcdefghijklmnopqrstuvwxyz01234\n"), RenderWidth(20))
+
+	a.NoError(err)
+	a.Equal(`error[H0109]: unclosed string literal
+ --> file:1:32-2:1
+  |
+1 | ...rstuvwxyz01234
+  |                  ^
+`, buf.String())
+}
+
+func TestRenderDiagnosticWidthPastEnd(t *testing.T) {
+	a := assert.New(t)
+	diag := Diagnose(ErrUnclosedStr, Location{
+		File: "file",
+		B:    FilePos{L: 1, C: 40},
+		E:    FilePos{L: 1, C: 41},
+	})
+	buf := &bytes.Buffer{}
+
+	err := RenderDiagnostic(buf, diag, []byte("abcdefghijklmnopqrstuvwxyz01234\n"), RenderWidth(20))
+
+	a.NoError(err)
+	a.Equal(`error[H0109]: unclosed string literal
+ --> file:1:40
+  |
+1 | ...rstuvwxyz01234
+  |                    ^
+`, buf.String())
+}
+
+func TestRenderDiagnosticWidthNarrow(t *testing.T) {
+	a := assert.New(t)
+	diag := Diagnose(ErrBadOp, Location{
+		File: "file",
+		B:    FilePos{L: 1, C: 30},
+		E:    FilePos{L: 1, C: 31},
+	})
+	buf := &bytes.Buffer{}
+
+	err := RenderDiagnostic(buf, diag, []byte("abcdefghijklmnopqrstuvwxyz012$456789\n"), RenderWidth(10))
+
+	a.NoError(err)
+	a.Equal(`error[H0103]: bad operator character
+ --> file:1:30
+  |
+1 | ...$456789
+  |    ^
+`, buf.String())
+}
+
+func TestRenderDiagnosticColor(t *testing.T) {
+	a := assert.New(t)
+	diag := Diagnose(ErrBadOp, Location{
+		File: "file",
+		B:    FilePos{L: 1, C: 3},
+		E:    FilePos{L: 1, C: 4},
+	})
+	diag.Severity = SevWarning
+	buf := &bytes.Buffer{}
+
+	err := RenderDiagnostic(buf, diag, []byte("a $ b"), RenderColor(true))
+
+	a.NoError(err)
+	a.Equal(ansiYellow+"warning[H0103]"+ansiReset+ansiBold+": bad operator character"+ansiReset+"\n"+
+		" "+ansiBlue+"-->"+ansiReset+" file:1:3\n"+
+		"  "+ansiBlue+"|"+ansiReset+"\n"+
+		ansiBlue+"1"+ansiReset+" "+ansiBlue+"|"+ansiReset+" a $ b\n"+
+		"  "+ansiBlue+"|"+ansiReset+"   "+ansiYellow+"^"+ansiReset+"\n", buf.String())
+}
+
+func TestRenderDiagnosticFixes(t *testing.T) {
+	a := assert.New(t)
+	diag := Diagnose(ErrUnclosedStr, Location{
+		File: "file",
+		B:    FilePos{L: 1, C: 9},
+		E:    FilePos{L: 2, C: 1},
+	})
+	diag.Fixes = []Fix{
+		{
+			Msg:   "insert the missing close quote",
+			Edits: []Edit{Insert(diag.Loc, "'")},
+		},
+	}
+	buf := &bytes.Buffer{}
+
+	err := RenderDiagnostic(buf, diag, []byte("a = 'abc\n"))
+
+	a.NoError(err)
+	a.Equal(`error[H0109]: unclosed string literal
+ --> file:1:9-2:1
+  |
+1 | a = 'abc
+  |         ^
+  = help: insert the missing close quote
+`, buf.String())
+}
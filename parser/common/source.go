@@ -0,0 +1,141 @@
+// Copyright (c) 2019 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package common
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Source describes the text of a source file, split into lines.  It
+// is used to map locations back to the text they describe.  The text
+// is expected to be UTF-8; line endings may be any of the styles
+// accepted by the scanner.
+type Source struct {
+	text    []byte // The source text
+	lines   []int  // Offsets of the beginning of each line
+	ends    []int  // Offsets of the end of each line's content
+	tabStop int    // The size of a tab stop
+}
+
+// NewSource constructs a Source from the specified text.  The tab
+// stop should match the one used when scanning the text; if 0, the
+// default is used.
+func NewSource(text []byte, tabStop int) *Source {
+	if tabStop == 0 {
+		tabStop = defaultTabStop
+	}
+
+	src := &Source{
+		text:    text,
+		lines:   []int{0},
+		tabStop: tabStop,
+	}
+
+	// Find the line endings
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '\r':
+			src.ends = append(src.ends, i)
+			if i+1 < len(text) && text[i+1] == '\n' {
+				i++
+			}
+			src.lines = append(src.lines, i+1)
+
+		case '\n':
+			src.ends = append(src.ends, i)
+			src.lines = append(src.lines, i+1)
+		}
+	}
+	src.ends = append(src.ends, len(text))
+
+	return src
+}
+
+// Lines returns the number of lines in the source.  A source that
+// ends with a line ending has an empty final line.
+func (s *Source) Lines() int {
+	return len(s.lines)
+}
+
+// Line returns the text of the specified line, numbered from 1, not
+// including the line ending.  Returns nil if the line does not exist.
+func (s *Source) Line(n int) []byte {
+	if n < 1 || n > len(s.lines) {
+		return nil
+	}
+
+	return s.text[s.lines[n-1]:s.ends[n-1]]
+}
+
+// Columns returns the text of the specified line as a sequence of
+// display columns: element i of the result is the character
+// displayed in column i+1.  Tabs are expanded to spaces, and other
+// whitespace is displayed as a space, so that the columns correspond
+// to the columns of a Location.
+func (s *Source) Columns(n int) []rune {
+	line := s.Line(n)
+	opts := &Options{TabStop: s.tabStop}
+	loc := Location{B: FilePos{L: n, C: 1}, E: FilePos{L: n, C: 1}}
+	result := make([]rune, 0, len(line))
+
+	for len(line) > 0 {
+		r, w := utf8.DecodeRune(line)
+		line = line[w:]
+
+		// Figure out how many columns the character occupies
+		col := loc.E.C
+		opts.Advance(r, &loc)
+		width := loc.E.C - col
+
+		// Whitespace displays as spaces
+		if unicode.IsSpace(r) {
+			r = ' '
+		}
+		for ; width > 0; width-- {
+			result = append(result, r)
+		}
+	}
+
+	return result
+}
+
+// Offset returns the byte offset within the source text of the
+// specified position.  The column just past the end of a line refers
+// to the line ending, or to the end of the text on the last line.
+// Returns false if the position does not exist in the source, or if
+// it falls within the columns occupied by a tab.
+func (s *Source) Offset(pos FilePos) (int, bool) {
+	if pos.L < 1 || pos.L > len(s.lines) {
+		return 0, false
+	}
+
+	// Step through the line until the column is reached
+	off, end := s.lines[pos.L-1], s.ends[pos.L-1]
+	opts := &Options{TabStop: s.tabStop}
+	loc := Location{B: FilePos{L: pos.L, C: 1}, E: FilePos{L: pos.L, C: 1}}
+	for loc.E.C < pos.C && off < end {
+		r, w := utf8.DecodeRune(s.text[off:end])
+		off += w
+		opts.Advance(r, &loc)
+	}
+
+	// Make sure the position is at a character boundary
+	if loc.E.C != pos.C {
+		return 0, false
+	}
+
+	return off, true
+}
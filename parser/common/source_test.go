@@ -0,0 +1,129 @@
+// Copyright (c) 2019 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSource(t *testing.T) {
+	a := assert.New(t)
+
+	result := NewSource([]byte("one\ntwo\r\nthree\rfour"), 0)
+
+	a.Equal(&Source{
+		text:    []byte("one\ntwo\r\nthree\rfour"),
+		lines:   []int{0, 4, 9, 15},
+		ends:    []int{3, 7, 14, 19},
+		tabStop: 8,
+	}, result)
+}
+
+func TestNewSourceTabStop(t *testing.T) {
+	a := assert.New(t)
+
+	result := NewSource([]byte("one\n"), 4)
+
+	a.Equal(&Source{
+		text:    []byte("one\n"),
+		lines:   []int{0, 4},
+		ends:    []int{3, 4},
+		tabStop: 4,
+	}, result)
+}
+
+func TestSourceLines(t *testing.T) {
+	a := assert.New(t)
+	src := NewSource([]byte("one\ntwo\n"), 0)
+
+	result := src.Lines()
+
+	a.Equal(3, result)
+}
+
+func TestSourceLine(t *testing.T) {
+	a := assert.New(t)
+	src := NewSource([]byte("one\ntwo\r\nthree"), 0)
+
+	a.Nil(src.Line(0))
+	a.Equal([]byte("one"), src.Line(1))
+	a.Equal([]byte("two"), src.Line(2))
+	a.Equal([]byte("three"), src.Line(3))
+	a.Nil(src.Line(4))
+}
+
+func TestSourceColumns(t *testing.T) {
+	a := assert.New(t)
+	src := NewSource([]byte("\fa\tb\u00e9\v"), 4)
+
+	result := src.Columns(1)
+
+	a.Equal([]rune("a   b\u00e9 "), result)
+}
+
+func TestSourceOffset(t *testing.T) {
+	a := assert.New(t)
+	src := NewSource([]byte("a\tb\u00e9\r\n\fc"), 4)
+
+	off, ok := src.Offset(FilePos{L: 1, C: 1})
+	a.True(ok)
+	a.Equal(0, off)
+
+	off, ok = src.Offset(FilePos{L: 1, C: 2})
+	a.True(ok)
+	a.Equal(1, off)
+
+	off, ok = src.Offset(FilePos{L: 1, C: 5})
+	a.True(ok)
+	a.Equal(2, off)
+
+	off, ok = src.Offset(FilePos{L: 1, C: 6})
+	a.True(ok)
+	a.Equal(3, off)
+
+	off, ok = src.Offset(FilePos{L: 1, C: 7})
+	a.True(ok)
+	a.Equal(5, off)
+
+	off, ok = src.Offset(FilePos{L: 2, C: 1})
+	a.True(ok)
+	a.Equal(7, off)
+
+	off, ok = src.Offset(FilePos{L: 2, C: 2})
+	a.True(ok)
+	a.Equal(9, off)
+}
+
+func TestSourceOffsetInvalid(t *testing.T) {
+	a := assert.New(t)
+	src := NewSource([]byte("a\tb\u00e9\r\n\fc"), 4)
+
+	_, ok := src.Offset(FilePos{L: 0, C: 1})
+	a.False(ok)
+
+	_, ok = src.Offset(FilePos{L: 1, C: 3})
+	a.False(ok)
+
+	_, ok = src.Offset(FilePos{L: 1, C: 8})
+	a.False(ok)
+
+	_, ok = src.Offset(FilePos{L: 2, C: 3})
+	a.False(ok)
+
+	_, ok = src.Offset(FilePos{L: 3, C: 1})
+	a.False(ok)
+}
@@ -24,6 +24,7 @@ const (
 	StrBytes                    // Byte strings
 	StrMulti                    // Multi-line (triple-quoted) string
 	StrTriple                   // Quote allows triples
+	StrFormat                   // Formatted (interpolated) string
 )
 
 // StrFlags is a mapping of string flags to names.
@@ -32,6 +33,7 @@ var StrFlags = utils.FlagSet8{
 	StrBytes:  "bytes",
 	StrMulti:  "multi-line",
 	StrTriple: "triple quote",
+	StrFormat: "format",
 }
 
 // StrEscape is a function type for handling string escapes.  It is
@@ -57,7 +59,7 @@ func HexEscape(cnt int) StrEscape {
 		bLoc := ch.Loc
 
 		// Count off the specified number of characters
-		for cnt--; cnt >= 0; cnt-- {
+		for i := cnt - 1; i >= 0; i-- {
 			ch = s.Next()
 			if ch.C == Err {
 				return 0, ch.Loc, ch.Val.(error)
@@ -66,7 +68,7 @@ func HexEscape(cnt int) StrEscape {
 			}
 
 			// Accumulate the digit
-			r |= rune(ch.Val.(int)) << (4 * uint(cnt))
+			r |= rune(ch.Val.(int)) << (4 * uint(i))
 		}
 
 		// Return the rune
@@ -84,6 +84,42 @@ func TestHexEscapeBase(t *testing.T) {
 	s.AssertExpectations(t)
 }
 
+func TestHexEscapeReused(t *testing.T) {
+	a := assert.New(t)
+	s := &MockScanner{}
+	for i := 0; i < 2; i++ {
+		s.On("Next").Return(AugChar{
+			C:     '6',
+			Class: CharHexDigit,
+			Loc: Location{
+				File: "file",
+				B:    FilePos{L: 3, C: 2},
+				E:    FilePos{L: 3, C: 3},
+			},
+			Val: 6,
+		}).Once()
+		s.On("Next").Return(AugChar{
+			C:     '1',
+			Class: CharHexDigit,
+			Loc: Location{
+				File: "file",
+				B:    FilePos{L: 3, C: 3},
+				E:    FilePos{L: 3, C: 4},
+			},
+			Val: 1,
+		}).Once()
+	}
+	ch := AugChar{Loc: Location{File: "file"}}
+	esc := HexEscape(2)
+	esc(ch, s, 0)
+
+	r, _, err := esc(ch, s, 0)
+
+	a.NoError(err)
+	a.Equal('a', r)
+	s.AssertExpectations(t)
+}
+
 func TestHexEscapeErr(t *testing.T) {
 	a := assert.New(t)
 	s := &MockScanner{}
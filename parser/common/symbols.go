@@ -0,0 +1,143 @@
+// Copyright (c) 2019 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package common
+
+// SymTab is a table of symbols, assigning each symbol a dense
+// integer ID.  The standard token symbols have the IDs given by the
+// ID constants, such as IDEOF; the symbols defined by a profile
+// follow.  A symbol table is constructed by Profile.Build.
+type SymTab struct {
+	syms  []*Symbol          // Symbols, indexed by ID
+	ids   map[*Symbol]int    // Mapping of symbols to IDs
+	names map[string]*Symbol // Mapping of names to symbols
+}
+
+// newSymTab constructs a symbol table containing the standard
+// symbols.  The reserved IDs below IDFirstProfile have no symbol.
+func newSymTab() *SymTab {
+	tab := &SymTab{
+		syms:  make([]*Symbol, IDFirstProfile),
+		ids:   map[*Symbol]int{},
+		names: map[string]*Symbol{},
+	}
+	for id, sym := range StdSymbols {
+		tab.syms[id] = sym
+		tab.ids[sym] = id
+		tab.names[sym.Name] = sym
+	}
+
+	return tab
+}
+
+// add adds a symbol to the symbol table, if it is not already
+// present.  If several symbols have the same name, the first one
+// added is found by Lookup.
+func (t *SymTab) add(sym *Symbol) {
+	if _, ok := t.ids[sym]; ok {
+		return
+	}
+
+	t.ids[sym] = len(t.syms)
+	t.syms = append(t.syms, sym)
+	if _, ok := t.names[sym.Name]; !ok {
+		t.names[sym.Name] = sym
+	}
+}
+
+// Len returns the number of IDs in the symbol table, including the
+// reserved IDs below IDFirstProfile.
+func (t *SymTab) Len() int {
+	return len(t.syms)
+}
+
+// ID looks up the ID of a symbol.  The boolean result is false if the
+// symbol is not in the table.
+func (t *SymTab) ID(sym *Symbol) (int, bool) {
+	id, ok := t.ids[sym]
+	return id, ok
+}
+
+// Symbol looks up a symbol by ID.  Returns nil if there is no symbol
+// with that ID.
+func (t *SymTab) Symbol(id int) *Symbol {
+	if id < 0 || id >= len(t.syms) {
+		return nil
+	}
+
+	return t.syms[id]
+}
+
+// Lookup looks up a symbol by name.  Returns nil if there is no
+// symbol with that name.
+func (t *SymTab) Lookup(name string) *Symbol {
+	return t.names[name]
+}
+
+// Symbols returns the symbols in the table, indexed by ID.  The
+// entries for reserved IDs are nil.
+func (t *SymTab) Symbols() []*Symbol {
+	return append([]*Symbol{}, t.syms...)
+}
+
+// SymSet is a set of symbol IDs, represented as a bitset.  The zero
+// value is an empty set.
+type SymSet []uint64
+
+// NewSymSet constructs a set containing the specified symbol IDs.
+func NewSymSet(ids ...int) SymSet {
+	var s SymSet
+	for _, id := range ids {
+		s.Add(id)
+	}
+
+	return s
+}
+
+// Add adds a symbol ID to the set.
+func (s *SymSet) Add(id int) {
+	word := id / 64
+	for len(*s) <= word {
+		*s = append(*s, 0)
+	}
+
+	(*s)[word] |= 1 << uint(id%64)
+}
+
+// Remove removes a symbol ID from the set.
+func (s SymSet) Remove(id int) {
+	if word := id / 64; word < len(s) {
+		s[word] &^= 1 << uint(id%64)
+	}
+}
+
+// Has tests whether a symbol ID is in the set.
+func (s SymSet) Has(id int) bool {
+	word := id / 64
+	return word < len(s) && s[word]&(1<<uint(id%64)) != 0
+}
+
+// Union returns a new set containing the symbol IDs in either set.
+func (s SymSet) Union(other SymSet) SymSet {
+	if len(s) < len(other) {
+		s, other = other, s
+	}
+
+	result := append(SymSet{}, s...)
+	for i, word := range other {
+		result[i] |= word
+	}
+
+	return result
+}
@@ -0,0 +1,198 @@
+// Copyright (c) 2019 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package common
+
+import (
+	"testing"
+
+	"github.com/hydralang/hydra/testutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSymTab(t *testing.T) {
+	a := assert.New(t)
+
+	result := newSymTab()
+
+	a.Len(result.syms, IDFirstProfile)
+	a.Equal(StdSymbols, result.syms[:len(StdSymbols)])
+	for _, sym := range result.syms[len(StdSymbols):] {
+		a.Nil(sym)
+	}
+	a.Len(result.ids, len(StdSymbols))
+	a.Equal(IDFExprEnd, result.ids[TokFExprEnd])
+	testutils.AssertPtrEqual(a, TokEOF, result.names[TokEOF.Name])
+}
+
+func TestSymTabAdd(t *testing.T) {
+	a := assert.New(t)
+	sym := &Symbol{Name: "sym"}
+	tab := newSymTab()
+
+	tab.add(sym)
+
+	a.Equal(IDFirstProfile+1, len(tab.syms))
+	a.Equal(IDFirstProfile, tab.ids[sym])
+}
+
+func TestSymTabAddDuplicate(t *testing.T) {
+	a := assert.New(t)
+	sym := &Symbol{Name: "sym"}
+	tab := newSymTab()
+	tab.add(sym)
+
+	tab.add(sym)
+
+	a.Equal(IDFirstProfile+1, len(tab.syms))
+}
+
+func TestSymTabLen(t *testing.T) {
+	a := assert.New(t)
+	tab := newSymTab()
+
+	result := tab.Len()
+
+	a.Equal(IDFirstProfile, result)
+}
+
+func TestSymTabIDPresent(t *testing.T) {
+	a := assert.New(t)
+	tab := newSymTab()
+
+	id, ok := tab.ID(TokInt)
+
+	a.True(ok)
+	a.Equal(IDInt, id)
+}
+
+func TestSymTabIDAbsent(t *testing.T) {
+	a := assert.New(t)
+	tab := newSymTab()
+
+	_, ok := tab.ID(&Symbol{Name: "sym"})
+
+	a.False(ok)
+}
+
+func TestSymTabSymbolPresent(t *testing.T) {
+	a := assert.New(t)
+	tab := newSymTab()
+
+	result := tab.Symbol(IDNewline)
+
+	a.Equal(TokNewline, result)
+}
+
+func TestSymTabSymbolAbsent(t *testing.T) {
+	a := assert.New(t)
+	tab := newSymTab()
+
+	a.Nil(tab.Symbol(-1))
+	a.Nil(tab.Symbol(IDFExprEnd + 1))
+	a.Nil(tab.Symbol(IDFirstProfile))
+}
+
+func TestSymTabLookupPresent(t *testing.T) {
+	a := assert.New(t)
+	sym := &Symbol{Name: "sym"}
+	tab := newSymTab()
+	tab.add(sym)
+
+	result := tab.Lookup("sym")
+
+	a.Equal(sym, result)
+}
+
+func TestSymTabLookupAbsent(t *testing.T) {
+	a := assert.New(t)
+	tab := newSymTab()
+
+	result := tab.Lookup("sym")
+
+	a.Nil(result)
+}
+
+func TestSymTabLookupDuplicate(t *testing.T) {
+	a := assert.New(t)
+	sym1 := &Symbol{Name: "sym"}
+	sym2 := &Symbol{Name: "sym"}
+	tab := newSymTab()
+	tab.add(sym1)
+	tab.add(sym2)
+
+	result := tab.Lookup("sym")
+
+	testutils.AssertPtrEqual(a, sym1, result)
+}
+
+func TestSymTabSymbols(t *testing.T) {
+	a := assert.New(t)
+	tab := newSymTab()
+
+	result := tab.Symbols()
+
+	a.Len(result, IDFirstProfile)
+	a.Equal(StdSymbols, result[:len(StdSymbols)])
+	result[0] = nil
+	a.Equal(TokError, tab.syms[0])
+}
+
+func TestNewSymSet(t *testing.T) {
+	a := assert.New(t)
+
+	result := NewSymSet(1, 64, 130)
+
+	a.Equal(SymSet{1 << 1, 1 << 0, 1 << 2}, result)
+}
+
+func TestSymSetAdd(t *testing.T) {
+	a := assert.New(t)
+	var s SymSet
+
+	s.Add(65)
+
+	a.Equal(SymSet{0, 1 << 1}, s)
+}
+
+func TestSymSetRemove(t *testing.T) {
+	a := assert.New(t)
+	s := NewSymSet(3, 65)
+
+	s.Remove(65)
+	s.Remove(200)
+
+	a.Equal(SymSet{1 << 3, 0}, s)
+}
+
+func TestSymSetHas(t *testing.T) {
+	a := assert.New(t)
+	s := NewSymSet(3, 65)
+
+	a.True(s.Has(3))
+	a.True(s.Has(65))
+	a.False(s.Has(4))
+	a.False(s.Has(200))
+}
+
+func TestSymSetUnion(t *testing.T) {
+	a := assert.New(t)
+	s1 := NewSymSet(3)
+	s2 := NewSymSet(4, 65)
+
+	result := s1.Union(s2)
+
+	a.Equal(NewSymSet(3, 4, 65), result)
+	a.Equal(NewSymSet(3), s1)
+}
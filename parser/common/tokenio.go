@@ -0,0 +1,573 @@
+// Copyright (c) 2019 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// Value types used when serializing tokens.
+const (
+	valString = "string" // A string value
+	valBytes  = "bytes"  // A bytes value, encoded in base64
+	valInt    = "int"    // An integer value, as a decimal string
+	valFloat  = "float"  // A floating point value, as a number
+	valError  = "error"  // A diagnostic
+)
+
+// Argument types used when serializing the arguments and fields of
+// diagnostics.
+const (
+	argString = "string" // A string
+	argInt    = "int"    // An integer
+	argInts   = "ints"   // A list of integers
+	argSymbol = "symbol" // A symbol, encoded as its name
+	argToken  = "token"  // A token, encoded as a token record
+)
+
+// tokenRecord is the serialized form of a token.  Each token is
+// written as a single JSON object on a line by itself.
+type tokenRecord struct {
+	Sym     string          `json:"sym"`            // The symbol name
+	File    string          `json:"file"`           // The file name
+	Line    int             `json:"line"`           // The first line
+	Col     int             `json:"col"`            // The first column
+	EndLine int             `json:"endLine"`        // The last line
+	EndCol  int             `json:"endCol"`         // The column after the last
+	Text    string          `json:"text,omitempty"` // The source text
+	Type    string          `json:"type,omitempty"` // The value type
+	Val     json.RawMessage `json:"val,omitempty"`  // The value
+}
+
+// locRecord is the serialized form of a location.
+type locRecord struct {
+	File    string `json:"file"`    // The file name
+	Line    int    `json:"line"`    // The first line
+	Col     int    `json:"col"`     // The first column
+	EndLine int    `json:"endLine"` // The last line
+	EndCol  int    `json:"endCol"`  // The column after the last
+}
+
+// argRecord is the serialized form of an argument or field of a
+// diagnostic.
+type argRecord struct {
+	Type string          `json:"type"` // The argument type
+	Val  json.RawMessage `json:"val"`  // The value
+}
+
+// labelRecord is the serialized form of a diagnostic label.
+type labelRecord struct {
+	Loc locRecord `json:"loc"` // The location being labeled
+	Msg string    `json:"msg"` // The label text
+}
+
+// editRecord is the serialized form of an edit.
+type editRecord struct {
+	Loc  locRecord `json:"loc"`  // The location of the text to replace
+	Text string    `json:"text"` // The replacement text
+}
+
+// fixRecord is the serialized form of a suggested fix.
+type fixRecord struct {
+	Msg   string       `json:"msg"`             // Description of the fix
+	Edits []editRecord `json:"edits,omitempty"` // The edits to apply
+}
+
+// errorRecord is the serialized form of a diagnostic value.
+type errorRecord struct {
+	Code     string               `json:"code"`             // The diagnostic code
+	Severity string               `json:"severity"`         // The severity name
+	Msg      string               `json:"msg"`              // The message
+	Args     []argRecord          `json:"args,omitempty"`   // Message arguments
+	Fields   map[string]argRecord `json:"fields,omitempty"` // Structured data
+	Labels   []labelRecord        `json:"labels,omitempty"` // Secondary labels
+	Fixes    []fixRecord          `json:"fixes,omitempty"`  // Suggested fixes
+}
+
+// encodeLoc encodes a location.
+func encodeLoc(loc Location) locRecord {
+	return locRecord{
+		File:    loc.File,
+		Line:    loc.B.L,
+		Col:     loc.B.C,
+		EndLine: loc.E.L,
+		EndCol:  loc.E.C,
+	}
+}
+
+// decodeLoc decodes a location.
+func decodeLoc(rec locRecord) Location {
+	return Location{
+		File: rec.File,
+		B:    FilePos{L: rec.Line, C: rec.Col},
+		E:    FilePos{L: rec.EndLine, C: rec.EndCol},
+	}
+}
+
+// encodeArg encodes an argument or field of a diagnostic.
+func encodeArg(arg interface{}) (argRecord, error) {
+	var typ string
+	var val interface{}
+	switch v := arg.(type) {
+	case string:
+		typ, val = argString, v
+
+	case int:
+		typ, val = argInt, v
+
+	case []int:
+		typ, val = argInts, v
+
+	case *Symbol:
+		typ, val = argSymbol, v.Name
+
+	case *Token:
+		rec, err := encodeToken(v)
+		if err != nil {
+			return argRecord{}, err
+		}
+		typ, val = argToken, rec
+
+	default:
+		return argRecord{}, ErrBadTokenValue
+	}
+
+	data, err := marshal(val)
+	if err != nil {
+		return argRecord{}, err
+	}
+	return argRecord{Type: typ, Val: data}, nil
+}
+
+// decodeArg decodes an argument or field of a diagnostic.  Symbols
+// are resolved using the profile.
+func decodeArg(rec argRecord, prof *Profile) (interface{}, error) {
+	switch rec.Type {
+	case argString:
+		var v string
+		err := json.Unmarshal(rec.Val, &v)
+		return v, err
+
+	case argInt:
+		var v int
+		err := json.Unmarshal(rec.Val, &v)
+		return v, err
+
+	case argInts:
+		var v []int
+		err := json.Unmarshal(rec.Val, &v)
+		return v, err
+
+	case argSymbol:
+		var name string
+		if err := json.Unmarshal(rec.Val, &name); err != nil {
+			return nil, err
+		}
+		sym := lookupSymbol(name, prof)
+		if sym == nil {
+			return nil, fmt.Errorf("%w %q", ErrUnknownSymbol, name)
+		}
+		return sym, nil
+
+	case argToken:
+		var tokRec tokenRecord
+		if err := json.Unmarshal(rec.Val, &tokRec); err != nil {
+			return nil, err
+		}
+		return decodeToken(tokRec, prof)
+	}
+
+	return nil, ErrBadTokenValue
+}
+
+// encodeDiagnostic encodes a diagnostic, including its arguments,
+// fields, labels, and suggested fixes.
+func encodeDiagnostic(diag *Diagnostic) (errorRecord, error) {
+	rec := errorRecord{
+		Code:     diag.Code,
+		Severity: diag.Severity.String(),
+		Msg:      diag.Msg,
+	}
+
+	// Encode the arguments and fields
+	for _, arg := range diag.Args {
+		argRec, err := encodeArg(arg)
+		if err != nil {
+			return errorRecord{}, err
+		}
+		rec.Args = append(rec.Args, argRec)
+	}
+	for name, field := range diag.Fields {
+		argRec, err := encodeArg(field)
+		if err != nil {
+			return errorRecord{}, err
+		}
+		if rec.Fields == nil {
+			rec.Fields = map[string]argRecord{}
+		}
+		rec.Fields[name] = argRec
+	}
+
+	// Encode the labels and fixes
+	for _, label := range diag.Labels {
+		rec.Labels = append(rec.Labels, labelRecord{Loc: encodeLoc(label.Loc), Msg: label.Msg})
+	}
+	for _, fix := range diag.Fixes {
+		fixRec := fixRecord{Msg: fix.Msg}
+		for _, edit := range fix.Edits {
+			fixRec.Edits = append(fixRec.Edits, editRecord{Loc: encodeLoc(edit.Loc), Text: edit.Text})
+		}
+		rec.Fixes = append(rec.Fixes, fixRec)
+	}
+
+	return rec, nil
+}
+
+// encodeValue encodes the semantic value of a token, returning the
+// value type and the encoded value.
+func encodeValue(val interface{}) (string, interface{}, error) {
+	switch v := val.(type) {
+	case nil:
+		return "", nil, nil
+
+	case string:
+		return valString, v, nil
+
+	case []byte:
+		return valBytes, v, nil
+
+	case *big.Int:
+		return valInt, v.String(), nil
+
+	case *big.Float:
+		// JSON has no representation for infinities
+		if v.IsInf() {
+			return "", nil, ErrBadTokenValue
+		}
+		return valFloat, json.Number(v.Text('g', -1)), nil
+
+	case *Diagnostic:
+		rec, err := encodeDiagnostic(v)
+		if err != nil {
+			return "", nil, err
+		}
+		return valError, rec, nil
+	}
+
+	return "", nil, ErrBadTokenValue
+}
+
+// decodeValue decodes the semantic value of a token, given the value
+// type and the encoded value.  The location is used for diagnostic
+// values, and the profile to resolve the symbols they refer to.
+func decodeValue(typ string, data json.RawMessage, loc Location, prof *Profile) (interface{}, error) {
+	switch typ {
+	case "":
+		return nil, nil
+
+	case valString:
+		var v string
+		err := json.Unmarshal(data, &v)
+		return v, err
+
+	case valBytes:
+		var v []byte
+		err := json.Unmarshal(data, &v)
+		return v, err
+
+	case valInt:
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return nil, err
+		}
+		v, ok := (&big.Int{}).SetString(text, 10)
+		if !ok {
+			return nil, ErrBadTokenValue
+		}
+		return v, nil
+
+	case valFloat:
+		var num json.Number
+		if err := json.Unmarshal(data, &num); err != nil {
+			return nil, err
+		}
+		v, ok := (&big.Float{}).SetString(num.String())
+		if !ok {
+			return nil, ErrBadTokenValue
+		}
+		return v, nil
+
+	case valError:
+		var rec errorRecord
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return nil, err
+		}
+		return decodeDiagnostic(rec, loc, prof)
+	}
+
+	return nil, ErrBadTokenValue
+}
+
+// decodeDiagnostic reconstructs a diagnostic from its serialized
+// form.  The underlying error is the sentinel error for the
+// diagnostic code, if there is one.  Symbols in the arguments and
+// fields are resolved using the profile.
+func decodeDiagnostic(rec errorRecord, loc Location, prof *Profile) (*Diagnostic, error) {
+	diag := &Diagnostic{
+		Code: rec.Code,
+		Msg:  rec.Msg,
+		Loc:  loc,
+	}
+
+	// Look up the severity
+	found := false
+	for sev, name := range severityNames {
+		if name == rec.Severity {
+			diag.Severity = sev
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, ErrBadTokenValue
+	}
+
+	// Look up the underlying error
+	for _, entry := range codes {
+		if entry.code == rec.Code {
+			diag.Err = entry.err
+			break
+		}
+	}
+
+	// Decode the arguments and fields
+	for _, argRec := range rec.Args {
+		arg, err := decodeArg(argRec, prof)
+		if err != nil {
+			return nil, err
+		}
+		diag.Args = append(diag.Args, arg)
+	}
+	for name, argRec := range rec.Fields {
+		field, err := decodeArg(argRec, prof)
+		if err != nil {
+			return nil, err
+		}
+		if diag.Fields == nil {
+			diag.Fields = map[string]interface{}{}
+		}
+		diag.Fields[name] = field
+	}
+
+	// Decode the labels and fixes
+	for _, labelRec := range rec.Labels {
+		diag.Labels = append(diag.Labels, Label{Loc: decodeLoc(labelRec.Loc), Msg: labelRec.Msg})
+	}
+	for _, fixRec := range rec.Fixes {
+		fix := Fix{Msg: fixRec.Msg}
+		for _, editRec := range fixRec.Edits {
+			fix.Edits = append(fix.Edits, Edit{Loc: decodeLoc(editRec.Loc), Text: editRec.Text})
+		}
+		diag.Fixes = append(diag.Fixes, fix)
+	}
+
+	return diag, nil
+}
+
+// marshal encodes a value as JSON.  Unlike json.Marshal, characters
+// special to HTML are not escaped, since symbol names and string
+// values often contain them.
+func marshal(val interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(val); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// lookupSymbol looks up a symbol by name.  If the profile has been
+// built, the name is looked up in its symbol table.  Otherwise, the
+// standard symbols are checked first, followed by the keywords,
+// operators, and recognizer symbols of the profile.
+func lookupSymbol(name string, prof *Profile) *Symbol {
+	if prof != nil && prof.Symbols != nil {
+		return prof.Symbols.Lookup(name)
+	}
+
+	for _, sym := range StdSymbols {
+		if sym.Name == name {
+			return sym
+		}
+	}
+
+	if prof == nil {
+		return nil
+	}
+
+	// Check the keywords
+	if sym, ok := prof.Keywords[name]; ok {
+		return sym
+	}
+
+	// Walk the operator tree
+	node := prof.Operators
+	for _, r := range name {
+		if node == nil {
+			break
+		}
+		node = node.Next(r)
+	}
+	if node != nil && node.Sym != nil {
+		return node.Sym
+	}
+
+	// Check the symbols produced by recognizers
+	if prof.Recognizers != nil {
+		for _, entry := range prof.Recognizers.All() {
+			for _, sym := range entry.Symbols {
+				if sym.Name == name {
+					return sym
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// encodeToken encodes a token.
+func encodeToken(tok *Token) (tokenRecord, error) {
+	loc := encodeLoc(tok.Loc)
+	rec := tokenRecord{
+		Sym:     tok.Sym.Name,
+		File:    loc.File,
+		Line:    loc.Line,
+		Col:     loc.Col,
+		EndLine: loc.EndLine,
+		EndCol:  loc.EndCol,
+		Text:    tok.Text,
+	}
+
+	// Encode the value
+	typ, val, err := encodeValue(tok.Val)
+	if err != nil {
+		return tokenRecord{}, err
+	}
+	if typ != "" {
+		rec.Type = typ
+		if rec.Val, err = marshal(val); err != nil {
+			return tokenRecord{}, err
+		}
+	}
+
+	return rec, nil
+}
+
+// decodeToken decodes a token, resolving its symbol using the
+// profile.
+func decodeToken(rec tokenRecord, prof *Profile) (*Token, error) {
+	// Resolve the symbol
+	sym := lookupSymbol(rec.Sym, prof)
+	if sym == nil {
+		return nil, fmt.Errorf("%w %q", ErrUnknownSymbol, rec.Sym)
+	}
+
+	// Construct the token
+	tok := &Token{
+		Sym: sym,
+		Loc: decodeLoc(locRecord{
+			File:    rec.File,
+			Line:    rec.Line,
+			Col:     rec.Col,
+			EndLine: rec.EndLine,
+			EndCol:  rec.EndCol,
+		}),
+		Text: rec.Text,
+	}
+	val, err := decodeValue(rec.Type, rec.Val, tok.Loc, prof)
+	if err != nil {
+		return nil, err
+	}
+	tok.Val = val
+
+	return tok, nil
+}
+
+// WriteTokens writes a list of tokens to a writer in JSON Lines
+// format: each token is written as a JSON object on a line by itself.
+// The object contains the symbol name, the location, the source text,
+// and the semantic value of the token, along with the type of the
+// value: "int", encoded as a decimal string; "float", encoded as a
+// number; "string"; "bytes", encoded in base64; or "error", a
+// diagnostic encoded as an object containing the code, severity,
+// message, arguments, fields, labels, and suggested fixes.  Byte
+// offsets are not yet included; use Source.Offset to compute them
+// from the line and column.  Returns ErrBadTokenValue if a token's
+// semantic value can't be encoded, including infinite floats.
+func WriteTokens(w io.Writer, toks []*Token) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for i, tok := range toks {
+		rec, err := encodeToken(tok)
+		if err != nil {
+			return fmt.Errorf("token %d: %w", i+1, err)
+		}
+
+		if err := enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReadTokens reads a list of tokens written by WriteTokens.  Symbols
+// are resolved by name using the symbol table of the specified
+// profile, if it has been built; otherwise, they are resolved against
+// the standard token symbols, then the keywords, operators, and
+// recognizer symbols of the profile.  Returns ErrUnknownSymbol if a
+// symbol can't be resolved, or ErrBadTokenValue if a value can't be
+// decoded.
+func ReadTokens(r io.Reader, prof *Profile) ([]*Token, error) {
+	dec := json.NewDecoder(r)
+
+	var toks []*Token
+	for i := 1; ; i++ {
+		// Read a record
+		var rec tokenRecord
+		if err := dec.Decode(&rec); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("token %d: %w", i, err)
+		}
+
+		// Decode the token
+		tok, err := decodeToken(rec, prof)
+		if err != nil {
+			return nil, fmt.Errorf("token %d: %w", i, err)
+		}
+
+		toks = append(toks, tok)
+	}
+
+	return toks, nil
+}
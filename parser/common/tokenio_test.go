@@ -0,0 +1,542 @@
+// Copyright (c) 2019 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package common
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/hydralang/hydra/testutils"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testKwIf    = &Symbol{Name: "if"}
+	testOpAdd   = &Symbol{Name: "+"}
+	testOpAug   = &Symbol{Name: "+="}
+	testOpOpen  = &Symbol{Name: "(", Close: ")"}
+	testOpClose = &Symbol{Name: ")"}
+	testOpBrack = &Symbol{Name: "]"}
+	testSigil   = &Symbol{Name: "<Sigil>"}
+)
+
+func testTokenProfile() *Profile {
+	return &Profile{
+		Keywords:  Keywords{"if": testKwIf},
+		Operators: NewOperators(testOpAdd, testOpAug, testOpOpen, testOpClose, testOpBrack),
+		Recognizers: NewRecognizers(&RecogEntry{
+			Name:    "sigil",
+			Class:   CharQuote,
+			Init:    testRecogInit,
+			Symbols: []*Symbol{testSigil},
+		}),
+	}
+}
+
+func testTokenLoc(l, b, e int) Location {
+	return Location{
+		File: "file",
+		B:    FilePos{L: l, C: b},
+		E:    FilePos{L: l, C: e},
+	}
+}
+
+func TestEncodeValueNil(t *testing.T) {
+	a := assert.New(t)
+
+	typ, val, err := encodeValue(nil)
+
+	a.NoError(err)
+	a.Equal("", typ)
+	a.Nil(val)
+}
+
+func TestEncodeValueString(t *testing.T) {
+	a := assert.New(t)
+
+	typ, val, err := encodeValue("spam")
+
+	a.NoError(err)
+	a.Equal(valString, typ)
+	a.Equal("spam", val)
+}
+
+func TestEncodeValueBytes(t *testing.T) {
+	a := assert.New(t)
+
+	typ, val, err := encodeValue([]byte("spam"))
+
+	a.NoError(err)
+	a.Equal(valBytes, typ)
+	a.Equal([]byte("spam"), val)
+}
+
+func TestEncodeValueInt(t *testing.T) {
+	a := assert.New(t)
+
+	typ, val, err := encodeValue(big.NewInt(255))
+
+	a.NoError(err)
+	a.Equal(valInt, typ)
+	a.Equal("255", val)
+}
+
+func TestEncodeValueFloat(t *testing.T) {
+	a := assert.New(t)
+
+	typ, val, err := encodeValue(big.NewFloat(1.5))
+
+	a.NoError(err)
+	a.Equal(valFloat, typ)
+	a.EqualValues("1.5", val)
+}
+
+func TestEncodeValueFloatInf(t *testing.T) {
+	a := assert.New(t)
+
+	typ, val, err := encodeValue(new(big.Float).SetInf(true))
+
+	a.Equal(ErrBadTokenValue, err)
+	a.Equal("", typ)
+	a.Nil(val)
+}
+
+func TestEncodeValueDiagnostic(t *testing.T) {
+	a := assert.New(t)
+	diag := Diagnose(ErrBadOp, Location{})
+
+	typ, val, err := encodeValue(diag)
+
+	a.NoError(err)
+	a.Equal(valError, typ)
+	a.Equal(errorRecord{
+		Code:     CodeBadOp,
+		Severity: "error",
+		Msg:      "bad operator character",
+	}, val)
+}
+
+func TestEncodeValueDiagnosticFull(t *testing.T) {
+	a := assert.New(t)
+	diag := Diagnose(ErrBadIndent, Location{})
+	diag.Args = []interface{}{"1 or 5", 3}
+	diag.Fields = map[string]interface{}{
+		"expected": []int{1, 5},
+		"column":   3,
+	}
+	diag.Labels = []Label{{Loc: testTokenLoc(1, 1, 2), Msg: "label"}}
+	diag.Fixes = []Fix{
+		{
+			Msg:   "fix",
+			Edits: []Edit{{Loc: testTokenLoc(2, 1, 3), Text: "  "}},
+		},
+	}
+
+	typ, val, err := encodeValue(diag)
+
+	a.NoError(err)
+	a.Equal(valError, typ)
+	a.Equal(errorRecord{
+		Code:     CodeBadIndent,
+		Severity: "error",
+		Msg:      "inconsistent indentation",
+		Args: []argRecord{
+			{Type: argString, Val: []byte(`"1 or 5"`)},
+			{Type: argInt, Val: []byte(`3`)},
+		},
+		Fields: map[string]argRecord{
+			"expected": {Type: argInts, Val: []byte(`[1,5]`)},
+			"column":   {Type: argInt, Val: []byte(`3`)},
+		},
+		Labels: []labelRecord{
+			{
+				Loc: locRecord{File: "file", Line: 1, Col: 1, EndLine: 1, EndCol: 2},
+				Msg: "label",
+			},
+		},
+		Fixes: []fixRecord{
+			{
+				Msg: "fix",
+				Edits: []editRecord{
+					{
+						Loc:  locRecord{File: "file", Line: 2, Col: 1, EndLine: 2, EndCol: 3},
+						Text: "  ",
+					},
+				},
+			},
+		},
+	}, val)
+}
+
+func TestEncodeValueDiagnosticBadArg(t *testing.T) {
+	a := assert.New(t)
+	diag := Diagnose(ErrBadOp, Location{})
+	diag.Args = []interface{}{3.5}
+
+	_, _, err := encodeValue(diag)
+
+	a.Equal(ErrBadTokenValue, err)
+}
+
+func TestEncodeValueDiagnosticBadField(t *testing.T) {
+	a := assert.New(t)
+	diag := Diagnose(ErrBadOp, Location{})
+	diag.Fields = map[string]interface{}{"spam": 3.5}
+
+	_, _, err := encodeValue(diag)
+
+	a.Equal(ErrBadTokenValue, err)
+}
+
+func TestEncodeValueUnknown(t *testing.T) {
+	a := assert.New(t)
+
+	_, _, err := encodeValue(3.5)
+
+	a.Equal(ErrBadTokenValue, err)
+}
+
+func TestDecodeValueNil(t *testing.T) {
+	a := assert.New(t)
+
+	result, err := decodeValue("", nil, Location{}, nil)
+
+	a.NoError(err)
+	a.Nil(result)
+}
+
+func TestDecodeValueString(t *testing.T) {
+	a := assert.New(t)
+
+	result, err := decodeValue(valString, []byte(`"spam"`), Location{}, nil)
+
+	a.NoError(err)
+	a.Equal("spam", result)
+}
+
+func TestDecodeValueBytes(t *testing.T) {
+	a := assert.New(t)
+
+	result, err := decodeValue(valBytes, []byte(`"c3BhbQ=="`), Location{}, nil)
+
+	a.NoError(err)
+	a.Equal([]byte("spam"), result)
+}
+
+func TestDecodeValueInt(t *testing.T) {
+	a := assert.New(t)
+
+	result, err := decodeValue(valInt, []byte(`"255"`), Location{}, nil)
+
+	a.NoError(err)
+	a.Equal(0, big.NewInt(255).Cmp(result.(*big.Int)))
+}
+
+func TestDecodeValueIntBad(t *testing.T) {
+	a := assert.New(t)
+
+	_, err := decodeValue(valInt, []byte(`"spam"`), Location{}, nil)
+
+	a.Equal(ErrBadTokenValue, err)
+}
+
+func TestDecodeValueFloat(t *testing.T) {
+	a := assert.New(t)
+
+	result, err := decodeValue(valFloat, []byte(`1.5`), Location{}, nil)
+
+	a.NoError(err)
+	a.Equal(0, big.NewFloat(1.5).Cmp(result.(*big.Float)))
+}
+
+func TestDecodeValueFloatBad(t *testing.T) {
+	a := assert.New(t)
+
+	_, err := decodeValue(valFloat, []byte(`"spam"`), Location{}, nil)
+
+	a.Error(err)
+}
+
+func TestDecodeValueError(t *testing.T) {
+	a := assert.New(t)
+	loc := testTokenLoc(1, 2, 3)
+
+	result, err := decodeValue(valError, []byte(`{"code":"H0103","severity":"error","msg":"bad operator character"}`), loc, nil)
+
+	a.NoError(err)
+	a.Equal(&Diagnostic{
+		Code:     CodeBadOp,
+		Severity: SevError,
+		Msg:      "bad operator character",
+		Loc:      loc,
+		Err:      ErrBadOp,
+	}, result)
+}
+
+func TestDecodeValueUnknown(t *testing.T) {
+	a := assert.New(t)
+
+	_, err := decodeValue("spam", []byte(`"spam"`), Location{}, nil)
+
+	a.Equal(ErrBadTokenValue, err)
+}
+
+func TestDecodeDiagnosticUnknownCode(t *testing.T) {
+	a := assert.New(t)
+
+	result, err := decodeDiagnostic(errorRecord{Code: "X0001", Severity: "warning", Msg: "spam"}, Location{}, nil)
+
+	a.NoError(err)
+	a.Equal(&Diagnostic{
+		Code:     "X0001",
+		Severity: SevWarning,
+		Msg:      "spam",
+	}, result)
+}
+
+func TestDecodeDiagnosticUnknownSymbol(t *testing.T) {
+	a := assert.New(t)
+	rec := errorRecord{
+		Code:     CodeMismatchedOp,
+		Severity: "error",
+		Fields: map[string]argRecord{
+			FieldClose: {Type: argSymbol, Val: []byte(`"spam"`)},
+		},
+	}
+
+	_, err := decodeDiagnostic(rec, Location{}, testTokenProfile())
+
+	a.True(errors.Is(err, ErrUnknownSymbol))
+}
+
+func TestDecodeDiagnosticBadArg(t *testing.T) {
+	a := assert.New(t)
+	rec := errorRecord{
+		Code:     CodeBadOp,
+		Severity: "error",
+		Args:     []argRecord{{Type: "spam", Val: []byte(`"spam"`)}},
+	}
+
+	_, err := decodeDiagnostic(rec, Location{}, nil)
+
+	a.Equal(ErrBadTokenValue, err)
+}
+
+func TestDecodeDiagnosticBadSeverity(t *testing.T) {
+	a := assert.New(t)
+
+	_, err := decodeDiagnostic(errorRecord{Code: CodeBadOp, Severity: "spam"}, Location{}, nil)
+
+	a.Equal(ErrBadTokenValue, err)
+}
+
+func TestMarshal(t *testing.T) {
+	a := assert.New(t)
+
+	result, err := marshal("<<=")
+
+	a.NoError(err)
+	a.Equal([]byte(`"<<="`), result)
+}
+
+func TestLookupSymbolStandard(t *testing.T) {
+	a := assert.New(t)
+
+	result := lookupSymbol("<Int>", nil)
+
+	a.Equal(TokInt, result)
+}
+
+func TestLookupSymbolKeyword(t *testing.T) {
+	a := assert.New(t)
+
+	result := lookupSymbol("if", testTokenProfile())
+
+	a.Equal(testKwIf, result)
+}
+
+func TestLookupSymbolOperator(t *testing.T) {
+	a := assert.New(t)
+
+	result := lookupSymbol("+=", testTokenProfile())
+
+	a.Equal(testOpAug, result)
+}
+
+func TestLookupSymbolRecognizer(t *testing.T) {
+	a := assert.New(t)
+
+	result := lookupSymbol("<Sigil>", testTokenProfile())
+
+	a.Equal(testSigil, result)
+}
+
+func TestLookupSymbolSymTab(t *testing.T) {
+	a := assert.New(t)
+	prof := testTokenProfile()
+	prof.Symbols = newSymTab()
+	prof.Symbols.add(testSigil)
+
+	result := lookupSymbol("<Sigil>", prof)
+
+	a.Equal(testSigil, result)
+	a.Nil(lookupSymbol("if", prof))
+}
+
+func TestLookupSymbolUnknown(t *testing.T) {
+	a := assert.New(t)
+
+	result := lookupSymbol("+-", testTokenProfile())
+
+	a.Nil(result)
+}
+
+func TestLookupSymbolNoProfile(t *testing.T) {
+	a := assert.New(t)
+
+	result := lookupSymbol("if", nil)
+
+	a.Nil(result)
+}
+
+func TestWriteTokens(t *testing.T) {
+	a := assert.New(t)
+	buf := &bytes.Buffer{}
+	toks := []*Token{
+		{Sym: testKwIf, Loc: testTokenLoc(1, 1, 3), Val: "if", Text: "if"},
+		{Sym: TokInt, Loc: testTokenLoc(1, 4, 9), Val: big.NewInt(255), Text: "0x_FF"},
+		{Sym: TokBytes, Loc: testTokenLoc(1, 10, 17), Val: []byte("spam"), Text: "b'spam'"},
+		{Sym: TokEOF, Loc: testTokenLoc(2, 1, 1)},
+	}
+
+	err := WriteTokens(buf, toks)
+
+	a.NoError(err)
+	a.Equal(`{"sym":"if","file":"file","line":1,"col":1,"endLine":1,"endCol":3,"text":"if","type":"string","val":"if"}
+{"sym":"<Int>","file":"file","line":1,"col":4,"endLine":1,"endCol":9,"text":"0x_FF","type":"int","val":"255"}
+{"sym":"<Bytes>","file":"file","line":1,"col":10,"endLine":1,"endCol":17,"text":"b'spam'","type":"bytes","val":"c3BhbQ=="}
+{"sym":"<EOF>","file":"file","line":2,"col":1,"endLine":2,"endCol":1}
+`, buf.String())
+}
+
+func TestWriteTokensBadValue(t *testing.T) {
+	a := assert.New(t)
+	buf := &bytes.Buffer{}
+	toks := []*Token{
+		{Sym: TokFloat, Loc: testTokenLoc(1, 1, 4), Val: 3.5},
+	}
+
+	err := WriteTokens(buf, toks)
+
+	a.True(errors.Is(err, ErrBadTokenValue))
+	a.EqualError(err, "token 1: bad token value")
+}
+
+func TestWriteTokensInfiniteFloat(t *testing.T) {
+	a := assert.New(t)
+	buf := &bytes.Buffer{}
+	toks := []*Token{
+		{Sym: TokFloat, Loc: testTokenLoc(1, 1, 4), Val: new(big.Float).SetInf(false)},
+	}
+
+	err := WriteTokens(buf, toks)
+
+	a.True(errors.Is(err, ErrBadTokenValue))
+	a.Equal("", buf.String())
+}
+
+func TestReadTokens(t *testing.T) {
+	a := assert.New(t)
+	toks := []*Token{
+		{Sym: testKwIf, Loc: testTokenLoc(1, 1, 3), Val: "if", Text: "if"},
+		{Sym: testOpAdd, Loc: testTokenLoc(1, 4, 5), Val: "+", Text: "+"},
+		{Sym: TokString, Loc: testTokenLoc(1, 6, 12), Val: "s\tp", Text: "'s\\tp'"},
+		{Sym: TokError, Loc: testTokenLoc(1, 13, 14), Val: Diagnose(ErrBadOp, testTokenLoc(1, 13, 14))},
+		{Sym: TokEOF, Loc: testTokenLoc(2, 1, 1)},
+	}
+	buf := &bytes.Buffer{}
+	WriteTokens(buf, toks)
+
+	result, err := ReadTokens(buf, testTokenProfile())
+
+	a.NoError(err)
+	a.Equal(toks, result)
+}
+
+func TestReadTokensRoundTrip(t *testing.T) {
+	a := assert.New(t)
+	prof := testTokenProfile()
+	prof.Build()
+	openTok := &Token{Sym: testOpOpen, Loc: testTokenLoc(1, 1, 2), Val: "(", Text: "("}
+	diag := ErrOpMismatch(openTok, testOpBrack)
+	diag.Loc = testTokenLoc(1, 9, 10)
+	diag.Fixes = []Fix{
+		{
+			Msg:   "replace with the matching close operator",
+			Edits: []Edit{{Loc: diag.Loc, Text: ")"}},
+		},
+	}
+	toks := []*Token{
+		openTok,
+		{Sym: testSigil, Loc: testTokenLoc(1, 2, 8), Val: "sigil", Text: "$sigil"},
+		{Sym: TokError, Loc: diag.Loc, Val: diag, Text: "]"},
+		{Sym: TokEOF, Loc: testTokenLoc(2, 1, 1)},
+	}
+	buf := &bytes.Buffer{}
+	WriteTokens(buf, toks)
+
+	result, err := ReadTokens(buf, prof)
+
+	a.NoError(err)
+	a.Equal(toks, result)
+	resultDiag := result[2].Val.(*Diagnostic)
+	testutils.AssertPtrEqual(a, testOpBrack, resultDiag.Fields[FieldClose])
+	testutils.AssertPtrEqual(a, testSigil, result[1].Sym)
+}
+
+func TestReadTokensUnknownSymbol(t *testing.T) {
+	a := assert.New(t)
+	src := `{"sym":"<EOF>","file":"file","line":1,"col":1,"endLine":1,"endCol":1}
+{"sym":"spam","file":"file","line":1,"col":1,"endLine":1,"endCol":1}
+`
+
+	result, err := ReadTokens(strings.NewReader(src), testTokenProfile())
+
+	a.Nil(result)
+	a.True(errors.Is(err, ErrUnknownSymbol))
+	a.EqualError(err, "token 2: unknown token symbol \"spam\"")
+}
+
+func TestReadTokensBadValue(t *testing.T) {
+	a := assert.New(t)
+	src := `{"sym":"<Int>","file":"file","line":1,"col":1,"endLine":1,"endCol":1,"type":"int","val":"spam"}`
+
+	result, err := ReadTokens(strings.NewReader(src), testTokenProfile())
+
+	a.Nil(result)
+	a.True(errors.Is(err, ErrBadTokenValue))
+}
+
+func TestReadTokensBadJSON(t *testing.T) {
+	a := assert.New(t)
+
+	result, err := ReadTokens(strings.NewReader("{spam"), testTokenProfile())
+
+	a.Nil(result)
+	a.Error(err)
+}
@@ -21,13 +21,50 @@ import (
 	"github.com/hydralang/hydra/utils"
 )
 
+// SymKind describes the kind of a symbol.
+type SymKind uint8
+
+// Defined symbol kinds.
+const (
+	KindUnknown    SymKind = iota // Kind not yet known
+	KindKeyword                   // A keyword
+	KindOperator                  // An operator
+	KindIdent                     // An identifier
+	KindLiteral                   // A literal, such as a number
+	KindStructural                // Structure, such as an indent
+	KindError                     // An error
+)
+
+// kindNames is a mapping of symbol kinds to names.
+var kindNames = map[SymKind]string{
+	KindUnknown:    "unknown",
+	KindKeyword:    "keyword",
+	KindOperator:   "operator",
+	KindIdent:      "identifier",
+	KindLiteral:    "literal",
+	KindStructural: "structural",
+	KindError:      "error",
+}
+
+// String constructs a string representation of a symbol kind.
+func (k SymKind) String() string {
+	if name, ok := kindNames[k]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("kind %d", uint8(k))
+}
+
 // Symbol represents a defined symbol, or token type.  This could
 // indicate something with a fixed value, like an operator, or
 // something that has semantic value, such as a number literal.
 type Symbol struct {
-	Name  string // The name of the symbol, for display purposes
-	Open  string // Paired operator that opens
-	Close string // Paired operator that closes
+	Name  string  // The name of the symbol, for display purposes
+	Open  string  // Paired operator that opens
+	Close string  // Paired operator that closes
+	Kind  SymKind // The kind of symbol
+	Desc  string  // Optional description of the symbol
+	Since string  // Optional version that introduced the symbol
 }
 
 // String constructs a string representation of a symbol--e.g., the
@@ -38,9 +75,12 @@ func (s Symbol) String() string {
 
 // Token represents a single token emitted by the lexer.
 type Token struct {
-	Sym *Symbol     // The token type
-	Loc Location    // The location range of the token
-	Val interface{} // The semantic value of the token
+	Sym   *Symbol     // The token type
+	Loc   Location    // The location range of the token
+	Val   interface{} // The semantic value of the token
+	Text  string      // The source text of the token
+	Lead  []Trivia    // Trivia preceding the token
+	Trail []Trivia    // Trivia following the token on its line
 }
 
 // String constructs a string representation of a token.
@@ -64,17 +104,94 @@ func (t *Token) Children() []utils.Visitable {
 	return []utils.Visitable{}
 }
 
+// Location implements the Located interface, allowing a Token to be
+// added to a LocIndex.
+func (t *Token) Location() Location {
+	return t.Loc
+}
+
 // Standard token symbols
 var (
-	TokError      = &Symbol{Name: "<Error>"}
-	TokEOF        = &Symbol{Name: "<EOF>"}
-	TokNewline    = &Symbol{Name: "<Newline>"}
-	TokIndent     = &Symbol{Name: "<Indent>"}
-	TokDedent     = &Symbol{Name: "<Dedent>"}
-	TokIdent      = &Symbol{Name: "<Ident>"}
-	TokInt        = &Symbol{Name: "<Int>"}
-	TokFloat      = &Symbol{Name: "<Float>"}
-	TokString     = &Symbol{Name: "<String>"}
-	TokBytes      = &Symbol{Name: "<Bytes>"}
-	TokDocComment = &Symbol{Name: "<DocComment>"}
+	TokError      = &Symbol{Name: "<Error>", Kind: KindError}
+	TokEOF        = &Symbol{Name: "<EOF>", Kind: KindStructural}
+	TokNewline    = &Symbol{Name: "<Newline>", Kind: KindStructural}
+	TokIndent     = &Symbol{Name: "<Indent>", Kind: KindStructural}
+	TokDedent     = &Symbol{Name: "<Dedent>", Kind: KindStructural}
+	TokIdent      = &Symbol{Name: "<Ident>", Kind: KindIdent}
+	TokInt        = &Symbol{Name: "<Int>", Kind: KindLiteral}
+	TokFloat      = &Symbol{Name: "<Float>", Kind: KindLiteral}
+	TokString     = &Symbol{Name: "<String>", Kind: KindLiteral}
+	TokBytes      = &Symbol{Name: "<Bytes>", Kind: KindLiteral}
+	TokDocComment = &Symbol{Name: "<DocComment>", Kind: KindStructural}
 )
+
+// Token symbols for formatted strings, or f-strings.  An f-string is
+// lexed into a TokFStringStart token, followed by the literal parts
+// of the string and its replacement fields, and ending with a
+// TokFStringEnd token.  A replacement field begins with a
+// TokFExprStart token and ends with a TokFExprEnd token; between
+// them are the tokens of the expression, optionally followed by a
+// TokFStringConv token for the conversion, such as "!r", and by the
+// format specification, which begins with a TokFStringSpec token and
+// may itself contain replacement fields.
+var (
+	TokFStringStart = &Symbol{Name: "<FStringStart>", Kind: KindStructural}
+	TokFStringPart  = &Symbol{Name: "<FStringPart>", Kind: KindLiteral}
+	TokFStringConv  = &Symbol{Name: "<FStringConv>", Kind: KindLiteral}
+	TokFStringSpec  = &Symbol{Name: "<FStringSpec>", Kind: KindLiteral}
+	TokFStringEnd   = &Symbol{Name: "<FStringEnd>", Kind: KindStructural}
+	TokFExprStart   = &Symbol{Name: "<FExprStart>", Close: "}", Kind: KindStructural}
+	TokFExprEnd     = &Symbol{Name: "<FExprEnd>", Open: "{", Kind: KindStructural}
+)
+
+// Standard symbol IDs.  The standard token symbols always have these
+// IDs; symbols defined by a profile are assigned IDs beginning with
+// IDFirstProfile.  The IDs between the last standard symbol and
+// IDFirstProfile are reserved for standard symbols added later, so
+// new standard symbols do not change the IDs of profile symbols.
+const (
+	IDError = iota
+	IDEOF
+	IDNewline
+	IDIndent
+	IDDedent
+	IDIdent
+	IDInt
+	IDFloat
+	IDString
+	IDBytes
+	IDDocComment
+	IDFStringStart
+	IDFStringPart
+	IDFStringConv
+	IDFStringSpec
+	IDFStringEnd
+	IDFExprStart
+	IDFExprEnd
+)
+
+// IDFirstProfile is the first ID assigned to a symbol defined by a
+// profile.
+const IDFirstProfile = 32
+
+// StdSymbols is a list of the standard token symbols, indexed by ID.
+var StdSymbols = []*Symbol{
+	IDError:        TokError,
+	IDEOF:          TokEOF,
+	IDNewline:      TokNewline,
+	IDIndent:       TokIndent,
+	IDDedent:       TokDedent,
+	IDIdent:        TokIdent,
+	IDInt:          TokInt,
+	IDFloat:        TokFloat,
+	IDString:       TokString,
+	IDBytes:        TokBytes,
+	IDDocComment:   TokDocComment,
+	IDFStringStart: TokFStringStart,
+	IDFStringPart:  TokFStringPart,
+	IDFStringConv:  TokFStringConv,
+	IDFStringSpec:  TokFStringSpec,
+	IDFStringEnd:   TokFStringEnd,
+	IDFExprStart:   TokFExprStart,
+	IDFExprEnd:     TokFExprEnd,
+}
@@ -22,6 +22,36 @@ import (
 	"github.com/hydralang/hydra/utils"
 )
 
+func TestSymKindString(t *testing.T) {
+	a := assert.New(t)
+
+	result := KindOperator.String()
+
+	a.Equal("operator", result)
+}
+
+func TestSymKindStringUnknown(t *testing.T) {
+	a := assert.New(t)
+
+	result := SymKind(42).String()
+
+	a.Equal("kind 42", result)
+}
+
+func TestStdSymbols(t *testing.T) {
+	a := assert.New(t)
+
+	a.Len(StdSymbols, IDFExprEnd+1)
+	a.True(len(StdSymbols) <= IDFirstProfile)
+	a.Equal(TokEOF, StdSymbols[IDEOF])
+	a.Equal(TokDocComment, StdSymbols[IDDocComment])
+	a.Equal(TokFStringStart, StdSymbols[IDFStringStart])
+	a.Equal(TokFExprEnd, StdSymbols[IDFExprEnd])
+	for _, sym := range StdSymbols {
+		a.NotEqual(KindUnknown, sym.Kind, "%s", sym)
+	}
+}
+
 func TestSymbolString(t *testing.T) {
 	a := assert.New(t)
 	sym := Symbol{Name: "sym"}
@@ -67,3 +97,18 @@ func TestTokenChildren(t *testing.T) {
 
 	a.Equal([]utils.Visitable{}, result)
 }
+
+func TestTokenImplementsLocated(t *testing.T) {
+	assert.Implements(t, (*Located)(nil), &Token{})
+}
+
+func TestTokenLocation(t *testing.T) {
+	a := assert.New(t)
+	sym := &Symbol{Name: "sym"}
+	loc := Location{File: "file", B: FilePos{3, 2}, E: FilePos{3, 3}}
+	tok := &Token{Sym: sym, Loc: loc, Val: "value"}
+
+	result := tok.Location()
+
+	a.Equal(loc, result)
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2019 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package common
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Trivia kinds.  Trivia is source text that does not contribute to
+// any token, but which must be retained to reconstruct the source.
+const (
+	TriviaSpace        uint8 = iota // A run of whitespace
+	TriviaNewline                   // A non-logical newline
+	TriviaComment                   // A comment, excluding the newline
+	TriviaContinuation              // A backslash line continuation
+	TriviaSkipped                   // Text skipped by error recovery
+)
+
+// TriviaNames is a mapping of trivia kinds to names.
+var TriviaNames = map[uint8]string{
+	TriviaSpace:        "space",
+	TriviaNewline:      "newline",
+	TriviaComment:      "comment",
+	TriviaContinuation: "continuation",
+	TriviaSkipped:      "skipped",
+}
+
+// Trivia describes a single piece of trivia attached to a token.
+type Trivia struct {
+	Kind uint8    // The kind of trivia
+	Text string   // The source text of the trivia
+	Loc  Location // The location of the trivia
+}
+
+// String constructs a string representation of a piece of trivia.
+func (t Trivia) String() string {
+	return fmt.Sprintf("%s: %s trivia %q", t.Loc, TriviaNames[t.Kind], t.Text)
+}
+
+// Location implements the Located interface, allowing Trivia to be
+// added to a LocIndex.
+func (t Trivia) Location() Location {
+	return t.Loc
+}
+
+// FullText returns the source text of the token, including its
+// leading and trailing trivia.  When trivia is preserved, the
+// concatenation of the full text of every token produced by the lexer
+// reproduces the source.
+func (t *Token) FullText() string {
+	text := strings.Builder{}
+
+	for _, triv := range t.Lead {
+		text.WriteString(triv.Text)
+	}
+	text.WriteString(t.Text)
+	for _, triv := range t.Trail {
+		text.WriteString(triv.Text)
+	}
+
+	return text.String()
+}
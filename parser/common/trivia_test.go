@@ -0,0 +1,81 @@
+// Copyright (c) 2019 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTriviaString(t *testing.T) {
+	a := assert.New(t)
+	triv := Trivia{
+		Kind: TriviaComment,
+		Text: "# comment",
+		Loc: Location{
+			File: "file",
+			B:    FilePos{L: 3, C: 2},
+			E:    FilePos{L: 3, C: 11},
+		},
+	}
+
+	result := triv.String()
+
+	a.Equal("file:3:2-11: comment trivia \"# comment\"", result)
+}
+
+func TestTriviaLocation(t *testing.T) {
+	a := assert.New(t)
+	triv := Trivia{
+		Loc: Location{
+			File: "file",
+			B:    FilePos{L: 3, C: 2},
+			E:    FilePos{L: 3, C: 11},
+		},
+	}
+
+	result := triv.Location()
+
+	a.Equal(triv.Loc, result)
+}
+
+func TestTokenFullText(t *testing.T) {
+	a := assert.New(t)
+	tok := &Token{
+		Text: "spam",
+		Lead: []Trivia{
+			{Kind: TriviaNewline, Text: "\r\n"},
+			{Kind: TriviaSpace, Text: "  "},
+		},
+		Trail: []Trivia{
+			{Kind: TriviaSpace, Text: " "},
+			{Kind: TriviaComment, Text: "# comment"},
+		},
+	}
+
+	result := tok.FullText()
+
+	a.Equal("\r\n  spam # comment", result)
+}
+
+func TestTokenFullTextNoTrivia(t *testing.T) {
+	a := assert.New(t)
+	tok := &Token{Text: "spam"}
+
+	result := tok.FullText()
+
+	a.Equal("spam", result)
+}
@@ -29,9 +29,9 @@ type recognizeComment struct {
 }
 
 // recogComment constructs a recognizer for comments.
-func recogComment(l *lexer) Recognizer {
+func recogComment(lc common.LexContext) common.Recognizer {
 	return &recognizeComment{
-		l: l,
+		l: lc.(*lexer),
 	}
 }
 
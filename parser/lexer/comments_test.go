@@ -25,7 +25,7 @@ import (
 )
 
 func TestRecognizeCommentImplementsRecognizer(t *testing.T) {
-	assert.Implements(t, (*Recognizer)(nil), &recognizeComment{})
+	assert.Implements(t, (*common.Recognizer)(nil), &recognizeComment{})
 }
 
 func TestRecogComment(t *testing.T) {
@@ -179,6 +179,10 @@ func TestRecognizeCommentRecognizeErr(t *testing.T) {
 			B:    common.FilePos{L: 3, C: 2},
 			E:    common.FilePos{L: 3, C: 3},
 		},
-		Val: assert.AnError,
+		Val: common.Diagnose(assert.AnError, common.Location{
+			File: "file",
+			B:    common.FilePos{L: 3, C: 2},
+			E:    common.FilePos{L: 3, C: 3},
+		}),
 	}, l.tokens.Front().Value.(*common.Token))
 }
@@ -0,0 +1,248 @@
+// Copyright (c) 2019 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package lexer
+
+import (
+	"errors"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hydralang/hydra/parser/common"
+)
+
+// Result is the result of lexing a single source with LexFiles or
+// LexBatch.  The source is lexed with error recovery enabled, so the
+// lexical errors and the warnings reported through the warning hook
+// are all collected in Diagnostics, in order by location, and Tokens
+// contains the remaining tokens.  Err is reserved for failures that
+// prevent the source from being lexed at all, such as a file that
+// can't be opened or read.
+type Result struct {
+	Filename    string               // The name of the file
+	Tokens      []*common.Token      // The tokens, other than errors
+	Diagnostics []*common.Diagnostic // The lexical diagnostics
+	Err         error                // The I/O or setup error, if any
+	Bytes       int64                // The number of bytes read
+}
+
+// failed tests whether the result has an error: either an I/O or
+// setup error, or a diagnostic with error severity.
+func (r *Result) failed() bool {
+	if r.Err != nil {
+		return true
+	}
+
+	for _, diag := range r.Diagnostics {
+		if diag.Severity == common.SevError {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Stats contains aggregate statistics for a LexFiles or LexBatch run.
+type Stats struct {
+	Files  int           // The number of files lexed
+	Errors int           // The number of files with errors
+	Tokens int           // The total number of tokens
+	Bytes  int64         // The total number of bytes read
+	Time   time.Duration // The elapsed time
+}
+
+// counter is an io.Reader that counts the bytes read through it.  It
+// also saves the first error, other than io.EOF, returned by the
+// underlying reader.
+type counter struct {
+	r   io.Reader // The underlying reader
+	n   int64     // The number of bytes read
+	err error     // The first read error
+}
+
+// Read reads up to len(p) bytes into p.
+func (c *counter) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	if err != nil && err != io.EOF && c.err == nil {
+		c.err = err
+	}
+	return n, err
+}
+
+// lexOne lexes the source described by the options to the end,
+// with error recovery enabled.  The options are copied, so that the
+// bytes read may be counted and the warnings collected; warnings are
+// also passed on to the warning hook in the options, if any, with
+// calls to the hook serialized by the mutex.  A failure reading the
+// source is reported as the result's error.
+func lexOne(opts *common.Options, mu *sync.Mutex) *Result {
+	result := &Result{Filename: opts.Filename}
+	cnt := &counter{r: opts.Source}
+	tmp := *opts
+	tmp.Source = cnt
+	tmp.Recover = true
+	tmp.Warn = func(diag *common.Diagnostic) {
+		result.Diagnostics = append(result.Diagnostics, diag)
+		if opts.Warn != nil {
+			mu.Lock()
+			defer mu.Unlock()
+			opts.Warn(diag)
+		}
+	}
+
+	l, err := Lex(&tmp, nil)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	// Collect the tokens and diagnostics
+	result.Tokens = []*common.Token{}
+	for tok := l.Next(); tok != nil; tok = l.Next() {
+		if tok.Sym != common.TokError {
+			result.Tokens = append(result.Tokens, tok)
+			continue
+		}
+
+		// Skip the error token for a read failure
+		if cnt.err != nil && errors.Is(tok.Val.(error), cnt.err) {
+			continue
+		}
+		result.Diagnostics = append(result.Diagnostics, common.Diagnose(tok.Val.(error), tok.Loc))
+	}
+	sort.SliceStable(result.Diagnostics, func(i, j int) bool {
+		return result.Diagnostics[i].Loc.Less(result.Diagnostics[j].Loc)
+	})
+	result.Err = cnt.err
+	result.Bytes = cnt.n
+
+	return result
+}
+
+// lexFile opens and lexes the named file, using the specified
+// profile and options.  The mutex serializes calls to the warning
+// hook.
+func lexFile(prof *common.Profile, path string, opts []common.Option, mu *sync.Mutex) *Result {
+	f, err := os.Open(path)
+	if err != nil {
+		return &Result{Filename: path, Err: err}
+	}
+	defer f.Close()
+
+	// Set up the options
+	o := &common.Options{
+		Source: f,
+		Prof:   prof,
+	}
+	o.Parse(opts...)
+
+	return lexOne(o, mu)
+}
+
+// run runs a job for each of the specified number of sources on a
+// pool of worker goroutines, and collects the results and the
+// statistics.  The results are in the same order as the sources.
+func run(n, workers int, job func(i int) *Result) ([]*Result, *Stats) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	start := time.Now()
+
+	// Start the workers
+	results := make([]*Result, n)
+	jobs := make(chan int)
+	wg := &sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = job(i)
+			}
+		}()
+	}
+
+	// Feed them the jobs and wait for them to finish
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	// Compute the statistics
+	stats := &Stats{
+		Files: n,
+		Time:  time.Since(start),
+	}
+	for _, result := range results {
+		if result.failed() {
+			stats.Errors++
+		}
+		stats.Tokens += len(result.Tokens)
+		stats.Bytes += result.Bytes
+	}
+
+	return results, stats
+}
+
+// LexFiles lexes the named files on a pool of the specified number
+// of worker goroutines; if the number of workers is not positive, one
+// worker is used for each CPU.  Each file is lexed to the end with
+// error recovery enabled, using the specified profile and options.
+// The profile is frozen, so that it may be shared by the workers; an
+// error is returned if it cannot be frozen.  Calls to the warning
+// hook set by the options are serialized, so the hook need not be
+// safe for concurrent use.  The results are in the same order as the
+// files.
+func LexFiles(prof *common.Profile, paths []string, workers int, opts ...common.Option) ([]*Result, *Stats, error) {
+	if err := prof.Freeze(); err != nil {
+		return nil, nil, err
+	}
+
+	mu := &sync.Mutex{}
+	results, stats := run(len(paths), workers, func(i int) *Result {
+		return lexFile(prof, paths[i], opts, mu)
+	})
+
+	return results, stats, nil
+}
+
+// LexBatch lexes the sources described by the options on a pool of
+// the specified number of worker goroutines; if the number of
+// workers is not positive, one worker is used for each CPU.  Each
+// source is lexed to the end with error recovery enabled.  The
+// profiles are frozen, so that they may be shared by the workers; an
+// error is returned if one cannot be frozen.  Calls to the warning
+// hooks set in the options are serialized, so the hooks need not be
+// safe for concurrent use.  The results are in the same order as the
+// options.
+func LexBatch(opts []*common.Options, workers int) ([]*Result, *Stats, error) {
+	for _, o := range opts {
+		if err := o.Prof.Freeze(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	mu := &sync.Mutex{}
+	results, stats := run(len(opts), workers, func(i int) *Result {
+		return lexOne(opts[i], mu)
+	})
+
+	return results, stats, nil
+}
@@ -0,0 +1,371 @@
+// Copyright (c) 2019 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package lexer
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hydralang/hydra/parser/common"
+)
+
+func makeDriverProfile() *common.Profile {
+	prof := testProfile.Copy()
+	prof.Keywords = common.Keywords{}
+	prof.Operators = common.NewOperators(
+		&common.Symbol{Name: "="},
+		&common.Symbol{Name: "+"},
+	)
+	return prof
+}
+
+func makeDriverFiles(t *testing.T, sources ...string) (string, []string) {
+	dir, err := ioutil.TempDir("", "driver")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paths := []string{}
+	for i, src := range sources {
+		path := filepath.Join(dir, string('a'+rune(i))+".hy")
+		if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	return dir, paths
+}
+
+type recognizeSpam struct {
+	lc common.LexContext
+}
+
+func recogSpam(lc common.LexContext) common.Recognizer {
+	return &recognizeSpam{lc: lc}
+}
+
+func (r *recognizeSpam) Recognize(ch common.AugChar) {
+	r.lc.PushErr(ch.Loc, errors.New("spam"))
+}
+
+func TestCounterRead(t *testing.T) {
+	a := assert.New(t)
+	obj := &counter{r: strings.NewReader("abcde")}
+	buf := make([]byte, 3)
+
+	n, err := obj.Read(buf)
+
+	a.NoError(err)
+	a.Equal(3, n)
+	a.Equal(int64(3), obj.n)
+	a.Equal([]byte("abc"), buf)
+}
+
+func TestLexOne(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("a = b\n"))
+	opts.Prof = makeDriverProfile()
+
+	result := lexOne(opts, &sync.Mutex{})
+
+	a.Equal("file", result.Filename)
+	a.Len(result.Tokens, 5)
+	a.NoError(result.Err)
+	a.Equal(int64(6), result.Bytes)
+}
+
+func TestCounterReadError(t *testing.T) {
+	a := assert.New(t)
+	obj := &counter{r: iotest.TimeoutReader(strings.NewReader("abcde"))}
+	buf := make([]byte, 3)
+	obj.Read(buf)
+
+	n, err := obj.Read(buf)
+
+	a.Equal(iotest.ErrTimeout, err)
+	a.Equal(0, n)
+	a.Equal(iotest.ErrTimeout, obj.err)
+}
+
+func TestCounterReadEOF(t *testing.T) {
+	a := assert.New(t)
+	obj := &counter{r: strings.NewReader("")}
+	buf := make([]byte, 3)
+
+	_, err := obj.Read(buf)
+
+	a.Equal(io.EOF, err)
+	a.NoError(obj.err)
+}
+
+func TestLexOneErrors(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("a ? b $ c\n"))
+	opts.Prof = makeDriverProfile()
+
+	result := lexOne(opts, &sync.Mutex{})
+
+	a.NoError(result.Err)
+	a.Len(result.Tokens, 5)
+	a.Len(result.Diagnostics, 2)
+	a.True(errors.Is(result.Diagnostics[0], common.ErrBadOp))
+	a.Equal(3, result.Diagnostics[0].Loc.B.C)
+	a.True(errors.Is(result.Diagnostics[1], common.ErrBadOp))
+	a.Equal(7, result.Diagnostics[1].Loc.B.C)
+	a.False(opts.Recover)
+}
+
+func TestLexOneUncodedError(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("a @ b @ c\n"))
+	opts.Prof = makeDriverProfile()
+	opts.Prof.Recognizers = DefaultRecognizers()
+	opts.Prof.Recognizers.Add(&common.RecogEntry{
+		Name:     "spam",
+		Priority: PrioOp - 1,
+		Match: func(ch common.AugChar, s common.Scanner) bool {
+			return ch.C == '@'
+		},
+		Init: recogSpam,
+	})
+
+	result := lexOne(opts, &sync.Mutex{})
+
+	a.NoError(result.Err)
+	a.Len(result.Diagnostics, 2)
+	a.Equal(common.CodeUnknown, result.Diagnostics[0].Code)
+	a.EqualError(result.Diagnostics[1], "spam")
+}
+
+func TestLexOneWarnings(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("a = b \nc = d\f + e\n"))
+	opts.Prof = makeDriverProfile()
+	opts.Prof.Lints = common.LintAll
+	warnings := []*common.Diagnostic{}
+	opts.Warn = func(diag *common.Diagnostic) {
+		warnings = append(warnings, diag)
+	}
+
+	result := lexOne(opts, &sync.Mutex{})
+
+	a.NoError(result.Err)
+	a.Len(result.Diagnostics, 2)
+	a.True(errors.Is(result.Diagnostics[0], common.WarnTrailingWS))
+	a.True(errors.Is(result.Diagnostics[1], common.WarnFormFeed))
+	a.Equal(result.Diagnostics, warnings)
+}
+
+func TestLexOneReadError(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(iotest.TimeoutReader(strings.NewReader("a = b\n")))
+	opts.Prof = makeDriverProfile()
+
+	result := lexOne(opts, &sync.Mutex{})
+
+	a.Equal(iotest.ErrTimeout, result.Err)
+	a.Nil(result.Diagnostics)
+}
+
+func TestLexOneSetupError(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("a = b\n"))
+	opts.Prof = makeDriverProfile()
+	opts.Encoding = "no-such-encoding"
+
+	result := lexOne(opts, &sync.Mutex{})
+
+	a.Error(result.Err)
+	a.Nil(result.Tokens)
+	a.Nil(result.Diagnostics)
+}
+
+func TestResultFailedClean(t *testing.T) {
+	a := assert.New(t)
+	obj := &Result{}
+
+	a.False(obj.failed())
+}
+
+func TestResultFailedErr(t *testing.T) {
+	a := assert.New(t)
+	obj := &Result{Err: os.ErrNotExist}
+
+	a.True(obj.failed())
+}
+
+func TestResultFailedDiagnostic(t *testing.T) {
+	a := assert.New(t)
+	obj := &Result{
+		Diagnostics: []*common.Diagnostic{
+			common.Diagnose(common.ErrBadOp, common.Location{}),
+		},
+	}
+
+	a.True(obj.failed())
+}
+
+func TestResultFailedWarning(t *testing.T) {
+	a := assert.New(t)
+	obj := &Result{
+		Diagnostics: []*common.Diagnostic{
+			common.Warning(common.WarnTrailingWS, common.Location{}),
+		},
+	}
+
+	a.False(obj.failed())
+}
+
+func TestLexFileMissing(t *testing.T) {
+	a := assert.New(t)
+
+	result := lexFile(makeDriverProfile(), "/no/such/file.hy", nil, &sync.Mutex{})
+
+	a.Equal("/no/such/file.hy", result.Filename)
+	a.Nil(result.Tokens)
+	a.True(os.IsNotExist(result.Err))
+}
+
+func TestLexFiles(t *testing.T) {
+	a := assert.New(t)
+	dir, paths := makeDriverFiles(t, "a = b\n", "a ? b\n", "c + d\ne\n")
+	defer os.RemoveAll(dir)
+	paths = append(paths, filepath.Join(dir, "missing.hy"))
+	prof := makeDriverProfile()
+
+	results, stats, err := LexFiles(prof, paths, 2, common.Encoding("utf-8"))
+
+	a.NoError(err)
+	a.True(prof.Frozen())
+	a.Len(results, 4)
+	for i, result := range results {
+		a.Equal(paths[i], result.Filename)
+	}
+	a.Len(results[0].Tokens, 5)
+	a.NoError(results[0].Err)
+	a.Len(results[1].Tokens, 4)
+	a.NoError(results[1].Err)
+	a.Len(results[1].Diagnostics, 1)
+	a.True(errors.Is(results[1].Diagnostics[0], common.ErrBadOp))
+	a.Len(results[2].Tokens, 7)
+	a.NoError(results[2].Err)
+	a.True(os.IsNotExist(results[3].Err))
+	a.Equal(4, stats.Files)
+	a.Equal(2, stats.Errors)
+	a.Equal(16, stats.Tokens)
+	a.Equal(int64(20), stats.Bytes)
+}
+
+func TestLexFilesDefaultWorkers(t *testing.T) {
+	a := assert.New(t)
+	dir, paths := makeDriverFiles(t, "a = b\n", "c + d\n")
+	defer os.RemoveAll(dir)
+
+	results, stats, err := LexFiles(makeDriverProfile(), paths, 0)
+
+	a.NoError(err)
+	a.Len(results, 2)
+	a.Equal(10, stats.Tokens)
+}
+
+func TestLexFilesFreezeError(t *testing.T) {
+	a := assert.New(t)
+	prof := makeDriverProfile()
+	prof.Keywords = common.Keywords{"kw": &common.Symbol{Name: "kw", Kind: common.KindOperator}}
+
+	results, stats, err := LexFiles(prof, []string{"file.hy"}, 1)
+
+	a.True(errors.Is(err, common.ErrSymbolKind))
+	a.Nil(results)
+	a.Nil(stats)
+}
+
+func TestLexBatch(t *testing.T) {
+	a := assert.New(t)
+	prof := makeDriverProfile()
+	opts := []*common.Options{
+		makeOptions(strings.NewReader("a = b\n")),
+		makeOptions(strings.NewReader("a ? b\n")),
+	}
+	for _, o := range opts {
+		o.Prof = prof
+	}
+
+	results, stats, err := LexBatch(opts, 4)
+
+	a.NoError(err)
+	a.True(prof.Frozen())
+	a.Len(results, 2)
+	a.Len(results[0].Tokens, 5)
+	a.NoError(results[1].Err)
+	a.True(errors.Is(results[1].Diagnostics[0], common.ErrBadOp))
+	a.Equal(2, stats.Files)
+	a.Equal(1, stats.Errors)
+	a.Equal(9, stats.Tokens)
+	a.Equal(int64(12), stats.Bytes)
+}
+
+func TestLexBatchWarnings(t *testing.T) {
+	a := assert.New(t)
+	prof := makeDriverProfile()
+	prof.Lints = common.LintAll
+	opts := []*common.Options{
+		makeOptions(strings.NewReader("a = b \n")),
+		makeOptions(strings.NewReader("a = b\n")),
+		makeOptions(strings.NewReader("a = b\fc \n")),
+	}
+	count := 0
+	for _, o := range opts {
+		o.Prof = prof
+		o.Warn = func(diag *common.Diagnostic) {
+			count++
+		}
+	}
+
+	results, stats, err := LexBatch(opts, 3)
+
+	a.NoError(err)
+	a.Len(results[0].Diagnostics, 1)
+	a.True(errors.Is(results[0].Diagnostics[0], common.WarnTrailingWS))
+	a.Nil(results[1].Diagnostics)
+	a.Len(results[2].Diagnostics, 2)
+	a.True(errors.Is(results[2].Diagnostics[0], common.WarnFormFeed))
+	a.True(errors.Is(results[2].Diagnostics[1], common.WarnTrailingWS))
+	a.Equal(3, count)
+	a.Equal(0, stats.Errors)
+}
+
+func TestLexBatchFreezeError(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("a = b\n"))
+	opts.Prof = makeDriverProfile()
+	opts.Prof.Keywords = common.Keywords{"kw": &common.Symbol{Name: "kw", Kind: common.KindOperator}}
+
+	results, stats, err := LexBatch([]*common.Options{opts}, 1)
+
+	a.True(errors.Is(err, common.ErrSymbolKind))
+	a.Nil(results)
+	a.Nil(stats)
+}
@@ -0,0 +1,518 @@
+// Copyright (c) 2019 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package lexer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hydralang/hydra/parser/common"
+)
+
+// fstring describes an f-string being lexed.  It is the data of the
+// lexical modes used for the literal text of the f-string and for
+// the format specifications of its replacement fields.
+type fstring struct {
+	q     rune  // The quote character
+	flags uint8 // The string flags
+	depth int   // Depth of the pairing stack outside the f-string
+	spec  bool  // Lexing a format specification
+}
+
+// Recognizer registries for f-strings.  The literal text of an
+// f-string, including format specifications, is recognized by a
+// single recognizer, while the replacement fields use the profile's
+// recognizers, with the addition of a recognizer for the characters
+// that end the expression.
+var (
+	fstringRecogs = common.NewRecognizers(&common.RecogEntry{
+		Name:  "f-string",
+		Match: anyChar,
+		Init:  recogFString,
+	})
+	fieldRecogs = common.NewRecognizers(&common.RecogEntry{
+		Name:  "f-string field",
+		Match: fieldChar,
+		Init:  recogField,
+	})
+)
+
+// fieldMode is the lexical mode for the expression of a replacement
+// field in a triple-quoted f-string.  Newlines are ignored, since the
+// open brace of the field is on the pairing stack.
+var fieldMode = &common.Mode{
+	Name:        "f-string replacement field",
+	Recognizers: fieldRecogs,
+}
+
+// lineFieldMode is the lexical mode for the expression of a
+// replacement field in an f-string that is not triple-quoted.  Such a
+// field may not extend past the end of the line, so newlines are
+// passed to the field recognizer.
+var lineFieldMode = &common.Mode{
+	Name:        "f-string replacement field",
+	Recognizers: fieldRecogs,
+	Flags:       common.ModeStopNL | common.ModeNoIndent,
+}
+
+// anyChar is a prefix predicate that matches any character.
+func anyChar(ch common.AugChar, s common.Scanner) bool {
+	return true
+}
+
+// fieldChar is a prefix predicate for the characters that may end
+// the expression of an f-string replacement field.  Newlines and
+// quotes end the field if the f-string is not triple-quoted.
+func fieldChar(ch common.AugChar, s common.Scanner) bool {
+	return ch.C == '}' || ch.C == ':' || ch.C == '!' || ch.Class&(common.CharNL|common.CharQuote) != 0
+}
+
+// fstringMode constructs the lexical mode for the literal text of an
+// f-string or of a format specification.
+func fstringMode(fs fstring) *common.Mode {
+	name := "f-string"
+	if fs.spec {
+		name = "f-string format specification"
+	}
+
+	return &common.Mode{
+		Name:        name,
+		Recognizers: fstringRecogs,
+		Flags:       common.ModeKeepWS | common.ModeNoIndent,
+		Data:        fs,
+	}
+}
+
+// format begins lexing an f-string.  It is called by the string
+// recognizer with the character following the open quote, and pushes
+// the TokFStringStart token and the lexical mode for the literal text
+// of the f-string; the remainder of the f-string is lexed in that
+// mode.
+func (r *recognizeString) format(ch common.AugChar) {
+	// F-strings are always text
+	if r.flags&common.StrBytes != 0 {
+		r.l.s.Push(ch)
+		r.fail(r.loc.Thru(ch.Loc), common.ErrFStringBytes)
+		return
+	}
+
+	r.l.pushTok(common.TokFStringStart, r.loc.Thru(ch.Loc), nil)
+	r.l.PushMode(fstringMode(fstring{
+		q:     r.q,
+		flags: r.flags,
+		depth: r.l.pair.Len(),
+	}))
+	r.l.s.Push(ch)
+}
+
+// closeField closes an f-string replacement field.  The mode for the
+// expression must be the active mode.
+func (l *lexer) closeField(loc common.Location) {
+	l.pair.Remove(l.pair.Back())
+	l.PopMode()
+	l.pushTok(common.TokFExprEnd, loc, nil)
+}
+
+// fstring returns the innermost f-string being lexed.
+func (l *lexer) fstring() fstring {
+	for elem := l.modes.Back(); elem != nil; elem = elem.Prev() {
+		if fs, ok := elem.Value.(*common.Mode).Data.(fstring); ok {
+			return fs
+		}
+	}
+
+	return fstring{}
+}
+
+// unclosedField constructs the error for a replacement field that is
+// not closed by the end of the line or of the f-string, including a
+// label for the open brace and a suggested fix that inserts the
+// missing close brace.
+func unclosedField(open *common.Token, loc common.Location) error {
+	diag := common.Diagnose(common.ErrFStringField, loc)
+	diag.Labels = []common.Label{
+		{Loc: open.Loc, Msg: "replacement field begins here"},
+	}
+	diag.Fixes = []common.Fix{
+		{
+			Msg:   fmt.Sprintf("insert the missing \"%s\"", open.Sym.Close),
+			Edits: []common.Edit{common.Insert(loc, open.Sym.Close)},
+		},
+	}
+	return diag
+}
+
+// recognizeFString is a recognizer for the literal text of an
+// f-string, or of a format specification within the f-string.  It is
+// used only in the lexical modes constructed by fstringMode.
+type recognizeFString struct {
+	l       *lexer           // The lexer
+	fs      fstring          // The f-string being lexed
+	str     *recognizeString // For escapes and errors
+	loc     common.Location  // Location of the text
+	started bool             // Text location has been started
+}
+
+// recogFString constructs a recognizer for the literal text of an
+// f-string.
+func recogFString(lc common.LexContext) common.Recognizer {
+	l := lc.(*lexer)
+	fs := l.Mode().Data.(fstring)
+
+	return &recognizeFString{
+		l:  l,
+		fs: fs,
+		str: &recognizeString{
+			l:     l,
+			flags: fs.flags,
+			q:     fs.q,
+			buf:   &bufString{},
+		},
+	}
+}
+
+// mark marks the beginning of the text, if it has not already been
+// marked.
+func (r *recognizeFString) mark(ch common.AugChar) {
+	if !r.started {
+		r.loc = ch.Loc
+		r.started = true
+	}
+}
+
+// flush pushes a token for the text accumulated so far, which ends
+// at the specified character.  Nothing is pushed if there is no
+// text.
+func (r *recognizeFString) flush(end common.AugChar) {
+	if !r.started {
+		return
+	}
+
+	sym := common.TokFStringPart
+	if r.fs.spec {
+		sym = common.TokFStringSpec
+	}
+	r.l.pushTok(sym, r.loc.Thru(end.Loc), r.str.buf.get())
+
+	// Reset for more text
+	r.str.buf = &bufString{}
+	r.started = false
+}
+
+// closing checks whether a quote character closes the f-string.  It
+// returns the location of the close quotes and true if so;
+// otherwise, the quote characters are added to the text.
+func (r *recognizeFString) closing(ch common.AugChar) (common.Location, bool) {
+	if r.fs.flags&common.StrMulti == 0 {
+		return ch.Loc, true
+	}
+
+	// Look for triple quotes
+	loc := ch.Loc
+	cnt := 1
+	for ; cnt < 3; cnt++ {
+		next := r.l.s.Next()
+		if next.C != r.fs.q {
+			r.l.s.Push(next)
+			break
+		}
+		loc = loc.ThruEnd(next.Loc)
+	}
+	if cnt >= 3 {
+		return loc, true
+	}
+
+	// Not a close; add the quotes to the text
+	r.mark(ch)
+	for ; cnt > 0; cnt-- {
+		r.str.buf.putC(r.fs.q)
+	}
+	return loc, false
+}
+
+// abort abandons the f-string after an error, discarding its lexical
+// modes and any unclosed open operators within it.
+func (r *recognizeFString) abort() {
+	for mode := r.l.Mode(); mode != nil; mode = r.l.Mode() {
+		r.l.PopMode()
+		if fs, ok := mode.Data.(fstring); ok && !fs.spec {
+			break
+		}
+	}
+	for r.l.pair.Len() > r.fs.depth {
+		r.l.pair.Remove(r.l.pair.Back())
+	}
+}
+
+// unclosed reports an unclosed f-string at the specified character,
+// which is pushed back for reprocessing.
+func (r *recognizeFString) unclosed(ch common.AugChar) {
+	r.l.s.Push(ch)
+	r.flush(ch)
+	r.l.pushErr(ch.Loc, r.str.unclosed(ch.Loc))
+	r.abort()
+}
+
+// Recognize is called to recognize the literal text of an f-string.
+// Will be called with the first character, and should push zero or
+// more tokens onto the lexer's tokens queue.
+func (r *recognizeFString) Recognize(ch common.AugChar) {
+	r.text(ch)
+}
+
+// text lexes the literal text of an f-string, beginning with the
+// specified character, until the beginning of a replacement field or
+// the end of the f-string or the format specification.
+func (r *recognizeFString) text(ch common.AugChar) {
+	for ; ; ch = r.l.s.Next() {
+		switch ch.C {
+		case common.Err: // Error occurred
+			r.flush(ch)
+			r.l.pushErr(ch.Loc, ch.Val.(error))
+			return
+
+		case common.EOF: // EOF in an f-string
+			r.unclosed(ch)
+			return
+
+		case '\n': // Newline, possible unclosed f-string
+			if r.fs.flags&common.StrMulti == 0 {
+				r.unclosed(ch)
+				return
+			}
+			r.mark(ch)
+			r.str.buf.putC(ch.C)
+
+		case r.fs.q: // Possible end of the f-string
+			loc, ok := r.closing(ch)
+			if !ok {
+				continue
+			}
+			r.flush(ch)
+
+			// Format specification must be closed first
+			if r.fs.spec {
+				r.l.pushErr(loc, common.ErrFStringField)
+				r.abort()
+				return
+			}
+
+			r.l.pushTok(common.TokFStringEnd, loc, nil)
+			r.l.PopMode()
+			return
+
+		case '{': // Replacement field or escaped brace
+			next := r.l.s.Next()
+			if next.C == '{' && !r.fs.spec {
+				r.mark(ch)
+				r.str.buf.putC('{')
+				continue
+			}
+			r.l.s.Push(next)
+			r.flush(ch)
+
+			// Begin the replacement field; the text is set now
+			// so that pairing errors can use it
+			tok := r.l.pushTok(common.TokFExprStart, ch.Loc, nil)
+			tok.Text = "{"
+			r.l.pair.PushBack(tok)
+			if r.fs.flags&common.StrMulti != 0 {
+				r.l.PushMode(fieldMode)
+			} else {
+				r.l.PushMode(lineFieldMode)
+			}
+			return
+
+		case '}': // End of field or escaped brace
+			if r.fs.spec {
+				r.flush(ch)
+				r.l.PopMode()
+				r.l.closeField(ch.Loc)
+				return
+			}
+
+			next := r.l.s.Next()
+			if next.C == '}' {
+				r.mark(ch)
+				r.str.buf.putC('}')
+				continue
+			}
+			r.l.s.Push(next)
+
+			// A single close brace is an error
+			r.flush(ch)
+			r.l.pushErr(ch.Loc, common.ErrFStringBrace)
+			if r.l.s == nil {
+				return
+			}
+
+		case '\\': // Introduces an escape
+			r.mark(ch)
+			if loc, err := r.str.escape(ch); err != nil {
+				r.flush(ch)
+				r.l.pushErr(loc, err)
+				if r.l.s == nil {
+					return
+				}
+			}
+
+		default: // Regular character
+			r.mark(ch)
+			if err := r.str.buf.putC(ch.C); err != nil {
+				r.flush(ch)
+				r.l.pushErr(ch.Loc, err)
+				if r.l.s == nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+// recognizeField is a recognizer for the characters that may end the
+// expression of an f-string replacement field: the close brace, the
+// colon that begins the format specification, and the exclamation
+// point that begins the conversion.  These characters only end the
+// expression outside of any brackets within it; otherwise, they are
+// passed to the lexer's recognizers.
+type recognizeField struct {
+	l *lexer // The lexer
+}
+
+// recogField constructs a recognizer for the end of the expression
+// of an f-string replacement field.
+func recogField(lc common.LexContext) common.Recognizer {
+	return &recognizeField{
+		l: lc.(*lexer),
+	}
+}
+
+// delegate passes a character to the lexer's recognizers.
+func (r *recognizeField) delegate(ch common.AugChar) {
+	if entry := r.l.recognizers().Lookup(ch, r.l.s); entry != nil {
+		entry.Init(r.l).Recognize(ch)
+	} else {
+		r.l.pushErr(ch.Loc, common.ErrBadOp)
+	}
+}
+
+// conversion recognizes the conversion of a replacement field, such
+// as "!r".  Returns false if the exclamation point does not begin a
+// conversion, as with the "!=" operator.
+func (r *recognizeField) conversion(ch common.AugChar) bool {
+	next := r.l.s.Next()
+	if next.Class&common.CharIDStart == 0 {
+		r.l.s.Push(next)
+		return false
+	}
+
+	// Accumulate the conversion name
+	name := &strings.Builder{}
+	for ; next.Class&common.CharIDCont != 0; next = r.l.s.Next() {
+		name.WriteRune(next.C)
+	}
+	r.l.s.Push(next)
+
+	r.l.pushTok(common.TokFStringConv, ch.Loc.Thru(next.Loc), name.String())
+	return true
+}
+
+// unclosed reports a replacement field that is not closed by the
+// end of the line or of the f-string, at the specified character,
+// which is pushed back to end the f-string.  The field is abandoned,
+// along with any unclosed open operators within it.
+func (r *recognizeField) unclosed(ch common.AugChar, fs fstring) {
+	// Find the open brace of the field
+	elem := r.l.pair.Front()
+	for i := 0; i < fs.depth; i++ {
+		elem = elem.Next()
+	}
+	r.l.s.Push(ch)
+	r.l.pushErr(ch.Loc, unclosedField(elem.Value.(*common.Token), ch.Loc))
+
+	// Abandon the field
+	for r.l.pair.Len() > fs.depth {
+		r.l.pair.Remove(r.l.pair.Back())
+	}
+	r.l.PopMode()
+}
+
+// nested tests whether a quote character at the end of the
+// expression of a replacement field begins a nested string, rather
+// than ending the f-string.  It does if the quote character appears
+// again later on the line.  The characters examined are pushed back.
+func (r *recognizeField) nested(q rune) bool {
+	var chars []common.AugChar
+	found := false
+	for {
+		ch := r.l.s.Next()
+		chars = append(chars, ch)
+		if ch.C == q {
+			found = true
+		}
+		if found || ch.C == common.EOF || ch.C == common.Err || ch.Class&common.CharNL != 0 {
+			break
+		}
+	}
+
+	// Push back the characters
+	for i := len(chars) - 1; i >= 0; i-- {
+		r.l.s.Push(chars[i])
+	}
+
+	return found
+}
+
+// Recognize is called to recognize the end of the expression of a
+// replacement field.  Will be called with the first character, and
+// should push zero or more tokens onto the lexer's tokens queue.
+func (r *recognizeField) Recognize(ch common.AugChar) {
+	// A field in a single-line f-string ends with the line or
+	// the f-string
+	if ch.Class&(common.CharNL|common.CharQuote) != 0 {
+		if fs := r.l.fstring(); fs.flags&common.StrMulti == 0 && (ch.Class&common.CharNL != 0 || ch.C == fs.q && !r.nested(ch.C)) {
+			r.unclosed(ch, fs)
+		} else {
+			r.delegate(ch)
+		}
+		return
+	}
+
+	// Only applies outside of brackets
+	if r.l.pair.Len() == 0 || r.l.pair.Back().Value.(*common.Token).Sym != common.TokFExprStart {
+		r.delegate(ch)
+		return
+	}
+
+	switch ch.C {
+	case '}': // End of the replacement field
+		r.l.closeField(ch.Loc)
+
+	case ':': // Beginning of the format specification
+		fs := r.l.fstring()
+		fs.spec = true
+		r.l.PushMode(fstringMode(fs))
+
+		// The specification includes the colon
+		spec := recogFString(r.l).(*recognizeFString)
+		spec.mark(ch)
+		spec.text(r.l.s.Next())
+
+	case '!': // Beginning of the conversion
+		if !r.conversion(ch) {
+			r.delegate(ch)
+		}
+	}
+}
@@ -0,0 +1,519 @@
+// Copyright (c) 2019 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package lexer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hydralang/hydra/parser/common"
+	"github.com/hydralang/hydra/testutils"
+)
+
+func makeFStringOptions(src string) *common.Options {
+	opts := makeOptions(strings.NewReader(src))
+	opts.Prof = testProfile.Copy()
+	opts.Prof.StrFlags = map[rune]uint8{
+		'r': common.StrRaw,
+		'b': common.StrBytes,
+		'f': common.StrFormat,
+	}
+	opts.Recover = true
+	return opts
+}
+
+func lexFString(src string) []*common.Token {
+	l, _ := Lex(makeFStringOptions(src), nil)
+
+	toks := []*common.Token{}
+	for tok := l.Next(); tok != nil; tok = l.Next() {
+		toks = append(toks, tok)
+	}
+	return toks
+}
+
+func tokSyms(toks []*common.Token) []*common.Symbol {
+	result := make([]*common.Symbol, len(toks))
+	for i, tok := range toks {
+		result[i] = tok.Sym
+	}
+	return result
+}
+
+func tokVals(toks []*common.Token) []interface{} {
+	result := make([]interface{}, len(toks))
+	for i, tok := range toks {
+		if diag, ok := tok.Val.(*common.Diagnostic); ok {
+			result[i] = diag.Code
+		} else {
+			result[i] = tok.Val
+		}
+	}
+	return result
+}
+
+func TestFStringMode(t *testing.T) {
+	a := assert.New(t)
+	fs := fstring{q: '"', flags: common.StrFormat, depth: 1}
+
+	result := fstringMode(fs)
+
+	a.Equal(&common.Mode{
+		Name:        "f-string",
+		Recognizers: fstringRecogs,
+		Flags:       common.ModeKeepWS | common.ModeNoIndent,
+		Data:        fs,
+	}, result)
+}
+
+func TestFStringModeSpec(t *testing.T) {
+	a := assert.New(t)
+	fs := fstring{q: '"', flags: common.StrFormat, depth: 1, spec: true}
+
+	result := fstringMode(fs)
+
+	a.Equal("f-string format specification", result.Name)
+	a.Equal(fs, result.Data)
+}
+
+func TestAnyChar(t *testing.T) {
+	a := assert.New(t)
+
+	result := anyChar(common.AugChar{C: ' ', Class: common.CharWS}, nil)
+
+	a.True(result)
+}
+
+func TestFieldChar(t *testing.T) {
+	a := assert.New(t)
+
+	a.True(fieldChar(common.AugChar{C: '}'}, nil))
+	a.True(fieldChar(common.AugChar{C: ':'}, nil))
+	a.True(fieldChar(common.AugChar{C: '!'}, nil))
+	a.True(fieldChar(common.AugChar{C: '\n', Class: common.CharNL | common.CharWS}, nil))
+	a.True(fieldChar(common.AugChar{C: '\'', Class: common.CharQuote}, nil))
+	a.False(fieldChar(common.AugChar{C: '{'}, nil))
+}
+
+func TestRecognizeFStringImplementsRecognizer(t *testing.T) {
+	assert.Implements(t, (*common.Recognizer)(nil), &recognizeFString{})
+}
+
+func TestRecogFString(t *testing.T) {
+	a := assert.New(t)
+	fs := fstring{q: '\'', flags: common.StrFormat | common.StrRaw}
+	l := &lexer{}
+	l.modes.PushBack(fstringMode(fs))
+
+	result := recogFString(l)
+
+	r, ok := result.(*recognizeFString)
+	a.True(ok)
+	testutils.AssertPtrEqual(a, l, r.l)
+	a.Equal(fs, r.fs)
+	testutils.AssertPtrEqual(a, l, r.str.l)
+	a.Equal(fs.flags, r.str.flags)
+	a.Equal('\'', r.str.q)
+	a.False(r.started)
+}
+
+func TestRecognizeFieldImplementsRecognizer(t *testing.T) {
+	assert.Implements(t, (*common.Recognizer)(nil), &recognizeField{})
+}
+
+func TestRecogField(t *testing.T) {
+	a := assert.New(t)
+	l := &lexer{}
+
+	result := recogField(l)
+
+	a.Equal(&recognizeField{l: l}, result)
+}
+
+func TestFStringBasic(t *testing.T) {
+	a := assert.New(t)
+
+	result := lexFString("f\"a{x}b\"\n")
+
+	a.Equal([]*common.Symbol{
+		common.TokFStringStart,
+		common.TokFStringPart,
+		common.TokFExprStart,
+		common.TokIdent,
+		common.TokFExprEnd,
+		common.TokFStringPart,
+		common.TokFStringEnd,
+		common.TokNewline,
+		common.TokEOF,
+	}, tokSyms(result))
+	a.Equal([]interface{}{nil, "a", nil, "x", nil, "b", nil, nil, nil}, tokVals(result))
+	a.Equal(common.Location{
+		File: "file",
+		B:    common.FilePos{L: 1, C: 1},
+		E:    common.FilePos{L: 1, C: 3},
+	}, result[0].Loc)
+	a.Equal(common.Location{
+		File: "file",
+		B:    common.FilePos{L: 1, C: 8},
+		E:    common.FilePos{L: 1, C: 9},
+	}, result[6].Loc)
+}
+
+func TestFStringEmpty(t *testing.T) {
+	a := assert.New(t)
+
+	result := lexFString("f''\n")
+
+	a.Equal([]*common.Symbol{
+		common.TokFStringStart,
+		common.TokFStringEnd,
+		common.TokNewline,
+		common.TokEOF,
+	}, tokSyms(result))
+	a.Equal(common.Location{
+		File: "file",
+		B:    common.FilePos{L: 1, C: 3},
+		E:    common.FilePos{L: 1, C: 4},
+	}, result[1].Loc)
+}
+
+func TestFStringEscapes(t *testing.T) {
+	a := assert.New(t)
+
+	result := lexFString("f'{{a}}\\t\\''\n")
+
+	a.Equal([]*common.Symbol{
+		common.TokFStringStart,
+		common.TokFStringPart,
+		common.TokFStringEnd,
+		common.TokNewline,
+		common.TokEOF,
+	}, tokSyms(result))
+	a.Equal("{a}\t'", result[1].Val)
+	a.Equal("{{a}}\\t\\'", result[1].Text)
+}
+
+func TestFStringRaw(t *testing.T) {
+	a := assert.New(t)
+
+	result := lexFString("rf'\\t{x}'\n")
+
+	a.Equal("\\t", result[1].Val)
+	a.Equal(common.TokFExprStart, result[2].Sym)
+}
+
+func TestFStringConversionSpec(t *testing.T) {
+	a := assert.New(t)
+
+	result := lexFString("f'{x!r:>{w}.2}'\n")
+
+	a.Equal([]*common.Symbol{
+		common.TokFStringStart,
+		common.TokFExprStart,
+		common.TokIdent,
+		common.TokFStringConv,
+		common.TokFStringSpec,
+		common.TokFExprStart,
+		common.TokIdent,
+		common.TokFExprEnd,
+		common.TokFStringSpec,
+		common.TokFExprEnd,
+		common.TokFStringEnd,
+		common.TokNewline,
+		common.TokEOF,
+	}, tokSyms(result))
+	a.Equal([]interface{}{nil, nil, "x", "r", ">", nil, "w", nil, ".2", nil, nil, nil, nil}, tokVals(result))
+	a.Equal(common.Location{
+		File: "file",
+		B:    common.FilePos{L: 1, C: 5},
+		E:    common.FilePos{L: 1, C: 7},
+	}, result[3].Loc)
+	a.Equal(common.Location{
+		File: "file",
+		B:    common.FilePos{L: 1, C: 7},
+		E:    common.FilePos{L: 1, C: 9},
+	}, result[4].Loc)
+}
+
+func TestFStringEmptySpec(t *testing.T) {
+	a := assert.New(t)
+
+	result := lexFString("f'{x:}'\n")
+
+	a.Equal(common.TokFStringSpec, result[3].Sym)
+	a.Equal("", result[3].Val)
+	a.Equal(":", result[3].Text)
+}
+
+func TestFStringNotEqual(t *testing.T) {
+	a := assert.New(t)
+
+	result := lexFString("f'{x!=y}'\n")
+
+	a.Equal([]interface{}{nil, nil, "x", "!=", "y", nil, nil, nil, nil}, tokVals(result))
+}
+
+func TestFStringBrackets(t *testing.T) {
+	a := assert.New(t)
+
+	result := lexFString("f'{ {x: y}[x] }'\n")
+
+	a.Equal([]*common.Symbol{
+		common.TokFStringStart,
+		common.TokFExprStart,
+		testOperators.Next('{').Sym,
+		common.TokIdent,
+		common.TokError,
+		common.TokIdent,
+		testOperators.Next('}').Sym,
+		testOperators.Next('[').Sym,
+		common.TokIdent,
+		testOperators.Next(']').Sym,
+		common.TokFExprEnd,
+		common.TokFStringEnd,
+		common.TokNewline,
+		common.TokEOF,
+	}, tokSyms(result))
+	a.Equal(common.CodeBadOp, result[4].Val.(*common.Diagnostic).Code)
+}
+
+func TestFStringNestedQuotes(t *testing.T) {
+	a := assert.New(t)
+
+	result := lexFString("f'{f'{x}' + 'y'}'\n")
+
+	a.Equal([]*common.Symbol{
+		common.TokFStringStart,
+		common.TokFExprStart,
+		common.TokFStringStart,
+		common.TokFExprStart,
+		common.TokIdent,
+		common.TokFExprEnd,
+		common.TokFStringEnd,
+		testOperators.Next('+').Sym,
+		common.TokString,
+		common.TokFExprEnd,
+		common.TokFStringEnd,
+		common.TokNewline,
+		common.TokEOF,
+	}, tokSyms(result))
+}
+
+func TestFStringMultiLine(t *testing.T) {
+	a := assert.New(t)
+
+	result := lexFString("x = f'''a''\n{y\n  + 1}'''\nz\n")
+
+	a.Equal([]*common.Symbol{
+		common.TokIdent,
+		testOperators.Next('=').Sym,
+		common.TokFStringStart,
+		common.TokFStringPart,
+		common.TokFExprStart,
+		common.TokIdent,
+		testOperators.Next('+').Sym,
+		common.TokInt,
+		common.TokFExprEnd,
+		common.TokFStringEnd,
+		common.TokNewline,
+		common.TokIdent,
+		common.TokNewline,
+		common.TokEOF,
+	}, tokSyms(result))
+	a.Equal("a''\n", result[3].Val)
+	a.Equal(common.Location{
+		File: "file",
+		B:    common.FilePos{L: 3, C: 7},
+		E:    common.FilePos{L: 3, C: 10},
+	}, result[9].Loc)
+}
+
+func TestFStringSingleBrace(t *testing.T) {
+	a := assert.New(t)
+
+	result := lexFString("f'a}b'\n")
+
+	a.Equal([]interface{}{nil, "a", common.CodeFStringBrace, "b", nil, nil, nil}, tokVals(result))
+	a.Equal(common.Location{
+		File: "file",
+		B:    common.FilePos{L: 1, C: 4},
+		E:    common.FilePos{L: 1, C: 5},
+	}, result[2].Loc)
+}
+
+func TestFStringSingleBraceHalts(t *testing.T) {
+	a := assert.New(t)
+	opts := makeFStringOptions("f'a}b'\n")
+	opts.Recover = false
+
+	result, err := All(opts)
+
+	a.Len(result, 2)
+	a.Equal(common.CodeFStringBrace, common.Code(err))
+}
+
+func TestFStringBadEscape(t *testing.T) {
+	a := assert.New(t)
+
+	result := lexFString("f'a\\qb'\n")
+
+	a.Equal([]interface{}{nil, "a", common.CodeBadEscape, "b", nil, nil, nil}, tokVals(result))
+	a.Equal(common.Location{
+		File: "file",
+		B:    common.FilePos{L: 1, C: 4},
+		E:    common.FilePos{L: 1, C: 6},
+	}, result[2].Loc)
+}
+
+func TestFStringBytes(t *testing.T) {
+	a := assert.New(t)
+
+	result := lexFString("bf'{x}'\n")
+
+	a.Equal([]interface{}{common.CodeFStringBytes, nil, nil}, tokVals(result))
+}
+
+func TestFStringUnclosed(t *testing.T) {
+	a := assert.New(t)
+
+	result := lexFString("f'abc\nx\n")
+
+	a.Equal([]interface{}{nil, "abc", common.CodeUnclosedStr, nil, "x", nil, nil}, tokVals(result))
+	a.Equal(common.Location{
+		File: "file",
+		B:    common.FilePos{L: 1, C: 6},
+		E:    common.FilePos{L: 2, C: 1},
+	}, result[2].Loc)
+}
+
+func TestFStringUnclosedSpec(t *testing.T) {
+	a := assert.New(t)
+
+	result := lexFString("f'{x:abc'\ny\n")
+
+	a.Equal([]interface{}{nil, nil, "x", "abc", common.CodeFStringField, nil, "y", nil, nil}, tokVals(result))
+	a.Equal(common.Location{
+		File: "file",
+		B:    common.FilePos{L: 1, C: 9},
+		E:    common.FilePos{L: 1, C: 10},
+	}, result[4].Loc)
+}
+
+func TestFStringUnclosedField(t *testing.T) {
+	a := assert.New(t)
+
+	result := lexFString("f'{x")
+
+	a.Equal([]interface{}{nil, nil, "x", common.CodeUnclosedOp, common.CodeUnclosedMode, nil}, tokVals(result))
+}
+
+func TestFStringUnclosedFieldQuote(t *testing.T) {
+	a := assert.New(t)
+
+	result := lexFString("f'{'\ny\n")
+
+	a.Equal([]interface{}{nil, nil, common.CodeFStringField, nil, nil, "y", nil, nil}, tokVals(result))
+	diag := result[2].Val.(*common.Diagnostic)
+	a.Equal(common.Location{
+		File: "file",
+		B:    common.FilePos{L: 1, C: 4},
+		E:    common.FilePos{L: 1, C: 5},
+	}, diag.Loc)
+	a.Equal(result[1].Loc, diag.Labels[0].Loc)
+	a.Equal([]common.Edit{common.Insert(diag.Loc, "}")}, diag.Fixes[0].Edits)
+}
+
+func TestFStringUnclosedFieldLine(t *testing.T) {
+	a := assert.New(t)
+
+	result := lexFString("f'{x'\ny = 1\nz = 2\n")
+
+	a.Equal(common.TokNewline, result[5].Sym)
+	a.Equal(2, result[6].Loc.B.L)
+	prev := common.Location{}
+	codes := []string{}
+	for _, tok := range result {
+		if tok.Sym == common.TokError {
+			diag := tok.Val.(*common.Diagnostic)
+			a.False(diag.Loc.Less(prev))
+			prev = diag.Loc
+			codes = append(codes, diag.Code)
+		}
+	}
+	a.Equal([]string{common.CodeBadIdent, common.CodeFStringField, common.CodeUnclosedStr}, codes)
+	a.Equal(common.TokEOF, result[len(result)-1].Sym)
+	a.Equal(4, result[len(result)-1].Loc.B.L)
+}
+
+func TestFStringUnclosedFieldNewline(t *testing.T) {
+	a := assert.New(t)
+
+	result := lexFString("f'{(x\ny\n")
+
+	a.Equal([]interface{}{nil, nil, "(", "x", common.CodeFStringField, common.CodeUnclosedStr, nil, "y", nil, nil}, tokVals(result))
+	a.Equal(1, result[4].Loc.B.L)
+}
+
+func TestFStringUnclosedFieldHalts(t *testing.T) {
+	a := assert.New(t)
+	opts := makeFStringOptions("f'{x\ny\n")
+	opts.Recover = false
+
+	result, err := All(opts)
+
+	a.Len(result, 3)
+	a.Equal(common.CodeFStringField, common.Code(err))
+}
+
+func TestFStringFieldMismatch(t *testing.T) {
+	a := assert.New(t)
+
+	result := lexFString("f'{x]'\n")
+
+	a.Equal(common.TokError, result[3].Sym)
+	a.EqualError(result[3].Val.(error), "close operator \"]\" does not match open operator \"{\" at file:1:3")
+}
+
+func TestFStringTrivia(t *testing.T) {
+	a := assert.New(t)
+	src := "f'  a {x!r:>{w}}  b'  # comment\n"
+	opts := makeFStringOptions(src)
+	opts.Trivia = true
+	l, _ := Lex(opts, nil)
+
+	text := &strings.Builder{}
+	for tok := l.Next(); tok != nil; tok = l.Next() {
+		text.WriteString(tok.FullText())
+	}
+
+	a.Equal(src, text.String())
+}
+
+func TestFStringSnapshot(t *testing.T) {
+	a := assert.New(t)
+	l, _ := Lex(makeFStringOptions("f'''a\n{x}'''\n"), nil)
+	l.Next()
+	l.Next()
+
+	result := l.Snapshot()
+
+	a.Equal([]*common.Mode{
+		fstringMode(fstring{q: '\'', flags: common.StrFormat | common.StrTriple | common.StrMulti}),
+		fieldMode,
+	}, result.Modes)
+	a.Equal(1, len(result.Pairs))
+}
@@ -30,10 +30,10 @@ type recognizeIdentifier struct {
 }
 
 // recogIdentifier constructs a recognizer for identifiers.
-func recogIdentifier(l *lexer) Recognizer {
+func recogIdentifier(lc common.LexContext) common.Recognizer {
 	return &recognizeIdentifier{
-		l: l,
-		s: recogString(l).(*recognizeString),
+		l: lc.(*lexer),
+		s: recogString(lc).(*recognizeString),
 	}
 }
 
@@ -74,6 +74,7 @@ func (r *recognizeIdentifier) Recognize(ch common.AugChar) {
 		} else if ch.Class&common.CharIDCont == 0 {
 			// Bad character
 			r.l.pushErr(ch.Loc, common.ErrBadIdent)
+			r.l.resync()
 			return
 		}
 
@@ -81,17 +82,74 @@ func (r *recognizeIdentifier) Recognize(ch common.AugChar) {
 		r.buf.WriteRune(ch.C)
 	}
 
-	// Push back last character retrieved
-	r.l.s.Push(ch)
-
-	// Get the identifier string
+	// Get the identifier string, fusing multi-word keywords; this
+	// also pushes back the last character retrieved
 	ident := string(r.l.opts.Prof.Norm.Bytes(r.buf.Bytes()))
+	ident, end := r.compound(ident, ch)
 
 	// See if it's a keyword
 	if sym, ok := r.l.opts.Prof.Keywords[ident]; ok {
-		r.l.pushTok(sym, r.loc.Thru(ch.Loc), ident)
+		r.l.pushTok(sym, r.loc.Thru(end), ident)
 	} else {
 		// Push the identifier
-		r.l.pushTok(common.TokIdent, r.loc.Thru(ch.Loc), ident)
+		r.l.pushTok(common.TokIdent, r.loc.Thru(end), ident)
 	}
 }
+
+// compound extends an identifier into the longest multi-word keyword
+// beginning with it, if any.  The words of a multi-word keyword may
+// be separated by any whitespace other than a newline.  It is passed
+// the character following the identifier, and returns the identifier
+// or keyword text and the location of the character following it.
+// All characters following the returned text are pushed back onto
+// the scanner.
+func (r *recognizeIdentifier) compound(ident string, ch common.AugChar) (string, common.Location) {
+	prof := r.l.opts.Prof
+	end := ch.Loc
+	chars := []common.AugChar{ch}
+	for words := ident; prof.ContinuesKeyword(words) && isSpace(ch); {
+		// Skip the whitespace
+		for isSpace(ch) {
+			ch = r.l.s.Next()
+			chars = append(chars, ch)
+		}
+
+		// Accumulate the next word
+		if ch.Class&common.CharIDStart == 0 {
+			break
+		}
+		buf := &bytes.Buffer{}
+		buf.WriteRune(ch.C)
+		for ch = r.l.s.Next(); ch.Class&common.CharIDCont != 0; ch = r.l.s.Next() {
+			chars = append(chars, ch)
+			buf.WriteRune(ch.C)
+		}
+		chars = append(chars, ch)
+
+		// The word must be followed by whitespace or an operator
+		if ch.Class != 0 && ch.Class&common.CharWS == 0 {
+			break
+		}
+
+		// See if the words so far make a keyword
+		words += " " + string(r.l.opts.Prof.Norm.Bytes(buf.Bytes()))
+		if _, ok := prof.Keywords[words]; ok {
+			ident = words
+			end = ch.Loc
+			chars = chars[len(chars)-1:]
+		}
+	}
+
+	// Push back the unused characters
+	for i := len(chars) - 1; i >= 0; i-- {
+		r.l.s.Push(chars[i])
+	}
+
+	return ident, end
+}
+
+// isSpace tests whether a character is whitespace other than a
+// newline.
+func isSpace(ch common.AugChar) bool {
+	return ch.Class&common.CharWS != 0 && ch.Class&common.CharNL == 0
+}
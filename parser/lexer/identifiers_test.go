@@ -15,6 +15,7 @@
 package lexer
 
 import (
+	"io"
 	"strings"
 	"testing"
 
@@ -24,8 +25,17 @@ import (
 	"github.com/hydralang/hydra/parser/scanner"
 )
 
+func makeCompoundOptions(src io.Reader) *common.Options {
+	opts := makeOptions(src)
+	opts.Prof = testProfile.Copy()
+	for _, name := range []string{"not", "in", "is", "not in", "is not", "is not x y"} {
+		opts.Prof.Keywords.Add(&common.Symbol{Name: name})
+	}
+	return opts
+}
+
 func TestRecognizeIdentifierImplementsRecognizer(t *testing.T) {
-	assert.Implements(t, (*Recognizer)(nil), &recognizeIdentifier{})
+	assert.Implements(t, (*common.Recognizer)(nil), &recognizeIdentifier{})
 }
 
 func TestRecogIdentifier(t *testing.T) {
@@ -232,7 +242,11 @@ func TestRecognizeIdentifierRecognizeErr(t *testing.T) {
 			B:    common.FilePos{L: 3, C: 2},
 			E:    common.FilePos{L: 3, C: 3},
 		},
-		Val: assert.AnError,
+		Val: common.Diagnose(assert.AnError, common.Location{
+			File: "file",
+			B:    common.FilePos{L: 3, C: 2},
+			E:    common.FilePos{L: 3, C: 3},
+		}),
 	}, l.tokens.Front().Value.(*common.Token))
 }
 
@@ -262,6 +276,261 @@ func TestRecognizeIdentifierRecognizeBadIdent(t *testing.T) {
 			B:    common.FilePos{L: 1, C: 5},
 			E:    common.FilePos{L: 1, C: 6},
 		},
-		Val: common.ErrBadIdent,
+		Val: common.Diagnose(common.ErrBadIdent, common.Location{
+			File: "file",
+			B:    common.FilePos{L: 1, C: 5},
+			E:    common.FilePos{L: 1, C: 6},
+		}),
+	}, l.tokens.Front().Value.(*common.Token))
+}
+
+func TestRecognizeIdentifierRecognizeCompound(t *testing.T) {
+	a := assert.New(t)
+	opts := makeCompoundOptions(strings.NewReader("not \t in x"))
+	s, _ := scanner.Scan(opts)
+	l := &lexer{
+		s:    s,
+		opts: opts,
+	}
+	l.indent.PushBack(1)
+	r := &recognizeIdentifier{
+		l: l,
+		s: recogString(l).(*recognizeString),
+	}
+	ch := l.s.Next()
+
+	r.Recognize(ch)
+
+	a.Equal(1, l.tokens.Len())
+	a.Equal(&common.Token{
+		Sym: opts.Prof.Keywords["not in"],
+		Loc: common.Location{
+			File: "file",
+			B:    common.FilePos{L: 1, C: 1},
+			E:    common.FilePos{L: 1, C: 12},
+		},
+		Val: "not in",
 	}, l.tokens.Front().Value.(*common.Token))
+	ch = s.Next()
+	a.Equal(' ', ch.C)
+	a.Equal(common.FilePos{L: 1, C: 12}, ch.Loc.B)
+}
+
+func TestRecognizeIdentifierRecognizeCompoundNewline(t *testing.T) {
+	a := assert.New(t)
+	opts := makeCompoundOptions(strings.NewReader("not\nin"))
+	s, _ := scanner.Scan(opts)
+	l := &lexer{
+		s:    s,
+		opts: opts,
+	}
+	l.indent.PushBack(1)
+	r := &recognizeIdentifier{
+		l: l,
+		s: recogString(l).(*recognizeString),
+	}
+	ch := l.s.Next()
+
+	r.Recognize(ch)
+
+	a.Equal(1, l.tokens.Len())
+	a.Equal(&common.Token{
+		Sym: opts.Prof.Keywords["not"],
+		Loc: common.Location{
+			File: "file",
+			B:    common.FilePos{L: 1, C: 1},
+			E:    common.FilePos{L: 1, C: 4},
+		},
+		Val: "not",
+	}, l.tokens.Front().Value.(*common.Token))
+	ch = s.Next()
+	a.Equal('\n', ch.C)
+	a.Equal(common.FilePos{L: 1, C: 4}, ch.Loc.B)
+}
+
+func TestRecognizeIdentifierRecognizeCompoundNoMatch(t *testing.T) {
+	a := assert.New(t)
+	opts := makeCompoundOptions(strings.NewReader("not x"))
+	s, _ := scanner.Scan(opts)
+	l := &lexer{
+		s:    s,
+		opts: opts,
+	}
+	l.indent.PushBack(1)
+	r := &recognizeIdentifier{
+		l: l,
+		s: recogString(l).(*recognizeString),
+	}
+	ch := l.s.Next()
+
+	r.Recognize(ch)
+
+	a.Equal(1, l.tokens.Len())
+	a.Equal(&common.Token{
+		Sym: opts.Prof.Keywords["not"],
+		Loc: common.Location{
+			File: "file",
+			B:    common.FilePos{L: 1, C: 1},
+			E:    common.FilePos{L: 1, C: 4},
+		},
+		Val: "not",
+	}, l.tokens.Front().Value.(*common.Token))
+	ch = s.Next()
+	a.Equal(' ', ch.C)
+	a.Equal(common.FilePos{L: 1, C: 4}, ch.Loc.B)
+}
+
+func TestRecognizeIdentifierRecognizeCompoundNotWord(t *testing.T) {
+	a := assert.New(t)
+	opts := makeCompoundOptions(strings.NewReader("not (in)"))
+	s, _ := scanner.Scan(opts)
+	l := &lexer{
+		s:    s,
+		opts: opts,
+	}
+	l.indent.PushBack(1)
+	r := &recognizeIdentifier{
+		l: l,
+		s: recogString(l).(*recognizeString),
+	}
+	ch := l.s.Next()
+
+	r.Recognize(ch)
+
+	a.Equal(1, l.tokens.Len())
+	a.Equal(&common.Token{
+		Sym: opts.Prof.Keywords["not"],
+		Loc: common.Location{
+			File: "file",
+			B:    common.FilePos{L: 1, C: 1},
+			E:    common.FilePos{L: 1, C: 4},
+		},
+		Val: "not",
+	}, l.tokens.Front().Value.(*common.Token))
+	ch = s.Next()
+	a.Equal(' ', ch.C)
+	a.Equal(common.FilePos{L: 1, C: 4}, ch.Loc.B)
+}
+
+func TestRecognizeIdentifierRecognizeCompoundBadTerminator(t *testing.T) {
+	a := assert.New(t)
+	opts := makeCompoundOptions(strings.NewReader("not in'x'"))
+	s, _ := scanner.Scan(opts)
+	l := &lexer{
+		s:    s,
+		opts: opts,
+	}
+	l.indent.PushBack(1)
+	r := &recognizeIdentifier{
+		l: l,
+		s: recogString(l).(*recognizeString),
+	}
+	ch := l.s.Next()
+
+	r.Recognize(ch)
+
+	a.Equal(1, l.tokens.Len())
+	a.Equal(&common.Token{
+		Sym: opts.Prof.Keywords["not"],
+		Loc: common.Location{
+			File: "file",
+			B:    common.FilePos{L: 1, C: 1},
+			E:    common.FilePos{L: 1, C: 4},
+		},
+		Val: "not",
+	}, l.tokens.Front().Value.(*common.Token))
+	ch = s.Next()
+	a.Equal(' ', ch.C)
+	a.Equal(common.FilePos{L: 1, C: 4}, ch.Loc.B)
+}
+
+func TestRecognizeIdentifierRecognizeCompoundLongest(t *testing.T) {
+	a := assert.New(t)
+	opts := makeCompoundOptions(strings.NewReader("is not x y"))
+	s, _ := scanner.Scan(opts)
+	l := &lexer{
+		s:    s,
+		opts: opts,
+	}
+	l.indent.PushBack(1)
+	r := &recognizeIdentifier{
+		l: l,
+		s: recogString(l).(*recognizeString),
+	}
+	ch := l.s.Next()
+
+	r.Recognize(ch)
+
+	a.Equal(1, l.tokens.Len())
+	a.Equal(&common.Token{
+		Sym: opts.Prof.Keywords["is not x y"],
+		Loc: common.Location{
+			File: "file",
+			B:    common.FilePos{L: 1, C: 1},
+			E:    common.FilePos{L: 1, C: 11},
+		},
+		Val: "is not x y",
+	}, l.tokens.Front().Value.(*common.Token))
+	ch = s.Next()
+	a.Equal(common.EOF, ch.C)
+	a.Equal(common.FilePos{L: 1, C: 11}, ch.Loc.B)
+}
+
+func TestRecognizeIdentifierRecognizeCompoundBacktrack(t *testing.T) {
+	a := assert.New(t)
+	opts := makeCompoundOptions(strings.NewReader("is not x z"))
+	s, _ := scanner.Scan(opts)
+	l := &lexer{
+		s:    s,
+		opts: opts,
+	}
+	l.indent.PushBack(1)
+	r := &recognizeIdentifier{
+		l: l,
+		s: recogString(l).(*recognizeString),
+	}
+	ch := l.s.Next()
+
+	r.Recognize(ch)
+
+	a.Equal(1, l.tokens.Len())
+	a.Equal(&common.Token{
+		Sym: opts.Prof.Keywords["is not"],
+		Loc: common.Location{
+			File: "file",
+			B:    common.FilePos{L: 1, C: 1},
+			E:    common.FilePos{L: 1, C: 7},
+		},
+		Val: "is not",
+	}, l.tokens.Front().Value.(*common.Token))
+	ch = s.Next()
+	a.Equal(' ', ch.C)
+	a.Equal(common.FilePos{L: 1, C: 7}, ch.Loc.B)
+}
+
+func TestIsSpaceTrue(t *testing.T) {
+	a := assert.New(t)
+	ch := common.AugChar{C: '\t', Class: common.CharWS}
+
+	result := isSpace(ch)
+
+	a.True(result)
+}
+
+func TestIsSpaceNewline(t *testing.T) {
+	a := assert.New(t)
+	ch := common.AugChar{C: '\n', Class: common.CharWS | common.CharNL}
+
+	result := isSpace(ch)
+
+	a.False(result)
+}
+
+func TestIsSpaceOther(t *testing.T) {
+	a := assert.New(t)
+	ch := common.AugChar{C: 'a', Class: common.CharIDStart | common.CharIDCont}
+
+	result := isSpace(ch)
+
+	a.False(result)
 }
@@ -0,0 +1,312 @@
+// Copyright (c) 2019 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package lexer
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+
+	"github.com/hydralang/hydra/parser/common"
+)
+
+// restart describes a safe restart point for incremental lexing: the
+// beginning of a line following a newline token, which is therefore
+// outside of any string or pair of operators.
+type restart struct {
+	off   int              // Byte offset of the line in the text
+	tok   int              // Index of the first token after the point
+	state *common.LexState // State of the lexer at the point
+}
+
+// Change describes the effect of an edit on the token list of an
+// IncrementalLexer.  The tokens from Start to Start+Removed in the
+// old token list were replaced by the tokens from Start to
+// Start+Added in the new token list.
+type Change struct {
+	Start   int // Index of the first changed token
+	Removed int // Number of tokens removed
+	Added   int // Number of tokens added
+}
+
+// IncrementalLexer holds the token list for a document, and updates
+// it as the document is edited.  Rather than lexing the entire
+// document after each edit, it re-lexes from the nearest safe restart
+// point preceding the edit, stopping when the lexer's state at the
+// beginning of a line following the edit matches its state at the
+// same line before the edit.  The document should use a consistent
+// line ending style.
+type IncrementalLexer struct {
+	opts   common.Options  // The options for the lexer
+	text   []byte          // The text of the document
+	lines  []int           // Byte offsets of the lines of the text
+	toks   []*common.Token // The tokens of the document
+	points []restart       // The restart points, in order
+}
+
+// Incremental lexes a document, given as UTF-8 text, and returns an
+// IncrementalLexer which may be used to update the tokens as the
+// document is edited.  The options describe the document, except
+// that the Source, Encoding, and Start are ignored, and trivia is not
+// preserved.  Error recovery should generally be enabled, so that
+// the tokens following an error are available.
+func Incremental(opts *common.Options, text []byte) (*IncrementalLexer, error) {
+	il := &IncrementalLexer{
+		opts:  *opts,
+		text:  text,
+		lines: lineStarts(text),
+	}
+	il.opts.Encoding = "utf-8"
+	il.opts.Start = common.FilePos{}
+	il.opts.Trivia = false
+
+	// Lex the whole document
+	toks, points, _, _, err := il.lex(restart{}, nil)
+	if err != nil {
+		return nil, err
+	}
+	il.toks = toks
+	il.points = points
+
+	return il, nil
+}
+
+// Text returns the text of the document.
+func (il *IncrementalLexer) Text() []byte {
+	return il.text
+}
+
+// Tokens returns the tokens of the document.
+func (il *IncrementalLexer) Tokens() []*common.Token {
+	return il.toks
+}
+
+// lineStarts computes the byte offsets of the beginning of each line
+// of the text.  This follows the scanner in using the first line
+// ending to select the line ending style.
+func lineStarts(text []byte) []int {
+	lines := []int{0}
+	var style byte
+	for i, c := range text {
+		if c != '\r' && c != '\n' {
+			continue
+		}
+
+		// Select the style
+		if style == 0 {
+			style = c
+			if c == '\r' && i+1 < len(text) && text[i+1] == '\n' {
+				style = 'b'
+			}
+		}
+
+		// Is it a line ending?
+		if c == style || style == 'b' && c == '\n' {
+			lines = append(lines, i+1)
+		}
+	}
+
+	return lines
+}
+
+// lex lexes the document from a restart point, which is the zero
+// restart for the beginning of the document.  It returns the tokens
+// and the restart points following that point.  If the sync function
+// is not nil, it is called for each restart point, and lexing stops
+// if it returns true; in that case, the restart point is returned
+// separately, along with true.
+func (il *IncrementalLexer) lex(from restart, sync func(pt restart) bool) ([]*common.Token, []restart, restart, bool, error) {
+	// Set up the lexer
+	opts := il.opts
+	opts.Source = bytes.NewReader(il.text[from.off:])
+	if from.state != nil {
+		opts.Start = from.state.Pos
+	}
+	l, err := Lex(&opts, nil)
+	if err != nil {
+		return nil, nil, restart{}, false, err
+	}
+
+	// Restore its state
+	points := []restart{}
+	if from.state != nil {
+		if err := l.Restore(from.state); err != nil {
+			return nil, nil, restart{}, false, err
+		}
+	} else {
+		points = append(points, restart{state: l.Snapshot()})
+	}
+
+	// Collect the tokens, noting the restart points
+	toks := []*common.Token{}
+	for tok := l.Next(); tok != nil; tok = l.Next() {
+		toks = append(toks, tok)
+		if tok.Sym != common.TokNewline {
+			continue
+		}
+
+		// Find the beginning of the next line
+		state := l.Snapshot()
+		if state.Pos.C != 1 || state.Pos.L > len(il.lines) {
+			continue
+		}
+		pt := restart{
+			off:   il.lines[state.Pos.L-1],
+			tok:   from.tok + len(toks),
+			state: state,
+		}
+
+		// Are we back in sync?
+		if sync != nil && sync(pt) {
+			return toks, points, pt, true, nil
+		}
+		points = append(points, pt)
+	}
+
+	return toks, points, restart{}, false, nil
+}
+
+// sameTok tests whether two tokens are the same, including their
+// locations and source text.
+func sameTok(a, b *common.Token) bool {
+	return a.Sym == b.Sym && a.Loc == b.Loc && a.Text == b.Text && reflect.DeepEqual(a.Val, b.Val)
+}
+
+// shiftLoc shifts a location by the specified number of lines, if it
+// is not before the specified line.
+func shiftLoc(loc *common.Location, line, delta int) {
+	if loc.B.L >= line {
+		loc.B.L += delta
+		loc.E.L += delta
+	}
+}
+
+// shiftTok shifts the locations in a token by the specified number of
+// lines, if they are not before the specified line.  This includes
+// the locations of an error token's diagnostic.
+func shiftTok(tok *common.Token, line, delta int) {
+	shiftLoc(&tok.Loc, line, delta)
+
+	// Shift the diagnostic as well
+	diag, ok := tok.Val.(*common.Diagnostic)
+	if !ok {
+		return
+	}
+	shiftLoc(&diag.Loc, line, delta)
+	for i := range diag.Labels {
+		shiftLoc(&diag.Labels[i].Loc, line, delta)
+	}
+	for i := range diag.Fixes {
+		for j := range diag.Fixes[i].Edits {
+			shiftLoc(&diag.Fixes[i].Edits[j].Loc, line, delta)
+		}
+	}
+}
+
+// Edit applies an edit to the document, replacing the text between
+// the start and end byte offsets with the replacement text, and
+// updates the tokens.  The tokens following the changed range are
+// the same tokens as before the edit, with their locations adjusted.
+// Returns a Change describing the changed range of tokens, or
+// common.ErrBadEdit if the offsets are not within the document.
+func (il *IncrementalLexer) Edit(start, end int, repl []byte) (Change, error) {
+	if start < 0 || end < start || end > len(il.text) {
+		return Change{}, common.ErrBadEdit
+	}
+
+	// Find the last restart point before the edit
+	i := sort.Search(len(il.points), func(i int) bool {
+		return il.points[i].off >= start
+	}) - 1
+	if i < 0 {
+		i = 0
+	}
+	from := il.points[i]
+
+	// Apply the edit to the text
+	text := make([]byte, 0, len(il.text)-(end-start)+len(repl))
+	text = append(text, il.text[:start]...)
+	text = append(text, repl...)
+	text = append(text, il.text[end:]...)
+	il.text = text
+	il.lines = lineStarts(text)
+
+	// Re-lex until a restart point after the edit matches the
+	// corresponding restart point from before the edit
+	delta := len(repl) - (end - start)
+	j := 0
+	toks, points, pt, synced, err := il.lex(from, func(pt restart) bool {
+		if pt.off < start+len(repl) {
+			return false
+		}
+
+		oldOff := pt.off - delta
+		j = sort.Search(len(il.points), func(j int) bool {
+			return il.points[j].off >= oldOff
+		})
+		return j < len(il.points) && il.points[j].off == oldOff && il.points[j].state.Matches(pt.state)
+	})
+	if err != nil {
+		return Change{}, err
+	}
+
+	// Determine the replaced tokens
+	oldEnd := len(il.toks)
+	if synced {
+		oldEnd = il.points[j].tok
+	}
+	old := il.toks[from.tok:oldEnd]
+
+	// Keep the unchanged tokens at the beginning
+	k := 0
+	for k < len(toks) && k < len(old) && sameTok(toks[k], old[k]) {
+		toks[k] = old[k]
+		k++
+	}
+	change := Change{
+		Start:   from.tok + k,
+		Removed: len(old) - k,
+		Added:   len(toks) - k,
+	}
+
+	// Splice in the new tokens and restart points
+	newToks := append([]*common.Token{}, il.toks[:from.tok]...)
+	newToks = append(newToks, toks...)
+	newPoints := append([]restart{}, il.points[:i+1]...)
+	newPoints = append(newPoints, points...)
+	if synced {
+		// Adjust the remaining tokens and restart points
+		line := il.points[j].state.Pos.L
+		lineDelta := pt.state.Pos.L - line
+		tokDelta := len(toks) - len(old)
+		for _, tok := range il.toks[oldEnd:] {
+			if lineDelta != 0 {
+				shiftTok(tok, line, lineDelta)
+			}
+			newToks = append(newToks, tok)
+		}
+		newPoints = append(newPoints, pt)
+		for _, old := range il.points[j+1:] {
+			old.off += delta
+			old.tok += tokDelta
+			old.state.Pos.L += lineDelta
+			newPoints = append(newPoints, old)
+		}
+	}
+	il.toks = newToks
+	il.points = newPoints
+
+	return change, nil
+}
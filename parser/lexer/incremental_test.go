@@ -0,0 +1,273 @@
+// Copyright (c) 2019 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package lexer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hydralang/hydra/parser/common"
+)
+
+func makeIncrementalOptions() *common.Options {
+	opts := makeOptions(nil)
+	opts.Recover = true
+	return opts
+}
+
+func assertRelexed(a *assert.Assertions, il *IncrementalLexer) {
+	opts := makeIncrementalOptions()
+	opts.Source = bytes.NewReader(il.Text())
+	l, _ := Lex(opts, nil)
+	expected := []*common.Token{}
+	for tok := l.Next(); tok != nil; tok = l.Next() {
+		expected = append(expected, tok)
+	}
+
+	toks := il.Tokens()
+	a.Equal(len(expected), len(toks))
+	for i := 0; i < len(expected) && i < len(toks); i++ {
+		a.Equal(expected[i].Sym, toks[i].Sym, "token %d", i)
+		a.Equal(expected[i].Loc, toks[i].Loc, "token %d", i)
+		a.Equal(expected[i].Text, toks[i].Text, "token %d", i)
+		a.Equal(expected[i].Val, toks[i].Val, "token %d", i)
+	}
+}
+
+func TestLineStartsNewline(t *testing.T) {
+	a := assert.New(t)
+
+	result := lineStarts([]byte("ab\ncd\r\nef\n"))
+
+	a.Equal([]int{0, 3, 7, 10}, result)
+}
+
+func TestLineStartsCarriage(t *testing.T) {
+	a := assert.New(t)
+
+	result := lineStarts([]byte("ab\rcd\ref\n"))
+
+	a.Equal([]int{0, 3, 6}, result)
+}
+
+func TestLineStartsBoth(t *testing.T) {
+	a := assert.New(t)
+
+	result := lineStarts([]byte("ab\r\ncd\ref\ngh"))
+
+	a.Equal([]int{0, 4, 10}, result)
+}
+
+func TestLineStartsNone(t *testing.T) {
+	a := assert.New(t)
+
+	result := lineStarts([]byte("ab"))
+
+	a.Equal([]int{0}, result)
+}
+
+func TestIncremental(t *testing.T) {
+	a := assert.New(t)
+
+	result, err := Incremental(makeIncrementalOptions(), []byte("a = b\nc = d\n\ne = f\n"))
+
+	a.NoError(err)
+	assertRelexed(a, result)
+	a.Equal([]byte("a = b\nc = d\n\ne = f\n"), result.Text())
+	a.Len(result.points, 4)
+	a.Equal(0, result.points[0].off)
+	a.Equal(0, result.points[0].tok)
+	a.Equal(6, result.points[1].off)
+	a.Equal(4, result.points[1].tok)
+	a.Equal(12, result.points[2].off)
+	a.Equal(8, result.points[2].tok)
+	a.Equal(19, result.points[3].off)
+	a.Equal(12, result.points[3].tok)
+}
+
+func TestIncrementalIgnoresEncoding(t *testing.T) {
+	a := assert.New(t)
+	opts := makeIncrementalOptions()
+	opts.Encoding = "no-such-encoding"
+
+	result, err := Incremental(opts, []byte("a = b\n"))
+
+	a.NoError(err)
+	assertRelexed(a, result)
+}
+
+func TestIncrementalEditInLine(t *testing.T) {
+	a := assert.New(t)
+	il, _ := Incremental(makeIncrementalOptions(), []byte("a = b\nc = d\ne = f\n"))
+	tail := il.Tokens()[8]
+
+	result, err := il.Edit(6, 7, []byte("xy"))
+
+	a.NoError(err)
+	a.Equal(Change{Start: 4, Removed: 4, Added: 4}, result)
+	a.Equal([]byte("a = b\nxy = d\ne = f\n"), il.Text())
+	assertRelexed(a, il)
+	a.True(tail == il.Tokens()[8])
+	a.Equal(13, il.points[2].off)
+}
+
+func TestIncrementalEditInsertLines(t *testing.T) {
+	a := assert.New(t)
+	il, _ := Incremental(makeIncrementalOptions(), []byte("a = b\nc = d\ne = f\n"))
+	tail := il.Tokens()[8]
+
+	result, err := il.Edit(6, 6, []byte("g = h\ni\n"))
+
+	a.NoError(err)
+	a.Equal(Change{Start: 4, Removed: 0, Added: 6}, result)
+	assertRelexed(a, il)
+	a.True(tail == il.Tokens()[14])
+	a.Equal(common.FilePos{L: 5, C: 1}, tail.Loc.B)
+	a.Len(il.points, 6)
+}
+
+func TestIncrementalEditDeleteLines(t *testing.T) {
+	a := assert.New(t)
+	il, _ := Incremental(makeIncrementalOptions(), []byte("a = b\nc = d\ne = f\ng\n"))
+
+	result, err := il.Edit(4, 16, nil)
+
+	a.NoError(err)
+	a.Equal([]byte("a = f\ng\n"), il.Text())
+	a.Equal(Change{Start: 2, Removed: 10, Added: 2}, result)
+	assertRelexed(a, il)
+}
+
+func TestIncrementalEditOpenPair(t *testing.T) {
+	a := assert.New(t)
+	il, _ := Incremental(makeIncrementalOptions(), []byte("a = b\nc = d\ne = f)\ng\nh\n"))
+
+	result, err := il.Edit(10, 10, []byte("("))
+
+	a.NoError(err)
+	a.Equal([]byte("a = b\nc = (d\ne = f)\ng\nh\n"), il.Text())
+	a.Equal(6, result.Start)
+	assertRelexed(a, il)
+}
+
+func TestIncrementalEditIndent(t *testing.T) {
+	a := assert.New(t)
+	il, _ := Incremental(makeIncrementalOptions(), []byte("a\n  b\n  c\nd\ne\n"))
+
+	_, err := il.Edit(6, 6, []byte("  "))
+
+	a.NoError(err)
+	a.Equal([]byte("a\n  b\n    c\nd\ne\n"), il.Text())
+	assertRelexed(a, il)
+}
+
+func TestIncrementalEditString(t *testing.T) {
+	a := assert.New(t)
+	il, _ := Incremental(makeIncrementalOptions(), []byte("a = b\nc = d\ne = '''f\ng\n"))
+
+	_, err := il.Edit(10, 11, []byte("'''"))
+
+	a.NoError(err)
+	a.Equal([]byte("a = b\nc = '''\ne = '''f\ng\n"), il.Text())
+	assertRelexed(a, il)
+}
+
+func TestIncrementalEditStart(t *testing.T) {
+	a := assert.New(t)
+	il, _ := Incremental(makeIncrementalOptions(), []byte("a = b\nc = d\n"))
+
+	result, err := il.Edit(0, 1, []byte("  x"))
+
+	a.NoError(err)
+	a.Equal(0, result.Start)
+	assertRelexed(a, il)
+}
+
+func TestIncrementalEditEnd(t *testing.T) {
+	a := assert.New(t)
+	il, _ := Incremental(makeIncrementalOptions(), []byte("a = b\nc = d\n"))
+
+	result, err := il.Edit(12, 12, []byte("e\n"))
+
+	a.NoError(err)
+	a.Equal(Change{Start: 8, Removed: 0, Added: 2}, result)
+	assertRelexed(a, il)
+}
+
+func TestIncrementalEditSequence(t *testing.T) {
+	a := assert.New(t)
+	il, _ := Incremental(makeIncrementalOptions(), []byte("a = b\nc = d\ne = f\n"))
+
+	il.Edit(6, 6, []byte("x = (\n"))
+	il.Edit(12, 12, []byte("y)\n"))
+	il.Edit(0, 2, nil)
+	_, err := il.Edit(3, 9, []byte("z\n  w\n"))
+
+	a.NoError(err)
+	assertRelexed(a, il)
+}
+
+func TestIncrementalEditBad(t *testing.T) {
+	a := assert.New(t)
+	il, _ := Incremental(makeIncrementalOptions(), []byte("a = b\n"))
+
+	_, err := il.Edit(3, 7, nil)
+
+	a.Equal(common.ErrBadEdit, err)
+	a.Equal([]byte("a = b\n"), il.Text())
+}
+
+func TestIncrementalEditCRLF(t *testing.T) {
+	a := assert.New(t)
+	il, _ := Incremental(makeIncrementalOptions(), []byte("a = b\r\nc = d\r\ne = f\r\n"))
+
+	result, err := il.Edit(7, 8, []byte("x\r\ny"))
+
+	a.NoError(err)
+	a.Equal(4, result.Start)
+	assertRelexed(a, il)
+	a.Equal(common.FilePos{L: 4, C: 1}, il.Tokens()[len(il.Tokens())-5].Loc.B)
+}
+
+func TestShiftTok(t *testing.T) {
+	a := assert.New(t)
+	tok := &common.Token{
+		Sym: common.TokError,
+		Loc: common.Location{B: common.FilePos{L: 5, C: 2}, E: common.FilePos{L: 5, C: 3}},
+		Val: &common.Diagnostic{
+			Loc: common.Location{B: common.FilePos{L: 5, C: 2}, E: common.FilePos{L: 5, C: 3}},
+			Labels: []common.Label{
+				{Loc: common.Location{B: common.FilePos{L: 2, C: 1}, E: common.FilePos{L: 2, C: 2}}},
+				{Loc: common.Location{B: common.FilePos{L: 4, C: 1}, E: common.FilePos{L: 4, C: 2}}},
+			},
+			Fixes: []common.Fix{
+				{Edits: []common.Edit{
+					{Loc: common.Location{B: common.FilePos{L: 6, C: 1}, E: common.FilePos{L: 6, C: 1}}},
+				}},
+			},
+		},
+	}
+
+	shiftTok(tok, 3, 2)
+
+	a.Equal(common.Location{B: common.FilePos{L: 7, C: 2}, E: common.FilePos{L: 7, C: 3}}, tok.Loc)
+	diag := tok.Val.(*common.Diagnostic)
+	a.Equal(common.Location{B: common.FilePos{L: 7, C: 2}, E: common.FilePos{L: 7, C: 3}}, diag.Loc)
+	a.Equal(common.FilePos{L: 2, C: 1}, diag.Labels[0].Loc.B)
+	a.Equal(common.FilePos{L: 6, C: 1}, diag.Labels[1].Loc.B)
+	a.Equal(common.FilePos{L: 8, C: 1}, diag.Fixes[0].Edits[0].Loc.B)
+}
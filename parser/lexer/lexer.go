@@ -22,14 +22,25 @@
 // semantic value).
 //
 // To perform its work, the lexer relies on recognizers, which
-// implement the Recognizer interface defined in recognizers.go.  This
-// vastly simplifies the task of unit testing the lexer by allowing
-// the code that recognizes individual token types to be mocked out
-// for the testing, and allows the recognizers to be handled in
-// isolation.  The specific structure of the breakdown is needed
-// because recognizers are not 100% isolated: a string with flags will
-// be passed through to the recognizer for identifiers, so it needs to
-// be able to interface with the recognizer for strings.
+// implement the Recognizer interface (see
+// hydra/parser/common.Recognizer).  This vastly simplifies the task
+// of unit testing the lexer by allowing the code that recognizes
+// individual token types to be mocked out for the testing, and allows
+// the recognizers to be handled in isolation.  The specific structure
+// of the breakdown is needed because recognizers are not 100%
+// isolated: a string with flags will be passed through to the
+// recognizer for identifiers, so it needs to be able to interface
+// with the recognizer for strings.  The recognizer for each token is
+// selected from a registry in the Profile, which defaults to the
+// built-in recognizers in recognizers.go; a dialect may add its own
+// recognizers to the registry, to add new token types without
+// modifying the lexer.  Recognizers interact with the lexer through
+// the LexContext interface.  A recognizer may also push a lexical
+// mode onto the lexer's mode stack, which is implemented in modes.go;
+// this allows nested constructs, such as interpolated strings, to be
+// lexed with their own recognizers and their own rules for newlines,
+// indentation, and whitespace.  Interpolated strings, or f-strings,
+// are lexed in this way by the recognizers in fstrings.go.
 //
 // The lexer is incredibly flexible, owing to the use of a Profile
 // (see hydra/parser/common.Profile).  This allows string flags,
@@ -39,33 +50,51 @@
 // versions of the Hydra language without needing to write a custom
 // lexer for each, or to introduce ad-hoc complications to the lexer
 // to accommodate them.
+//
+// The characters read from the scanner are recorded, so that each
+// token may be given its source text, as well as its semantic value;
+// this is implemented in trivia.go.  The lexer normally discards the
+// whitespace, comments, and line continuations between tokens, but
+// when trivia preservation is enabled in the options, each token is
+// also given its leading and trailing trivia.  The source may then be
+// reconstructed exactly from the tokens, which is needed by tools
+// such as formatters.
+//
+// Most callers can use the convenience functions in stream.go, which
+// lex a whole source into a slice of tokens, or stream its tokens
+// through a channel from a separate goroutine.  To lex many files at
+// once, driver.go provides a driver that lexes them on a pool of
+// worker goroutines sharing a single frozen Profile.  Editors, which
+// must keep the tokens of a document up to date as it is edited, can
+// use the IncrementalLexer in incremental.go, which re-lexes only the
+// part of the document affected by each edit.
 package lexer
 
 import (
 	"container/list"
+	"fmt"
+	"strings"
 
 	"github.com/hydralang/hydra/parser/common"
 	"github.com/hydralang/hydra/parser/scanner"
 )
 
-// Default recognizers.  Defining these as variables enables the
-// recognizers to be mocked out for testing purposes.
-var (
-	rComment RecogInit = recogComment
-	rNumber  RecogInit = recogNumber
-	rIdent   RecogInit = recogIdentifier
-	rString  RecogInit = recogString
-	rOp      RecogInit = recogOperator
-)
-
 // lexer is an implementation of Lexer.
 type lexer struct {
-	s       common.Scanner  // The scanner for the source
-	opts    *common.Options // The parser options
-	indent  list.List       // The indent stack
-	pair    list.List       // The pairing stack
-	tokens  list.List       // The token stack
-	prevTok *common.Token   // Last token returned by lexer
+	s       common.Scanner      // The scanner for the source
+	opts    *common.Options     // The parser options
+	indent  list.List           // The indent stack
+	pair    list.List           // The pairing stack
+	tokens  list.List           // The token stack
+	prevTok *common.Token       // Last token returned by lexer
+	recov   bool                // Recover from lexical errors
+	trivia  bool                // Preserve trivia on tokens
+	rec     *recorder           // Records source text for trivia
+	pend    *common.Token       // Token awaiting trivia attribution
+	recogs  *common.Recognizers // Registry of recognizers
+	modes   list.List           // The lexical mode stack
+	lead    string              // Leading whitespace of the line
+	leads   list.List           // Leading whitespace of indent levels
 }
 
 // Lex prepares a new lexer from the parser options and the scanner.
@@ -82,12 +111,20 @@ func Lex(opts *common.Options, s common.Scanner) (common.Lexer, error) {
 
 	// Construct the lexer object
 	l := &lexer{
-		s:    s,
-		opts: opts,
+		rec:    &recorder{s: s},
+		opts:   opts,
+		recov:  opts.Recover,
+		trivia: opts.Trivia,
 	}
 
+	// Record the source text of the tokens
+	l.s = l.rec
+
 	// Push the starting column onto the indent stack
 	l.indent.PushBack(1)
+	if l.policy() == common.IndentConsistent {
+		l.leads.PushBack("")
+	}
 
 	return l, nil
 }
@@ -95,124 +132,227 @@ func Lex(opts *common.Options, s common.Scanner) (common.Lexer, error) {
 // Next retrieves the next token from the scanner.  If the end of file
 // is reached, an EOF token is returned; if an error occurs while
 // scanning or lexically analyzing the file, an error token is
-// returned with the error as the token's semantic value.  After
-// either an EOF token or an error token, nil will be returned.
+// returned with a *Diagnostic describing the error as the token's
+// semantic value.  After either an EOF token or an error token, nil
+// will be returned, unless error recovery is enabled, in which case
+// tokens continue to be returned after an error token until the EOF
+// token.
 func (l *lexer) Next() *common.Token {
-	// Pump some tokens onto the token stack
-	for l.s != nil && l.tokens.Len() == 0 {
-		// Get a character from the scanner
-		ch := l.s.Next()
-
-		// Handle EOF and error
-		if ch.C == common.Err {
-			l.pushErr(ch.Loc, ch.Val.(error))
-			break
-		} else if ch.C == common.EOF {
-			// Warn about dangling pairs
-			if l.pair.Len() > 0 {
-				dangle := l.pair.Back().Value.(*common.Token)
-				l.pushErr(dangle.Loc, common.ErrDanglingOpen(dangle))
-				break
-			}
+	// Make sure there's a token available
+	l.fill(1)
 
-			l.pushTok(common.TokEOF, ch.Loc, nil)
-			l.s = nil
-			break
-		}
+	// If there are no tokens, return nil
+	if l.tokens.Len() == 0 {
+		return nil
+	}
 
-		// Handle newlines and whitespace
-		if ch.Class&common.CharNL != 0 && l.pair.Len() == 0 {
-			// Generate a newline token
-			l.pushTok(common.TokNewline, ch.Loc, nil)
-			continue
-		} else if ch.Class&common.CharWS != 0 {
-			// Are we concerned about mixed spaces?
-			errMixed := false
-
-			// Set up the skipSpaces flags
-			var skip uint8
-			if l.pair.Len() > 0 {
-				skip = SkipNL
-			} else {
-				prevTok := l.lastTok()
-				if prevTok == nil || prevTok.Sym == common.TokNewline {
-					skip = SkipLeadFF
-					errMixed = true
-				}
-			}
+	// Pop the first element off
+	elem := l.tokens.Front()
+	l.tokens.Remove(elem)
+
+	// Return the token, and save it so we know what we returned
+	// last
+	l.prevTok = elem.Value.(*common.Token)
+	return l.prevTok
+}
+
+// Peek retrieves a token from the lexer without consuming it.  The
+// argument is the number of tokens to look ahead; Peek(0) returns the
+// token that the next call to Next will return.  If there are not
+// enough tokens, such as when looking past an EOF token or an error
+// token, nil is returned.
+func (l *lexer) Peek(n int) *common.Token {
+	if n < 0 {
+		return nil
+	}
 
-			// Skip the whitespace
-			mixed := l.skipSpaces(ch, skip)
+	// Make sure enough tokens are available
+	l.fill(n + 1)
 
-			// Error out if it's mixed
-			if errMixed && mixed {
-				l.pushErr(ch.Loc, common.ErrMixedIndent)
-				break
+	// Find the token
+	elem := l.tokens.Front()
+	for ; elem != nil && n > 0; n-- {
+		elem = elem.Next()
+	}
+	if elem == nil {
+		return nil
+	}
+
+	return elem.Value.(*common.Token)
+}
+
+// ready counts the tokens on the token queue that may be returned.
+// When preserving trivia, a token can't be returned until the next
+// token is known, so the count excludes the token awaiting trivia
+// attribution and any tokens following it.
+func (l *lexer) ready() int {
+	if l.pend == nil {
+		return l.tokens.Len()
+	}
+
+	cnt := 0
+	for elem := l.tokens.Front(); elem != nil && elem.Value != l.pend; elem = elem.Next() {
+		cnt++
+	}
+
+	return cnt
+}
+
+// fill pumps tokens onto the token queue until at least the
+// specified number of tokens may be returned, or until there are no
+// more tokens.
+func (l *lexer) fill(n int) {
+	for l.s != nil && l.ready() < n {
+		l.pump()
+	}
+
+	// Attribute trivia to the final token
+	if l.s == nil && l.pend != nil {
+		l.attribute(l.pend, nil)
+		l.pend = nil
+	}
+}
+
+// pump reads a token from the scanner and pushes it onto the token
+// queue.  It may push more than one token, or none at all, such as
+// when skipping whitespace.
+func (l *lexer) pump() {
+	// Get a character from the scanner
+	ch := l.s.Next()
+
+	// Handle EOF and error
+	if ch.C == common.Err {
+		l.pushErr(ch.Loc, ch.Val.(error))
+		return
+	} else if ch.C == common.EOF {
+		// Warn about dangling pairs
+		if l.pair.Len() > 0 {
+			dangle := l.pair.Back().Value.(*common.Token)
+			l.pushErr(dangle.Loc, danglingOpen(dangle, ch.Loc))
+			if !l.recov {
+				return
 			}
 
-			// Loop back around
-			continue
+			// Recovering; report the remaining pairs
+			// and forget them
+			for elem := l.pair.Back().Prev(); elem != nil; elem = elem.Prev() {
+				dangle = elem.Value.(*common.Token)
+				l.pushErr(dangle.Loc, danglingOpen(dangle, ch.Loc))
+			}
+			l.pair.Init()
 		}
 
-		// Handle backslash continuation
-		if ch.C == '\\' {
-			// Get next character and make sure it's
-			// newline
-			ch = l.s.Next()
-			if ch.C == common.Err {
-				// Hmm, got an error
-				l.pushErr(ch.Loc, ch.Val.(error))
-				break
-			} else if ch.C != '\n' {
-				l.pushErr(ch.Loc, common.ErrDanglingBackslash)
-				break
+		// Report an unterminated mode
+		if l.modes.Len() > 0 {
+			mode := l.modes.Back().Value.(*common.Mode)
+			l.pushErr(ch.Loc, fmt.Errorf("%w %q", common.ErrUnclosedMode, mode))
+			if !l.recov {
+				return
 			}
 
-			// OK, continue to the next character
-			continue
+			// Recovering; forget the modes
+			l.modes.Init()
 		}
 
-		// Handle the case of ".n", where n is a decimal digit
-		if ch.C == '.' {
-			next := l.s.Next()
-			l.s.Push(next)
-			if next.Class&common.CharDecDigit != 0 {
-				// Suck in a number
-				rNumber(l).Recognize(ch)
-				continue
-			}
+		l.pushTok(common.TokEOF, ch.Loc, nil)
+		l.s = nil
+		return
+	}
+
+	// Handle whitespace, unless the mode passes it to the
+	// recognizers
+	if flags := l.modeFlags(); flags&common.ModeKeepWS == 0 && l.space(ch, flags) {
+		return
+	}
+
+	// Apply the correct recognizer
+	if entry := l.lookup(ch); entry != nil {
+		entry.Init(l).Recognize(ch)
+	} else {
+		l.pushErr(ch.Loc, common.ErrBadOp)
+	}
+}
+
+// lookup selects the entry for the recognizer that applies to a
+// character.  The registry of the active mode, if it has one, is
+// consulted first, followed by the registry for the lexer.  Returns
+// nil if no recognizer applies.
+func (l *lexer) lookup(ch common.AugChar) *common.RecogEntry {
+	if mode := l.Mode(); mode != nil && mode.Recognizers != nil {
+		if entry := mode.Recognizers.Lookup(ch, l.s); entry != nil {
+			return entry
 		}
+	}
+
+	return l.recognizers().Lookup(ch, l.s)
+}
 
-		// Apply the correct recognizer
-		if ch.Class&common.CharComment != 0 {
-			rComment(l).Recognize(ch)
-		} else if ch.Class&common.CharDecDigit != 0 {
-			rNumber(l).Recognize(ch)
-		} else if ch.Class&common.CharIDStart != 0 {
-			rIdent(l).Recognize(ch)
-		} else if ch.Class&common.CharQuote != 0 {
-			rString(l).Recognize(ch)
-		} else if ch.Class == 0 {
-			rOp(l).Recognize(ch)
+// recognizers returns the registry of recognizers for the lexer.
+// This is the registry from the profile, if it has one, or the
+// registry of built-in recognizers.
+func (l *lexer) recognizers() *common.Recognizers {
+	if l.recogs == nil {
+		if l.opts != nil && l.opts.Prof != nil && l.opts.Prof.Recognizers != nil {
+			l.recogs = l.opts.Prof.Recognizers
 		} else {
-			l.pushErr(ch.Loc, common.ErrBadOp)
-			break
+			l.recogs = DefaultRecognizers()
 		}
 	}
 
-	// If there are no tokens, return nil
-	if l.tokens.Len() == 0 {
-		return nil
+	return l.recogs
+}
+
+// danglingOpen constructs the error for a dangling open operator,
+// including a suggested fix that inserts the close operator at the
+// end of the file.
+func danglingOpen(tok *common.Token, eof common.Location) error {
+	diag := common.ErrDanglingOpen(tok)
+	diag.Fixes = []common.Fix{
+		{
+			Msg:   fmt.Sprintf("insert the missing \"%s\"", tok.Sym.Close),
+			Edits: []common.Edit{common.Insert(eof, tok.Sym.Close)},
+		},
 	}
+	return diag
+}
 
-	// Pop the first element off
-	elem := l.tokens.Front()
-	l.tokens.Remove(elem)
+// indentError constructs an error for the whitespace in an indent,
+// including a suggested fix that replaces the indent with spaces, or
+// with tabs if tabs is set and the indent is a whole number of tab
+// stops.  The whitespace must already have been skipped.
+func (l *lexer) indentError(err error, loc common.Location, tabs bool) error {
+	// Find the end of the indent
+	next := l.s.Next()
+	l.s.Push(next)
 
-	// Return the token, and save it so we know what we returned
-	// last
-	l.prevTok = elem.Value.(*common.Token)
-	return l.prevTok
+	// Select the replacement text
+	msg := "indent using spaces"
+	text := strings.Repeat(" ", next.Loc.B.C-1)
+	if tabs {
+		if l.opts == nil || (next.Loc.B.C-1)%l.opts.TabStop != 0 {
+			return common.Diagnose(err, loc)
+		}
+		msg = "indent using tabs"
+		text = strings.Repeat("\t", (next.Loc.B.C-1)/l.opts.TabStop)
+	}
+
+	diag := common.Diagnose(err, loc)
+	diag.Fixes = []common.Fix{
+		{
+			Msg: msg,
+			Edits: []common.Edit{
+				{
+					Loc: common.Location{
+						File: loc.File,
+						B:    common.FilePos{L: next.Loc.B.L, C: 1},
+						E:    next.Loc.B,
+					},
+					Text: text,
+				},
+			},
+		},
+	}
+	return diag
 }
 
 // Push pushes a single token back onto the lexer.  Any number of
@@ -221,3 +361,86 @@ func (l *lexer) Push(tok *common.Token) {
 	// Push the token onto the queue
 	l.tokens.PushFront(tok)
 }
+
+// peekPos returns the position of the next character to be lexed.
+func (l *lexer) peekPos() common.FilePos {
+	ch := l.rec.Next()
+	l.rec.Push(ch)
+
+	return ch.Loc.B
+}
+
+// Snapshot captures the state of the lexer, including the position
+// of the next character to lex.  Trivia that has not yet been
+// attributed to a token is not part of the state, so a lexer
+// restored from a snapshot taken while preserving trivia may produce
+// pending tokens without trivia.
+func (l *lexer) Snapshot() *common.LexState {
+	state := &common.LexState{Prev: l.prevTok}
+
+	// Find the position of the next character
+	if l.rec != nil {
+		state.Pos = l.peekPos()
+	}
+
+	// Copy the stacks and the token queue
+	for elem := l.indent.Front(); elem != nil; elem = elem.Next() {
+		state.Indent = append(state.Indent, elem.Value.(int))
+	}
+	for elem := l.leads.Front(); elem != nil; elem = elem.Next() {
+		state.Leads = append(state.Leads, elem.Value.(string))
+	}
+	for elem := l.pair.Front(); elem != nil; elem = elem.Next() {
+		state.Pairs = append(state.Pairs, elem.Value.(*common.Token))
+	}
+	for elem := l.modes.Front(); elem != nil; elem = elem.Next() {
+		state.Modes = append(state.Modes, elem.Value.(*common.Mode))
+	}
+	for elem := l.tokens.Front(); elem != nil; elem = elem.Next() {
+		state.Pending = append(state.Pending, elem.Value.(*common.Token))
+	}
+
+	return state
+}
+
+// Restore restores the lexer to a state captured by Snapshot.  The
+// next character of the lexer's source must be at the position of
+// the snapshot; typically, the lexer is constructed over the
+// remainder of the source, using the Start option to set its
+// position.  Returns an error wrapping common.ErrStatePos if the
+// positions do not match.  A lexer that has halted is resumed.
+func (l *lexer) Restore(state *common.LexState) error {
+	// Check the position of the next character
+	if l.rec != nil {
+		if pos := l.peekPos(); pos != state.Pos {
+			return fmt.Errorf("%w: expected %d:%d, found %d:%d", common.ErrStatePos, state.Pos.L, state.Pos.C, pos.L, pos.C)
+		}
+		l.s = l.rec
+	}
+
+	// Restore the stacks and the token queue
+	l.indent.Init()
+	for _, col := range state.Indent {
+		l.indent.PushBack(col)
+	}
+	l.leads.Init()
+	for _, lead := range state.Leads {
+		l.leads.PushBack(lead)
+	}
+	l.pair.Init()
+	for _, tok := range state.Pairs {
+		l.pair.PushBack(tok)
+	}
+	l.modes.Init()
+	for _, mode := range state.Modes {
+		l.modes.PushBack(mode)
+	}
+	l.tokens.Init()
+	for _, tok := range state.Pending {
+		l.tokens.PushBack(tok)
+	}
+	l.prevTok = state.Prev
+	l.pend = nil
+
+	return nil
+}
@@ -15,6 +15,9 @@
 package lexer
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
 	"io"
 	"strings"
 	"testing"
@@ -26,6 +29,7 @@ import (
 
 	"github.com/hydralang/hydra/parser/common"
 	"github.com/hydralang/hydra/parser/scanner"
+	"github.com/hydralang/hydra/testutils"
 )
 
 var (
@@ -132,10 +136,24 @@ func makeOptions(src io.Reader) *common.Options {
 	}
 }
 
+func makeWarnOptions(src io.Reader, warnings *[]*common.Diagnostic) *common.Options {
+	opts := makeOptions(src)
+	opts.Prof = testProfile.Copy()
+	opts.Prof.Lints = common.LintAll
+	opts.Warn = func(diag *common.Diagnostic) {
+		*warnings = append(*warnings, diag)
+	}
+	return opts
+}
+
 func TestLexerImplementsLexer(t *testing.T) {
 	assert.Implements(t, (*common.Lexer)(nil), &lexer{})
 }
 
+func TestLexerImplementsLexContext(t *testing.T) {
+	assert.Implements(t, (*common.LexContext)(nil), &lexer{})
+}
+
 func TestLexWithScanner(t *testing.T) {
 	a := assert.New(t)
 	opts := makeOptions(strings.NewReader("test"))
@@ -147,7 +165,8 @@ func TestLexWithScanner(t *testing.T) {
 	a.NotNil(result)
 	l, ok := result.(*lexer)
 	a.True(ok)
-	a.Equal(s, l.s)
+	a.Equal(s, l.rec.s)
+	a.Equal(l.rec, l.s)
 	a.Equal(opts, l.opts)
 	a.Equal(1, l.indent.Len())
 	a.Equal(1, l.indent.Front().Value.(int))
@@ -175,6 +194,19 @@ func TestLexWithoutScanner(t *testing.T) {
 	a.Nil(l.prevTok)
 }
 
+func TestLexWithTrivia(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("test"))
+	opts.Trivia = true
+
+	result, err := Lex(opts, nil)
+
+	a.NoError(err)
+	l, ok := result.(*lexer)
+	a.True(ok)
+	a.True(l.trivia)
+}
+
 func TestLexScannerError(t *testing.T) {
 	a := assert.New(t)
 	opts := makeOptions(strings.NewReader("test"))
@@ -265,7 +297,11 @@ func TestLexerNextError(t *testing.T) {
 			B:    common.FilePos{L: 3, C: 2},
 			E:    common.FilePos{L: 3, C: 3},
 		},
-		Val: assert.AnError,
+		Val: common.Diagnose(assert.AnError, common.Location{
+			File: "file",
+			B:    common.FilePos{L: 3, C: 2},
+			E:    common.FilePos{L: 3, C: 3},
+		}),
 	}
 
 	result := l.Next()
@@ -350,7 +386,15 @@ func TestLexerNextEOFDangle(t *testing.T) {
 			B:    common.FilePos{L: 1, C: 1},
 			E:    common.FilePos{L: 1, C: 2},
 		},
-		Val: common.ErrDanglingOpen(pairTok),
+		Val: common.Diagnose(danglingOpen(pairTok, common.Location{
+			File: "file",
+			B:    common.FilePos{L: 3, C: 2},
+			E:    common.FilePos{L: 3, C: 3},
+		}), common.Location{
+			File: "file",
+			B:    common.FilePos{L: 1, C: 1},
+			E:    common.FilePos{L: 1, C: 2},
+		}),
 	}
 
 	result := l.Next()
@@ -737,7 +781,11 @@ func TestLexerNextOther(t *testing.T) {
 			B:    common.FilePos{L: 3, C: 1},
 			E:    common.FilePos{L: 3, C: 2},
 		},
-		Val: common.ErrBadOp,
+		Val: common.Diagnose(common.ErrBadOp, common.Location{
+			File: "file",
+			B:    common.FilePos{L: 3, C: 1},
+			E:    common.FilePos{L: 3, C: 2},
+		}),
 	}
 
 	result := l.Next()
@@ -749,6 +797,100 @@ func TestLexerNextOther(t *testing.T) {
 	recs.AssertExpectations(t)
 }
 
+func TestLexerRecognizersDefault(t *testing.T) {
+	a := assert.New(t)
+	l := &lexer{opts: makeOptions(strings.NewReader(""))}
+
+	result := l.recognizers()
+
+	a.Len(result.All(), 6)
+	a.NotNil(result.Get("operator"))
+	testutils.AssertPtrEqual(a, result, l.recogs)
+}
+
+func TestLexerRecognizersProfile(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader(""))
+	opts.Prof = testProfile.Copy()
+	opts.Prof.Recognizers = common.NewRecognizers()
+	l := &lexer{opts: opts}
+
+	result := l.recognizers()
+
+	testutils.AssertPtrEqual(a, opts.Prof.Recognizers, result)
+}
+
+func TestLexerRecognizersCached(t *testing.T) {
+	a := assert.New(t)
+	recogs := common.NewRecognizers()
+	l := &lexer{opts: makeOptions(strings.NewReader("")), recogs: recogs}
+
+	result := l.recognizers()
+
+	testutils.AssertPtrEqual(a, recogs, result)
+}
+
+type recognizeSigil struct {
+	lc common.LexContext
+}
+
+func recogSigil(lc common.LexContext) common.Recognizer {
+	return &recognizeSigil{lc: lc}
+}
+
+func (r *recognizeSigil) Recognize(ch common.AugChar) {
+	loc := ch.Loc
+	name := &strings.Builder{}
+	for {
+		ch = r.lc.Scanner().Next()
+		if ch.Class&common.CharIDCont == 0 {
+			r.lc.Scanner().Push(ch)
+			break
+		}
+		name.WriteRune(ch.C)
+		loc = loc.ThruEnd(ch.Loc)
+	}
+
+	if name.Len() == 0 {
+		r.lc.PushErr(loc, common.ErrBadIdent)
+		return
+	}
+	r.lc.PushTok(common.TokString, loc, name.String())
+}
+
+func TestLexerRecognizerDialect(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("x + @sigil\n"))
+	opts.Prof = testProfile.Copy()
+	opts.Prof.Recognizers = DefaultRecognizers()
+	opts.Prof.Recognizers.Add(&common.RecogEntry{
+		Name:     "sigil",
+		Priority: PrioOp - 1,
+		Match: func(ch common.AugChar, s common.Scanner) bool {
+			return ch.C == '@'
+		},
+		Init: recogSigil,
+	})
+	l, _ := Lex(opts, nil)
+
+	result := []*common.Token{}
+	for tok := l.Next(); tok != nil; tok = l.Next() {
+		result = append(result, tok)
+	}
+
+	a.Len(result, 5)
+	a.Equal(common.TokIdent, result[0].Sym)
+	a.Equal(common.TokString, result[2].Sym)
+	a.Equal("sigil", result[2].Val)
+	a.Equal(common.Location{
+		File: "file",
+		B:    common.FilePos{L: 1, C: 5},
+		E:    common.FilePos{L: 1, C: 11},
+	}, result[2].Loc)
+	a.Equal(common.TokNewline, result[3].Sym)
+	a.Equal(common.TokEOF, result[4].Sym)
+}
+
 func TestLexerNextContinuation(t *testing.T) {
 	a := assert.New(t)
 	opts := makeOptions(strings.NewReader(""))
@@ -853,7 +995,11 @@ func TestLexerNextContinuationErr(t *testing.T) {
 			B:    common.FilePos{L: 2, C: 4},
 			E:    common.FilePos{L: 2, C: 4},
 		},
-		Val: assert.AnError,
+		Val: common.Diagnose(assert.AnError, common.Location{
+			File: "file",
+			B:    common.FilePos{L: 2, C: 4},
+			E:    common.FilePos{L: 2, C: 4},
+		}),
 	}
 
 	result := l.Next()
@@ -901,7 +1047,11 @@ func TestLexerNextContinuationDangling(t *testing.T) {
 			B:    common.FilePos{L: 2, C: 4},
 			E:    common.FilePos{L: 2, C: 4},
 		},
-		Val: common.ErrDanglingBackslash,
+		Val: common.Diagnose(common.ErrDanglingBackslash, common.Location{
+			File: "file",
+			B:    common.FilePos{L: 2, C: 4},
+			E:    common.FilePos{L: 2, C: 4},
+		}),
 	}
 
 	result := l.Next()
@@ -1275,7 +1425,32 @@ func TestLexerNextWhitespaceMixed(t *testing.T) {
 			B:    common.FilePos{L: 3, C: 1},
 			E:    common.FilePos{L: 3, C: 9},
 		},
-		Val: common.ErrMixedIndent,
+		Val: &common.Diagnostic{
+			Code:     common.CodeMixedIndent,
+			Severity: common.SevError,
+			Msg:      common.ErrMixedIndent.Error(),
+			Loc: common.Location{
+				File: "file",
+				B:    common.FilePos{L: 3, C: 1},
+				E:    common.FilePos{L: 3, C: 9},
+			},
+			Fixes: []common.Fix{
+				{
+					Msg: "indent using spaces",
+					Edits: []common.Edit{
+						{
+							Loc: common.Location{
+								File: "file",
+								B:    common.FilePos{L: 3, C: 1},
+								E:    common.FilePos{L: 3, C: 13},
+							},
+							Text: "            ",
+						},
+					},
+				},
+			},
+			Err: common.ErrMixedIndent,
+		},
 	}
 
 	result := l.Next()
@@ -1717,6 +1892,225 @@ func TestLexerNextWhitespacePairedNewline(t *testing.T) {
 	recs.AssertExpectations(t)
 }
 
+func TestLexerPeekEnqueued(t *testing.T) {
+	a := assert.New(t)
+	tok1 := &common.Token{Sym: common.TokIdent, Val: "tok1"}
+	tok2 := &common.Token{Sym: common.TokIdent, Val: "tok2"}
+	l := &lexer{}
+	l.tokens.PushBack(tok1)
+	l.tokens.PushBack(tok2)
+
+	result := l.Peek(1)
+
+	a.Equal(tok2, result)
+	a.Equal(2, l.tokens.Len())
+	a.Nil(l.prevTok)
+}
+
+func TestLexerPeekNegative(t *testing.T) {
+	a := assert.New(t)
+	l := &lexer{}
+	l.tokens.PushBack(&common.Token{Sym: common.TokIdent, Val: "tok1"})
+
+	result := l.Peek(-1)
+
+	a.Nil(result)
+}
+
+func TestLexerPeekAhead(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("a = b\n"))
+	l, _ := Lex(opts, nil)
+
+	first := l.Peek(0)
+	third := l.Peek(2)
+	past := l.Peek(5)
+
+	a.Equal("a", first.Val)
+	a.Equal("b", third.Val)
+	a.Nil(past)
+	toks := []*common.Token{}
+	for tok := l.Next(); tok != nil; tok = l.Next() {
+		toks = append(toks, tok)
+	}
+	a.Len(toks, 5)
+	testutils.AssertPtrEqual(a, first, toks[0])
+	testutils.AssertPtrEqual(a, third, toks[2])
+	a.Nil(l.Peek(0))
+}
+
+func TestLexerPeekError(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("a ? b\n"))
+	l, _ := Lex(opts, nil)
+
+	result := l.Peek(1)
+
+	a.Equal(common.TokError, result.Sym)
+	a.Nil(l.Peek(2))
+	a.Equal("a", l.Next().Val)
+	a.Equal(common.TokError, l.Next().Sym)
+	a.Nil(l.Next())
+}
+
+func TestLexerPeekTrivia(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("a  # c\nb\n"))
+	opts.Trivia = true
+	l, _ := Lex(opts, nil)
+
+	result := l.Peek(0)
+
+	a.Equal("a", result.Val)
+	a.Equal([]common.Trivia{
+		{
+			Kind: common.TriviaSpace,
+			Text: "  ",
+			Loc: common.Location{
+				File: "file",
+				B:    common.FilePos{L: 1, C: 2},
+				E:    common.FilePos{L: 1, C: 4},
+			},
+		},
+		{
+			Kind: common.TriviaComment,
+			Text: "# c",
+			Loc: common.Location{
+				File: "file",
+				B:    common.FilePos{L: 1, C: 4},
+				E:    common.FilePos{L: 1, C: 7},
+			},
+		},
+	}, result.Trail)
+}
+
+func TestLexerReadyNoPend(t *testing.T) {
+	a := assert.New(t)
+	l := &lexer{}
+	l.tokens.PushBack(&common.Token{Sym: common.TokIdent, Val: "tok1"})
+	l.tokens.PushBack(&common.Token{Sym: common.TokIdent, Val: "tok2"})
+
+	result := l.ready()
+
+	a.Equal(2, result)
+}
+
+func TestLexerReadyPend(t *testing.T) {
+	a := assert.New(t)
+	pend := &common.Token{Sym: common.TokIdent, Val: "tok2"}
+	l := &lexer{pend: pend}
+	l.tokens.PushBack(&common.Token{Sym: common.TokIdent, Val: "tok1"})
+	l.tokens.PushBack(pend)
+	l.tokens.PushBack(&common.Token{Sym: common.TokDedent})
+
+	result := l.ready()
+
+	a.Equal(1, result)
+}
+
+func TestLexerSnapshot(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("a\n  b = (c +\nd)\ne\n"))
+	l, _ := Lex(opts, nil)
+	toks := []*common.Token{}
+	for i := 0; i < 8; i++ {
+		toks = append(toks, l.Next())
+	}
+
+	result := l.Snapshot()
+
+	a.Equal(common.FilePos{L: 2, C: 11}, result.Pos)
+	a.Equal([]int{1, 3}, result.Indent)
+	a.Equal([]*common.Token{toks[5]}, result.Pairs)
+	a.Nil(result.Pending)
+	testutils.AssertPtrEqual(a, toks[7], result.Prev)
+	a.Equal("+", result.Prev.Val)
+}
+
+func TestLexerSnapshotNoRecorder(t *testing.T) {
+	a := assert.New(t)
+	tok := &common.Token{Sym: common.TokIdent, Val: "tok"}
+	l := &lexer{}
+	l.indent.PushBack(1)
+	l.tokens.PushBack(tok)
+
+	result := l.Snapshot()
+
+	a.Equal(&common.LexState{
+		Indent:  []int{1},
+		Pending: []*common.Token{tok},
+	}, result)
+}
+
+func TestLexerRestore(t *testing.T) {
+	a := assert.New(t)
+	src := "a\n  b = (c +\nd)\ne\n"
+	full, _ := All(makeOptions(strings.NewReader(src)))
+	for _, cnt := range []int{2, 8} {
+		l, _ := Lex(makeOptions(strings.NewReader(src)), nil)
+		for i := 0; i < cnt; i++ {
+			l.Next()
+		}
+		state := l.Snapshot()
+		lines := strings.SplitAfter(src, "\n")
+		rest := strings.Join(lines[state.Pos.L-1:], "")[state.Pos.C-1:]
+		opts := makeOptions(strings.NewReader(rest))
+		opts.Start = state.Pos
+		l, _ = Lex(opts, nil)
+
+		err := l.Restore(state)
+
+		a.NoError(err)
+		toks := []*common.Token{}
+		for tok := l.Next(); tok != nil; tok = l.Next() {
+			toks = append(toks, tok)
+		}
+		a.Equal(len(full)-cnt, len(toks))
+		for i, tok := range toks {
+			a.Equal(full[cnt+i].Sym, tok.Sym)
+			a.Equal(full[cnt+i].Loc, tok.Loc)
+			a.Equal(full[cnt+i].Val, tok.Val)
+			a.Equal(full[cnt+i].Text, tok.Text)
+		}
+	}
+}
+
+func TestLexerRestorePending(t *testing.T) {
+	a := assert.New(t)
+	tok := &common.Token{Sym: common.TokIdent, Val: "tok"}
+	opts := makeOptions(strings.NewReader("b"))
+	opts.Start = common.FilePos{L: 3, C: 5}
+	l, _ := Lex(opts, nil)
+
+	err := l.Restore(&common.LexState{
+		Pos:     common.FilePos{L: 3, C: 5},
+		Indent:  []int{1, 3},
+		Pending: []*common.Token{tok},
+	})
+
+	a.NoError(err)
+	a.Equal(2, l.(*lexer).indent.Len())
+	a.Equal(3, l.(*lexer).indent.Back().Value)
+	testutils.AssertPtrEqual(a, tok, l.Next())
+	a.Equal("b", l.Next().Val)
+	a.Equal(common.TokEOF, l.Next().Sym)
+}
+
+func TestLexerRestoreMismatch(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("b\n"))
+	l, _ := Lex(opts, nil)
+
+	err := l.Restore(&common.LexState{
+		Pos:    common.FilePos{L: 3, C: 1},
+		Indent: []int{1},
+	})
+
+	a.True(errors.Is(err, common.ErrStatePos))
+	a.EqualError(err, "lexer state does not match source position: expected 3:1, found 1:1")
+	a.Equal("b", l.Next().Val)
+}
+
 func TestLexerPush(t *testing.T) {
 	a := assert.New(t)
 	l := &lexer{}
@@ -1748,3 +2142,430 @@ func TestLexerPush(t *testing.T) {
 	elem = elem.Next()
 	a.Equal(tok1, elem.Value.(*common.Token))
 }
+
+func TestDanglingOpen(t *testing.T) {
+	a := assert.New(t)
+	tok := &common.Token{
+		Sym: &common.Symbol{
+			Name:  "(",
+			Close: ")",
+		},
+		Loc: common.Location{
+			File: "file",
+			B:    common.FilePos{L: 1, C: 1},
+			E:    common.FilePos{L: 1, C: 2},
+		},
+	}
+	eof := common.Location{
+		File: "file",
+		B:    common.FilePos{L: 3, C: 2},
+		E:    common.FilePos{L: 3, C: 2},
+	}
+
+	result := danglingOpen(tok, eof)
+
+	diag, ok := result.(*common.Diagnostic)
+	a.True(ok)
+	a.True(errors.Is(result, common.ErrUnclosedOp))
+	a.Equal([]common.Fix{
+		{
+			Msg: "insert the missing \")\"",
+			Edits: []common.Edit{
+				{
+					Loc:  eof,
+					Text: ")",
+				},
+			},
+		},
+	}, diag.Fixes)
+}
+
+func TestLexerIndentErrorSpaces(t *testing.T) {
+	a := assert.New(t)
+	s := &common.MockScanner{}
+	next := common.AugChar{
+		C: 'a',
+		Loc: common.Location{
+			File: "file",
+			B:    common.FilePos{L: 3, C: 10},
+			E:    common.FilePos{L: 3, C: 11},
+		},
+	}
+	s.On("Next").Return(next)
+	s.On("Push", next)
+	l := &lexer{s: s}
+	loc := common.Location{
+		File: "file",
+		B:    common.FilePos{L: 3, C: 1},
+		E:    common.FilePos{L: 3, C: 2},
+	}
+
+	result := l.indentError(common.ErrMixedIndent, loc, false)
+
+	diag, ok := result.(*common.Diagnostic)
+	a.True(ok)
+	a.True(errors.Is(result, common.ErrMixedIndent))
+	a.Equal(loc, diag.Loc)
+	a.Equal([]common.Fix{
+		{
+			Msg: "indent using spaces",
+			Edits: []common.Edit{
+				{
+					Loc: common.Location{
+						File: "file",
+						B:    common.FilePos{L: 3, C: 1},
+						E:    common.FilePos{L: 3, C: 10},
+					},
+					Text: "         ",
+				},
+			},
+		},
+	}, diag.Fixes)
+	s.AssertExpectations(t)
+}
+
+func TestLexerIndentErrorTabs(t *testing.T) {
+	a := assert.New(t)
+	s := &common.MockScanner{}
+	next := common.AugChar{
+		C: 'a',
+		Loc: common.Location{
+			File: "file",
+			B:    common.FilePos{L: 3, C: 17},
+			E:    common.FilePos{L: 3, C: 18},
+		},
+	}
+	s.On("Next").Return(next)
+	s.On("Push", next)
+	l := &lexer{s: s, opts: &common.Options{TabStop: 8}}
+	loc := common.Location{
+		File: "file",
+		B:    common.FilePos{L: 3, C: 1},
+		E:    common.FilePos{L: 3, C: 2},
+	}
+
+	result := l.indentError(common.ErrSpaceIndent, loc, true)
+
+	diag, ok := result.(*common.Diagnostic)
+	a.True(ok)
+	a.True(errors.Is(result, common.ErrSpaceIndent))
+	a.Equal(loc, diag.Loc)
+	a.Equal([]common.Fix{
+		{
+			Msg: "indent using tabs",
+			Edits: []common.Edit{
+				{
+					Loc: common.Location{
+						File: "file",
+						B:    common.FilePos{L: 3, C: 1},
+						E:    common.FilePos{L: 3, C: 17},
+					},
+					Text: "\t\t",
+				},
+			},
+		},
+	}, diag.Fixes)
+	s.AssertExpectations(t)
+}
+
+func TestLexerIndentErrorTabsNoFix(t *testing.T) {
+	a := assert.New(t)
+	s := &common.MockScanner{}
+	next := common.AugChar{
+		C: 'a',
+		Loc: common.Location{
+			File: "file",
+			B:    common.FilePos{L: 3, C: 10},
+			E:    common.FilePos{L: 3, C: 11},
+		},
+	}
+	s.On("Next").Return(next)
+	s.On("Push", next)
+	l := &lexer{s: s, opts: &common.Options{TabStop: 8}}
+	loc := common.Location{
+		File: "file",
+		B:    common.FilePos{L: 3, C: 1},
+		E:    common.FilePos{L: 3, C: 2},
+	}
+
+	result := l.indentError(common.ErrSpaceIndent, loc, true)
+
+	diag, ok := result.(*common.Diagnostic)
+	a.True(ok)
+	a.True(errors.Is(result, common.ErrSpaceIndent))
+	a.Equal(loc, diag.Loc)
+	a.Nil(diag.Fixes)
+	s.AssertExpectations(t)
+}
+
+func TestLexerRecovery(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("a = 12x + \"abc\nb = $ 3\nc = (1]\nd = [2\n"))
+	opts.Recover = true
+	l, _ := Lex(opts, nil)
+	expected := []struct {
+		sym  *common.Symbol
+		code string
+	}{
+		{common.TokIdent, ""},
+		{testOperators.Next('=').Sym, ""},
+		{common.TokError, common.CodeBadNumber},
+		{testOperators.Next('+').Sym, ""},
+		{common.TokError, common.CodeUnclosedStr},
+		{common.TokNewline, ""},
+		{common.TokIdent, ""},
+		{testOperators.Next('=').Sym, ""},
+		{common.TokError, common.CodeBadOp},
+		{common.TokInt, ""},
+		{common.TokNewline, ""},
+		{common.TokIdent, ""},
+		{testOperators.Next('=').Sym, ""},
+		{testOperators.Next('(').Sym, ""},
+		{common.TokInt, ""},
+		{common.TokError, common.CodeMismatchedOp},
+		{common.TokIdent, ""},
+		{testOperators.Next('=').Sym, ""},
+		{testOperators.Next('[').Sym, ""},
+		{common.TokInt, ""},
+		{common.TokError, common.CodeUnclosedOp},
+		{common.TokError, common.CodeUnclosedOp},
+		{common.TokEOF, ""},
+	}
+
+	for _, exp := range expected {
+		tok := l.Next()
+		if !a.NotNil(tok) {
+			return
+		}
+		a.Equal(exp.sym, tok.Sym, "%s", tok)
+		if exp.code != "" {
+			a.Equal(exp.code, tok.Val.(*common.Diagnostic).Code)
+		}
+	}
+	a.Nil(l.Next())
+}
+
+func TestLexerWarnings(t *testing.T) {
+	a := assert.New(t)
+	var warnings []*common.Diagnostic
+	opts := makeWarnOptions(strings.NewReader("a = 1  \nb = (1 + \\\n \t2)\nc = \"\\012\\0\"\nd = 1 \f+ 2\n"), &warnings)
+	l, _ := Lex(opts, nil)
+
+	for tok := l.Next(); tok != nil; tok = l.Next() {
+		a.NotEqual(common.TokError, tok.Sym, "%s", tok)
+	}
+
+	codes := []string{}
+	for _, diag := range warnings {
+		a.Equal(common.SevWarning, diag.Severity)
+		codes = append(codes, diag.Code)
+	}
+	a.Equal([]string{
+		common.CodeTrailingWS,
+		common.CodeBackslash,
+		common.CodeTabAfterSpace,
+		common.CodeOctalEscape,
+		common.CodeFormFeed,
+	}, codes)
+}
+
+func TestLexerWarningsDisabled(t *testing.T) {
+	a := assert.New(t)
+	var warnings []*common.Diagnostic
+	opts := makeWarnOptions(strings.NewReader("a = 1  \nb = (1 + \\\n \t2)\nc = \"\\012\\0\"\nd = 1 \f+ 2\n"), &warnings)
+	opts.Prof.Lints = 0
+	l, _ := Lex(opts, nil)
+
+	for tok := l.Next(); tok != nil; tok = l.Next() {
+		a.NotEqual(common.TokError, tok.Sym, "%s", tok)
+	}
+
+	a.Nil(warnings)
+}
+
+func TestLexerTrivia(t *testing.T) {
+	a := assert.New(t)
+	src := "# leading comment\r\n\r\nif a >  # trailing\r\n    b = (1 +\r\n      2)  \r\n\f\r\n    c = \"x\\ty\" \\\r\n  + 0x_FF\r\n## doc\r\nd\r\n  \r\n"
+	opts := makeOptions(strings.NewReader(src))
+	opts.Trivia = true
+	l, _ := Lex(opts, nil)
+
+	text := &strings.Builder{}
+	toks := []*common.Token{}
+	for tok := l.Next(); tok != nil; tok = l.Next() {
+		a.NotEqual(common.TokError, tok.Sym, "%s", tok)
+		text.WriteString(tok.FullText())
+		toks = append(toks, tok)
+	}
+
+	a.Equal(src, text.String())
+	a.Len(toks, 25)
+	a.Equal("if", toks[0].Text)
+	a.Equal([]common.Trivia{
+		{
+			Kind: common.TriviaComment,
+			Text: "# leading comment",
+			Loc:  common.Location{File: "file", B: common.FilePos{L: 1, C: 1}, E: common.FilePos{L: 1, C: 18}},
+		},
+		{
+			Kind: common.TriviaNewline,
+			Text: "\r\n",
+			Loc:  common.Location{File: "file", B: common.FilePos{L: 1, C: 18}, E: common.FilePos{L: 2, C: 1}},
+		},
+		{
+			Kind: common.TriviaNewline,
+			Text: "\r\n",
+			Loc:  common.Location{File: "file", B: common.FilePos{L: 2, C: 1}, E: common.FilePos{L: 3, C: 1}},
+		},
+	}, toks[0].Lead)
+	a.Equal([]common.Trivia{
+		{
+			Kind: common.TriviaSpace,
+			Text: "  ",
+			Loc:  common.Location{File: "file", B: common.FilePos{L: 3, C: 7}, E: common.FilePos{L: 3, C: 9}},
+		},
+		{
+			Kind: common.TriviaComment,
+			Text: "# trailing",
+			Loc:  common.Location{File: "file", B: common.FilePos{L: 3, C: 9}, E: common.FilePos{L: 3, C: 19}},
+		},
+	}, toks[2].Trail)
+	a.Equal(common.TokNewline, toks[3].Sym)
+	a.Equal("\r\n", toks[3].Text)
+	a.Equal("\"x\\ty\"", toks[15].Text)
+	a.Equal(common.TriviaContinuation, toks[15].Trail[1].Kind)
+	a.Equal("0x_FF", toks[17].Text)
+	a.Equal("## doc", toks[20].Text)
+	a.Equal(common.TokEOF, toks[24].Sym)
+	a.Equal("  \r\n", toks[24].FullText())
+}
+
+func TestLexerTriviaRecovery(t *testing.T) {
+	a := assert.New(t)
+	src := "a = `x` + 1\n"
+	opts := makeOptions(strings.NewReader(src))
+	opts.Trivia = true
+	opts.Recover = true
+	l, _ := Lex(opts, nil)
+
+	text := &strings.Builder{}
+	var skipped []common.Trivia
+	for tok := l.Next(); tok != nil; tok = l.Next() {
+		text.WriteString(tok.FullText())
+		for _, triv := range tok.Lead {
+			if triv.Kind == common.TriviaSkipped {
+				skipped = append(skipped, triv)
+			}
+		}
+	}
+
+	a.Equal(src, text.String())
+	a.Len(skipped, 2)
+	a.Equal("`", skipped[0].Text)
+	a.Equal("`", skipped[1].Text)
+}
+
+func TestLexerTriviaHalted(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("a = ` b\n"))
+	opts.Trivia = true
+	l, _ := Lex(opts, nil)
+
+	toks := []*common.Token{}
+	for tok := l.Next(); tok != nil; tok = l.Next() {
+		toks = append(toks, tok)
+	}
+
+	a.Len(toks, 3)
+	a.Equal("=", toks[1].Text)
+	a.Equal("a = ", toks[0].FullText()+toks[1].FullText())
+	a.Equal(common.TokError, toks[2].Sym)
+}
+
+func TestLexerText(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("a = 0x_FF + 1.5e3  # c\r\nb = r'x\\n' <<= .5\r\n"))
+	l, _ := Lex(opts, nil)
+
+	texts := []string{}
+	for tok := l.Next(); tok != nil; tok = l.Next() {
+		a.NotEqual(common.TokError, tok.Sym, "%s", tok)
+		a.Nil(tok.Lead)
+		a.Nil(tok.Trail)
+		texts = append(texts, tok.Text)
+	}
+
+	a.Equal([]string{"a", "=", "0x_FF", "+", "1.5e3", "\r\n", "b", "=", "r'x\\n'", "<<=", ".5", "\r\n", ""}, texts)
+}
+
+func TestLexerSerialize(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("a = 0x_FF + 1.5e3\nb = (r'x\\n' + b\"\\x00\") <<= ?\n"))
+	opts.Recover = true
+	l, _ := Lex(opts, nil)
+	toks := []*common.Token{}
+	for tok := l.Next(); tok != nil; tok = l.Next() {
+		toks = append(toks, tok)
+	}
+	buf := &bytes.Buffer{}
+	common.WriteTokens(buf, toks)
+
+	result, err := common.ReadTokens(buf, testProfile)
+
+	a.NoError(err)
+	a.Equal(len(toks), len(result))
+	for i, tok := range result {
+		a.Equal(toks[i].Sym, tok.Sym)
+		a.Equal(toks[i].Loc, tok.Loc)
+		a.Equal(toks[i].Text, tok.Text)
+		a.Equal(fmt.Sprint(toks[i].Val), fmt.Sprint(tok.Val))
+	}
+}
+
+func TestLexerOperatorAlias(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("a ≤ b <= c\n"))
+	opts.Prof = testProfile.Copy()
+	le := opts.Prof.Operators.Next('<').Next('=').Sym
+	opts.Prof.Operators.AddAlias("≤", le)
+	l, _ := Lex(opts, nil)
+
+	toks := []*common.Token{}
+	for tok := l.Next(); tok != nil; tok = l.Next() {
+		toks = append(toks, tok)
+	}
+
+	a.Len(toks, 7)
+	a.Equal(le, toks[1].Sym)
+	a.Equal("≤", toks[1].Text)
+	a.Equal("<=", toks[1].Val)
+	a.Equal(le, toks[3].Sym)
+	a.Equal("<=", toks[3].Text)
+}
+
+func TestLexerCompoundKeyword(t *testing.T) {
+	a := assert.New(t)
+	opts := makeCompoundOptions(strings.NewReader("a not  in b\nc is not\nd\n"))
+	opts.Trivia = true
+	l, _ := Lex(opts, nil)
+
+	toks := []*common.Token{}
+	for tok := l.Next(); tok != nil; tok = l.Next() {
+		toks = append(toks, tok)
+	}
+
+	a.Len(toks, 10)
+	a.Equal(opts.Prof.Keywords["not in"], toks[1].Sym)
+	a.Equal("not  in", toks[1].Text)
+	a.Equal(common.Location{
+		File: "file",
+		B:    common.FilePos{L: 1, C: 3},
+		E:    common.FilePos{L: 1, C: 10},
+	}, toks[1].Loc)
+	a.Equal(opts.Prof.Keywords["is not"], toks[5].Sym)
+	src := &strings.Builder{}
+	for _, tok := range toks {
+		src.WriteString(tok.FullText())
+	}
+	a.Equal("a not  in b\nc is not\nd\n", src.String())
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2019 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package lexer
+
+import (
+	"github.com/hydralang/hydra/parser/common"
+)
+
+// Mode returns the active lexical mode, or nil if no mode has been
+// pushed.
+func (l *lexer) Mode() *common.Mode {
+	if l.modes.Len() == 0 {
+		return nil
+	}
+
+	return l.modes.Back().Value.(*common.Mode)
+}
+
+// PushMode pushes a lexical mode onto the mode stack, making it the
+// active mode.
+func (l *lexer) PushMode(mode *common.Mode) {
+	l.modes.PushBack(mode)
+}
+
+// PopMode pops the active lexical mode off the mode stack.  Returns
+// common.ErrNoMode if no mode has been pushed.
+func (l *lexer) PopMode() error {
+	if l.modes.Len() == 0 {
+		return common.ErrNoMode
+	}
+
+	l.modes.Remove(l.modes.Back())
+	return nil
+}
+
+// modeFlags returns the flags of the active lexical mode.  If no mode
+// has been pushed, no flags are set.
+func (l *lexer) modeFlags() uint8 {
+	if mode := l.Mode(); mode != nil {
+		return mode.Flags
+	}
+
+	return 0
+}
@@ -0,0 +1,323 @@
+// Copyright (c) 2019 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package lexer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
+
+	"github.com/hydralang/hydra/parser/common"
+	"github.com/hydralang/hydra/testutils"
+)
+
+func TestLexerModeNone(t *testing.T) {
+	a := assert.New(t)
+	l := &lexer{}
+
+	result := l.Mode()
+
+	a.Nil(result)
+}
+
+func TestLexerMode(t *testing.T) {
+	a := assert.New(t)
+	mode1 := &common.Mode{Name: "mode1"}
+	mode2 := &common.Mode{Name: "mode2"}
+	l := &lexer{}
+	l.modes.PushBack(mode1)
+	l.modes.PushBack(mode2)
+
+	result := l.Mode()
+
+	testutils.AssertPtrEqual(a, mode2, result)
+}
+
+func TestLexerPushMode(t *testing.T) {
+	a := assert.New(t)
+	mode1 := &common.Mode{Name: "mode1"}
+	mode2 := &common.Mode{Name: "mode2"}
+	l := &lexer{}
+	l.modes.PushBack(mode1)
+
+	l.PushMode(mode2)
+
+	a.Equal(2, l.modes.Len())
+	testutils.AssertPtrEqual(a, mode2, l.modes.Back().Value)
+}
+
+func TestLexerPopMode(t *testing.T) {
+	a := assert.New(t)
+	mode1 := &common.Mode{Name: "mode1"}
+	mode2 := &common.Mode{Name: "mode2"}
+	l := &lexer{}
+	l.modes.PushBack(mode1)
+	l.modes.PushBack(mode2)
+
+	err := l.PopMode()
+
+	a.NoError(err)
+	a.Equal(1, l.modes.Len())
+	testutils.AssertPtrEqual(a, mode1, l.modes.Back().Value)
+}
+
+func TestLexerPopModeEmpty(t *testing.T) {
+	a := assert.New(t)
+	l := &lexer{}
+
+	err := l.PopMode()
+
+	a.Equal(common.ErrNoMode, err)
+}
+
+func TestLexerModeFlags(t *testing.T) {
+	a := assert.New(t)
+	l := &lexer{}
+	l.modes.PushBack(&common.Mode{Flags: common.ModeKeepWS})
+
+	result := l.modeFlags()
+
+	a.Equal(common.ModeKeepWS, result)
+}
+
+func TestLexerModeFlagsNone(t *testing.T) {
+	a := assert.New(t)
+	l := &lexer{}
+
+	result := l.modeFlags()
+
+	a.Equal(uint8(0), result)
+}
+
+// templateMode is a lexical mode for testing, for template literals
+// delimited by backquotes.  Each line of a template literal is a
+// separate string token.
+var templateMode = &common.Mode{
+	Name: "template",
+	Recognizers: common.NewRecognizers(&common.RecogEntry{
+		Name: "text",
+		Match: func(ch common.AugChar, s common.Scanner) bool {
+			return true
+		},
+		Init: recogTemplateText,
+	}),
+	Flags: common.ModeKeepWS | common.ModeNoIndent,
+}
+
+type recognizeTemplateOpen struct {
+	lc common.LexContext
+}
+
+func recogTemplateOpen(lc common.LexContext) common.Recognizer {
+	return &recognizeTemplateOpen{lc: lc}
+}
+
+func (r *recognizeTemplateOpen) Recognize(ch common.AugChar) {
+	r.lc.PushMode(templateMode)
+}
+
+type recognizeTemplateText struct {
+	lc common.LexContext
+}
+
+func recogTemplateText(lc common.LexContext) common.Recognizer {
+	return &recognizeTemplateText{lc: lc}
+}
+
+func (r *recognizeTemplateText) Recognize(ch common.AugChar) {
+	loc := ch.Loc
+	text := &strings.Builder{}
+	for ch.C != '`' && ch.C != common.EOF {
+		text.WriteRune(ch.C)
+		loc = loc.ThruEnd(ch.Loc)
+		if ch.C == '\n' {
+			break
+		}
+		ch = r.lc.Scanner().Next()
+	}
+
+	if text.Len() > 0 {
+		r.lc.PushTok(common.TokString, loc, text.String())
+	}
+	if ch.C == '`' {
+		r.lc.PopMode()
+	} else if ch.C == common.EOF {
+		r.lc.Scanner().Push(ch)
+	}
+}
+
+func makeTemplateOptions(src string) *common.Options {
+	opts := makeOptions(strings.NewReader(src))
+	opts.Prof = testProfile.Copy()
+	opts.Prof.Recognizers = DefaultRecognizers()
+	opts.Prof.Recognizers.Add(&common.RecogEntry{
+		Name:     "template",
+		Priority: PrioOp - 1,
+		Match: func(ch common.AugChar, s common.Scanner) bool {
+			return ch.C == '`'
+		},
+		Init: recogTemplateOpen,
+	})
+	return opts
+}
+
+func TestLexerModeTemplate(t *testing.T) {
+	a := assert.New(t)
+	opts := makeTemplateOptions("x = `a  b\n  c`\ny\n")
+
+	result, err := All(opts)
+
+	a.NoError(err)
+	syms := []*common.Symbol{}
+	for _, tok := range result {
+		syms = append(syms, tok.Sym)
+	}
+	a.Equal([]*common.Symbol{
+		common.TokIdent,
+		testOperators.Next('=').Sym,
+		common.TokString,
+		common.TokString,
+		common.TokNewline,
+		common.TokIdent,
+		common.TokNewline,
+		common.TokEOF,
+	}, syms)
+	a.Equal("a  b\n", result[2].Val)
+	a.Equal("  c", result[3].Val)
+	a.Equal(common.Location{
+		File: "file",
+		B:    common.FilePos{L: 2, C: 1},
+		E:    common.FilePos{L: 2, C: 4},
+	}, result[3].Loc)
+}
+
+func TestLexerModeUnclosed(t *testing.T) {
+	a := assert.New(t)
+	opts := makeTemplateOptions("x = `abc")
+
+	result, err := All(opts)
+
+	a.Len(result, 3)
+	a.Equal("abc", result[2].Val)
+	a.Equal(common.CodeUnclosedMode, common.Code(err))
+	a.EqualError(err, "unterminated lexical mode \"template\"")
+}
+
+func TestLexerModeUnclosedLanguage(t *testing.T) {
+	a := assert.New(t)
+	opts := makeTemplateOptions("x = `abc")
+	opts.Lang = language.English
+
+	_, err := All(opts)
+
+	a.EqualError(err, "unterminated lexical mode \"template\"")
+}
+
+func TestLexerModeUnclosedRecover(t *testing.T) {
+	a := assert.New(t)
+	opts := makeTemplateOptions("x = `abc")
+	opts.Recover = true
+	l, _ := Lex(opts, nil)
+
+	result := []*common.Token{}
+	for tok := l.Next(); tok != nil; tok = l.Next() {
+		result = append(result, tok)
+	}
+
+	a.Len(result, 5)
+	a.Equal(common.TokError, result[3].Sym)
+	a.Equal(common.TokEOF, result[4].Sym)
+	a.Nil(l.(*lexer).Mode())
+}
+
+func TestLexerLookupMode(t *testing.T) {
+	a := assert.New(t)
+	l := &lexer{opts: makeOptions(strings.NewReader(""))}
+	l.modes.PushBack(templateMode)
+
+	result := l.lookup(common.AugChar{C: 'a', Class: common.CharIDStart})
+
+	testutils.AssertPtrEqual(a, templateMode.Recognizers.Get("text"), result)
+}
+
+func TestLexerLookupModeFallback(t *testing.T) {
+	a := assert.New(t)
+	l := &lexer{opts: makeOptions(strings.NewReader(""))}
+	l.modes.PushBack(&common.Mode{
+		Name: "fallback",
+		Recognizers: common.NewRecognizers(&common.RecogEntry{
+			Name:  "comment",
+			Class: common.CharComment,
+			Init:  recogTemplateText,
+		}),
+	})
+
+	result := l.lookup(common.AugChar{C: 'a', Class: common.CharIDStart})
+
+	a.Equal("identifier", result.Name)
+}
+
+func TestLexerLookupModeInherit(t *testing.T) {
+	a := assert.New(t)
+	l := &lexer{opts: makeOptions(strings.NewReader(""))}
+	l.modes.PushBack(&common.Mode{Name: "inherit"})
+
+	result := l.lookup(common.AugChar{C: 'a', Class: common.CharIDStart})
+
+	a.Equal("identifier", result.Name)
+}
+
+func TestLexerLookupNone(t *testing.T) {
+	a := assert.New(t)
+	l := &lexer{opts: makeOptions(strings.NewReader(""))}
+
+	result := l.lookup(common.AugChar{C: '$', Class: common.CharIDCont})
+
+	a.Nil(result)
+}
+
+func TestLexerSnapshotModes(t *testing.T) {
+	a := assert.New(t)
+	l, _ := Lex(makeTemplateOptions("x = `abc\ndef`\n"), nil)
+	l.Next()
+	l.Next()
+	l.Next()
+
+	result := l.Snapshot()
+
+	a.Equal([]*common.Mode{templateMode}, result.Modes)
+}
+
+func TestLexerRestoreModes(t *testing.T) {
+	a := assert.New(t)
+	opts := makeTemplateOptions("def`\n")
+	opts.Start = common.FilePos{L: 2, C: 1}
+	l, _ := Lex(opts, nil)
+	state := &common.LexState{
+		Pos:    common.FilePos{L: 2, C: 1},
+		Indent: []int{1},
+		Modes:  []*common.Mode{templateMode},
+	}
+
+	err := l.Restore(state)
+
+	a.NoError(err)
+	tok := l.Next()
+	a.Equal(common.TokString, tok.Sym)
+	a.Equal("def", tok.Val)
+	a.Nil(l.(*lexer).Mode())
+}
@@ -77,9 +77,9 @@ type recognizeNumber struct {
 }
 
 // recogNumber constructs a recognizer for numbers.
-func recogNumber(l *lexer) Recognizer {
+func recogNumber(lc common.LexContext) common.Recognizer {
 	return &recognizeNumber{
-		l:     l,
+		l:     lc.(*lexer),
 		buf:   &strings.Builder{},
 		flags: NumInt | NumFloat | NumWhole,
 	}
@@ -159,6 +159,7 @@ func (r *recognizeNumber) Recognize(ch common.AugChar) {
 	// Only terminated by operators and whitespace
 	if ch.Class != 0 && ch.Class&common.CharWS == 0 {
 		r.l.pushErr(ch.Loc, common.ErrBadNumber)
+		r.l.resync()
 		return
 	}
 
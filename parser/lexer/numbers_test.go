@@ -26,7 +26,7 @@ import (
 )
 
 func TestRecognizeNumberImplementsRecognizer(t *testing.T) {
-	assert.Implements(t, (*Recognizer)(nil), &recognizeNumber{})
+	assert.Implements(t, (*common.Recognizer)(nil), &recognizeNumber{})
 }
 
 func TestRecogNumber(t *testing.T) {
@@ -387,6 +387,10 @@ func TestRecogNumberIdent(t *testing.T) {
 			B:    common.FilePos{L: 1, C: 2},
 			E:    common.FilePos{L: 1, C: 3},
 		},
-		Val: common.ErrBadNumber,
+		Val: common.Diagnose(common.ErrBadNumber, common.Location{
+			File: "file",
+			B:    common.FilePos{L: 1, C: 2},
+			E:    common.FilePos{L: 1, C: 3},
+		}),
 	}, l.tokens.Front().Value.(*common.Token))
 }
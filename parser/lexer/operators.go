@@ -16,6 +16,7 @@ package lexer
 
 import (
 	"container/list"
+	"unicode/utf8"
 
 	"github.com/hydralang/hydra/parser/common"
 )
@@ -29,6 +30,18 @@ type opFrame struct {
 	node *common.Operators // The operator tree node
 }
 
+// isASCII tests whether a string consists entirely of ASCII
+// characters.
+func isASCII(text string) bool {
+	for i := 0; i < len(text); i++ {
+		if text[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+
+	return true
+}
+
 // recognizeOperator is a recognizer for operators.  It should be
 // called when the character is not of any other recognized class.
 type recognizeOperator struct {
@@ -38,10 +51,10 @@ type recognizeOperator struct {
 }
 
 // recogOperator constructs a recognizer for operators.
-func recogOperator(l *lexer) Recognizer {
+func recogOperator(lc common.LexContext) common.Recognizer {
 	return &recognizeOperator{
-		l:    l,
-		node: l.opts.Prof.Operators,
+		l:    lc.(*lexer),
+		node: lc.Options().Prof.Operators,
 	}
 }
 
@@ -75,9 +88,15 @@ func (r *recognizeOperator) pushFrame(ch common.AugChar, node *common.Operators)
 // emit pushes a token onto the lexer token stack and pushes any
 // unprocessed operator characters back onto the scanner.
 func (r *recognizeOperator) emit() {
-	// Get the first element from the operator stack
+	// Pop the first element off the operator stack
 	elem := r.st.Front()
 	frame := elem.Value.(*opFrame)
+	r.st.Remove(elem)
+
+	// Push back additional characters
+	for elem = r.st.Back(); elem != nil; elem = elem.Prev() {
+		r.l.s.Push(elem.Value.(*opFrame).ch)
+	}
 
 	// Check if there's a token
 	if frame.node.Sym == nil {
@@ -85,40 +104,68 @@ func (r *recognizeOperator) emit() {
 		return
 	}
 
-	// Check for pairing violations
-	if frame.node.Sym.Open != "" {
-		if r.l.pair.Len() > 0 {
-			// See if it's a match
-			openElem := r.l.pair.Back()
-			open := openElem.Value.(*common.Token)
-			if open.Sym.Close == frame.node.Sym.Name {
-				r.l.pair.Remove(openElem)
-			} else {
-				r.l.pushErr(frame.loc, common.ErrOpMismatch(open, frame.node.Sym))
-				return
-			}
-		} else {
-			// No opener
-			r.l.pushErr(frame.loc, common.ErrNoOpen(frame.node.Sym))
+	// Check for non-ASCII spellings, such as aliases
+	if !isASCII(frame.node.Spelling()) {
+		if r.l.opts.ASCIIOps {
+			r.l.pushErr(frame.loc, common.ErrNonASCIIOp)
 			return
 		}
+		r.l.warn(common.LintNonASCIIOp, frame.loc, common.WarnNonASCIIOp)
+	}
+
+	// Check for pairing violations
+	if frame.node.Sym.Open != "" && !r.closePair(frame) {
+		return
 	}
 
 	// Emit the operator
 	tok := r.l.pushTok(frame.node.Sym, frame.loc, frame.node.Sym.Name)
 
-	// Push a pairing if necessary
-	if frame.node.Sym.Close != "" {
+	// Push a pairing if necessary; there's no token if an
+	// indentation error halted the lexer
+	if tok != nil && frame.node.Sym.Close != "" {
 		r.l.pair.PushBack(tok)
 	}
+}
 
-	// Pop off the front element
-	r.st.Remove(elem)
+// closePair checks that a close operator matches the innermost open
+// operator on the pairing stack, and removes that open operator.  It
+// returns false if the close operator should not be emitted.  When
+// the lexer is recovering from errors, a mismatched close operator
+// that matches an enclosing open operator closes that operator, as
+// well as all the unclosed operators within it.
+func (r *recognizeOperator) closePair(frame *opFrame) bool {
+	// Make sure there's an opener
+	if r.l.pair.Len() == 0 {
+		r.l.pushErr(frame.loc, common.ErrNoOpen(frame.node.Sym))
+		return false
+	}
 
-	// Push back additional characters
-	for elem = r.st.Back(); elem != nil; elem = elem.Prev() {
-		r.l.s.Push(elem.Value.(*opFrame).ch)
+	// See if it's a match
+	openElem := r.l.pair.Back()
+	open := openElem.Value.(*common.Token)
+	if open.Sym.Close == frame.node.Sym.Name {
+		r.l.pair.Remove(openElem)
+		return true
+	}
+	r.l.pushErr(frame.loc, common.ErrOpMismatch(open, frame.node.Sym))
+
+	// If recovering, look for an enclosing match
+	if r.l.recov {
+		for elem := openElem.Prev(); elem != nil; elem = elem.Prev() {
+			if elem.Value.(*common.Token).Sym.Close == frame.node.Sym.Name {
+				// Discard everything from the match on
+				for r.l.pair.Back() != elem {
+					r.l.pair.Remove(r.l.pair.Back())
+				}
+				r.l.pair.Remove(elem)
+
+				return true
+			}
+		}
 	}
+
+	return false
 }
 
 // Recognize is called to recognize a operator.  Will be called with
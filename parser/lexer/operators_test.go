@@ -15,6 +15,7 @@
 package lexer
 
 import (
+	"errors"
 	"strings"
 	"testing"
 
@@ -25,7 +26,7 @@ import (
 )
 
 func TestRecognizeOperatorImplementsRecognizer(t *testing.T) {
-	assert.Implements(t, (*Recognizer)(nil), &recognizeOperator{})
+	assert.Implements(t, (*common.Recognizer)(nil), &recognizeOperator{})
 }
 
 func TestRecogOperator(t *testing.T) {
@@ -327,7 +328,11 @@ func TestRecognizeOperatorEmitNoSym(t *testing.T) {
 			B:    common.FilePos{L: 3, C: 3},
 			E:    common.FilePos{L: 3, C: 4},
 		},
-		Val: common.ErrBadOp,
+		Val: common.Diagnose(common.ErrBadOp, common.Location{
+			File: "file",
+			B:    common.FilePos{L: 3, C: 3},
+			E:    common.FilePos{L: 3, C: 4},
+		}),
 	}, l.tokens.Front().Value.(*common.Token))
 }
 
@@ -654,7 +659,11 @@ func TestRecognizeOperatorRecognizeNotOp(t *testing.T) {
 			B:    common.FilePos{L: 1, C: 1},
 			E:    common.FilePos{L: 1, C: 2},
 		},
-		Val: common.ErrBadOp,
+		Val: common.Diagnose(common.ErrBadOp, common.Location{
+			File: "file",
+			B:    common.FilePos{L: 1, C: 1},
+			E:    common.FilePos{L: 1, C: 2},
+		}),
 	}, l.tokens.Front().Value.(*common.Token))
 }
 
@@ -700,6 +709,279 @@ func TestRecognizeOperatorRecognizeErr(t *testing.T) {
 			B:    common.FilePos{L: 3, C: 2},
 			E:    common.FilePos{L: 3, C: 3},
 		},
-		Val: assert.AnError,
+		Val: common.Diagnose(assert.AnError, common.Location{
+			File: "file",
+			B:    common.FilePos{L: 3, C: 2},
+			E:    common.FilePos{L: 3, C: 3},
+		}),
 	}, l.tokens.Front().Value.(*common.Token))
 }
+
+func TestRecognizeOperatorEmitNoSymRecovering(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader(""))
+	s, _ := scanner.Scan(opts)
+	l := &lexer{
+		s:     s,
+		opts:  opts,
+		recov: true,
+	}
+	l.indent.PushBack(1)
+	r := &recognizeOperator{l: l}
+	r.st.PushBack(&opFrame{
+		ch: common.AugChar{
+			C: '$',
+			Loc: common.Location{
+				File: "file",
+				B:    common.FilePos{L: 3, C: 1},
+				E:    common.FilePos{L: 3, C: 2},
+			},
+		},
+		loc: common.Location{
+			File: "file",
+			B:    common.FilePos{L: 3, C: 1},
+			E:    common.FilePos{L: 3, C: 2},
+		},
+		node: opts.Prof.Operators.Next('$'),
+	})
+	r.st.PushBack(&opFrame{
+		ch: common.AugChar{
+			C: '$',
+			Loc: common.Location{
+				File: "file",
+				B:    common.FilePos{L: 3, C: 2},
+				E:    common.FilePos{L: 3, C: 3},
+			},
+		},
+		loc: common.Location{
+			File: "file",
+			B:    common.FilePos{L: 3, C: 2},
+			E:    common.FilePos{L: 3, C: 3},
+		},
+		node: opts.Prof.Operators.Next('$').Next('$'),
+	})
+
+	r.emit()
+
+	a.Equal(s, l.s)
+	a.Equal(1, l.tokens.Len())
+	tok := l.tokens.Front().Value.(*common.Token)
+	a.Equal(common.TokError, tok.Sym)
+	a.True(errors.Is(tok.Val.(error), common.ErrBadOp))
+	a.Equal(common.FilePos{L: 3, C: 2}, s.Next().Loc.B)
+}
+
+func TestRecognizeOperatorEmitCloseMismatchRecovering(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader(""))
+	s, _ := scanner.Scan(opts)
+	l := &lexer{
+		s:     s,
+		opts:  opts,
+		recov: true,
+	}
+	l.indent.PushBack(1)
+	l.pair.PushBack(&common.Token{
+		Sym: &common.Symbol{Name: "(", Close: ")"},
+		Loc: common.Location{
+			File: "file",
+			B:    common.FilePos{L: 1, C: 1},
+			E:    common.FilePos{L: 1, C: 2},
+		},
+		Val: "(",
+	})
+	l.pair.PushBack(&common.Token{
+		Sym: &common.Symbol{Name: "[", Close: "]"},
+		Loc: common.Location{
+			File: "file",
+			B:    common.FilePos{L: 1, C: 2},
+			E:    common.FilePos{L: 1, C: 3},
+		},
+		Val: "[",
+	})
+	r := &recognizeOperator{l: l}
+	r.st.PushBack(&opFrame{
+		ch: common.AugChar{
+			C: ')',
+			Loc: common.Location{
+				File: "file",
+				B:    common.FilePos{L: 1, C: 3},
+				E:    common.FilePos{L: 1, C: 4},
+			},
+		},
+		loc: common.Location{
+			File: "file",
+			B:    common.FilePos{L: 1, C: 3},
+			E:    common.FilePos{L: 1, C: 4},
+		},
+		node: opts.Prof.Operators.Next(')'),
+	})
+
+	r.emit()
+
+	a.Equal(s, l.s)
+	a.Equal(0, l.pair.Len())
+	a.Equal(2, l.tokens.Len())
+	tok := l.tokens.Front().Value.(*common.Token)
+	a.Equal(common.TokError, tok.Sym)
+	a.True(errors.Is(tok.Val.(error), common.ErrMismatchedOp))
+	tok = l.tokens.Back().Value.(*common.Token)
+	a.Equal(")", tok.Sym.Name)
+}
+
+func TestRecognizeOperatorEmitCloseMismatchRecoveringNoMatch(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader(""))
+	s, _ := scanner.Scan(opts)
+	l := &lexer{
+		s:     s,
+		opts:  opts,
+		recov: true,
+	}
+	l.indent.PushBack(1)
+	l.pair.PushBack(&common.Token{
+		Sym: &common.Symbol{Name: "[", Close: "]"},
+		Loc: common.Location{
+			File: "file",
+			B:    common.FilePos{L: 1, C: 1},
+			E:    common.FilePos{L: 1, C: 2},
+		},
+		Val: "[",
+	})
+	r := &recognizeOperator{l: l}
+	r.st.PushBack(&opFrame{
+		ch: common.AugChar{
+			C: ')',
+			Loc: common.Location{
+				File: "file",
+				B:    common.FilePos{L: 1, C: 2},
+				E:    common.FilePos{L: 1, C: 3},
+			},
+		},
+		loc: common.Location{
+			File: "file",
+			B:    common.FilePos{L: 1, C: 2},
+			E:    common.FilePos{L: 1, C: 3},
+		},
+		node: opts.Prof.Operators.Next(')'),
+	})
+
+	r.emit()
+
+	a.Equal(s, l.s)
+	a.Equal(1, l.pair.Len())
+	a.Equal(1, l.tokens.Len())
+	tok := l.tokens.Front().Value.(*common.Token)
+	a.Equal(common.TokError, tok.Sym)
+	a.True(errors.Is(tok.Val.(error), common.ErrMismatchedOp))
+}
+
+func TestRecognizeOperatorRecognizeAlias(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("≤"))
+	opts.Prof = testProfile.Copy()
+	le := opts.Prof.Operators.Next('<').Next('=').Sym
+	opts.Prof.Operators.AddAlias("≤", le)
+	s, _ := scanner.Scan(opts)
+	l := &lexer{
+		s:    s,
+		opts: opts,
+	}
+	l.indent.PushBack(1)
+	r := &recognizeOperator{
+		l:    l,
+		node: opts.Prof.Operators,
+	}
+	ch := s.Next()
+
+	r.Recognize(ch)
+
+	a.Equal(s, l.s)
+	a.Equal(1, l.tokens.Len())
+	a.Equal(&common.Token{
+		Sym: le,
+		Loc: common.Location{
+			File: "file",
+			B:    common.FilePos{L: 1, C: 1},
+			E:    common.FilePos{L: 1, C: 2},
+		},
+		Val: "<=",
+	}, l.tokens.Front().Value.(*common.Token))
+	ch = s.Next()
+	a.Equal(common.EOF, ch.C)
+}
+
+func TestRecognizeOperatorRecognizeAliasWarn(t *testing.T) {
+	a := assert.New(t)
+	warnings := []*common.Diagnostic{}
+	opts := makeWarnOptions(strings.NewReader("≤"), &warnings)
+	le := opts.Prof.Operators.Next('<').Next('=').Sym
+	opts.Prof.Operators.AddAlias("≤", le)
+	s, _ := scanner.Scan(opts)
+	l := &lexer{
+		s:    s,
+		opts: opts,
+	}
+	l.indent.PushBack(1)
+	r := &recognizeOperator{
+		l:    l,
+		node: opts.Prof.Operators,
+	}
+	ch := s.Next()
+
+	r.Recognize(ch)
+
+	a.Equal(1, l.tokens.Len())
+	a.Equal(le, l.tokens.Front().Value.(*common.Token).Sym)
+	a.Len(warnings, 1)
+	a.Equal(common.CodeNonASCIIOpWarn, warnings[0].Code)
+	a.Equal(common.Location{
+		File: "file",
+		B:    common.FilePos{L: 1, C: 1},
+		E:    common.FilePos{L: 1, C: 2},
+	}, warnings[0].Loc)
+}
+
+func TestRecognizeOperatorRecognizeAliasASCIIOps(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("≤"))
+	opts.Prof = testProfile.Copy()
+	opts.ASCIIOps = true
+	le := opts.Prof.Operators.Next('<').Next('=').Sym
+	opts.Prof.Operators.AddAlias("≤", le)
+	s, _ := scanner.Scan(opts)
+	l := &lexer{
+		s:    s,
+		opts: opts,
+	}
+	l.indent.PushBack(1)
+	r := &recognizeOperator{
+		l:    l,
+		node: opts.Prof.Operators,
+	}
+	ch := s.Next()
+
+	r.Recognize(ch)
+
+	a.Nil(l.s)
+	a.Equal(1, l.tokens.Len())
+	tok := l.tokens.Front().Value.(*common.Token)
+	a.Equal(common.TokError, tok.Sym)
+	a.True(errors.Is(tok.Val.(error), common.ErrNonASCIIOp))
+}
+
+func TestIsASCIITrue(t *testing.T) {
+	a := assert.New(t)
+
+	result := isASCII("<=")
+
+	a.True(result)
+}
+
+func TestIsASCIIFalse(t *testing.T) {
+	a := assert.New(t)
+
+	result := isASCII("<≤")
+
+	a.False(result)
+}
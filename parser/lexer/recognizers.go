@@ -18,21 +18,94 @@ import (
 	"github.com/hydralang/hydra/parser/common"
 )
 
-// Recognizer is a type describing recognizers.  A recognizer is
-// initialized with the lexer object and implements the logic
-// necessary to recognize a sequence of characters from the scanner.
-//
-// Note: some recognizers implement additional state; for instance,
-// the string recognizer has state designed to interact with the
-// recognizer for identifiers, to allow string flags to be recognized
-// and processed.
-type Recognizer interface {
-	// Recognize is called to recognize a lexical construct.  Will
-	// be called with the first character, and should push zero or
-	// more tokens onto the lexer's tokens queue.
-	Recognize(ch common.AugChar)
+// Built-in recognizers.  Defining these as variables enables the
+// recognizers to be mocked out for testing purposes.  Note that the
+// built-in recognizers rely on the internal state of the lexer; for
+// instance, the string recognizer has state designed to interact
+// with the recognizer for identifiers, to allow string flags to be
+// recognized and processed.  They must therefore be initialized with
+// the lexer itself, rather than some other LexContext.
+var (
+	rComment common.RecogInit = recogComment
+	rNumber  common.RecogInit = recogNumber
+	rIdent   common.RecogInit = recogIdentifier
+	rString  common.RecogInit = recogString
+	rOp      common.RecogInit = recogOperator
+)
+
+// Priorities of the built-in recognizers.  The priorities are spaced
+// out, so that a dialect may insert recognizers between them.
+const (
+	PrioPeriod  = 100 // Numbers beginning with a period, like ".5"
+	PrioComment = 200 // Comments
+	PrioNumber  = 300 // Numbers
+	PrioIdent   = 400 // Identifiers and keywords
+	PrioString  = 500 // Strings
+	PrioOp      = 600 // Operators
+)
+
+// periodNumber is a prefix predicate for a number beginning with a
+// period, such as ".5".
+func periodNumber(ch common.AugChar, s common.Scanner) bool {
+	if ch.C != '.' {
+		return false
+	}
+
+	// Check if the next character is a decimal digit
+	next := s.Next()
+	s.Push(next)
+	return next.Class&common.CharDecDigit != 0
+}
+
+// operator is a prefix predicate for an operator.  Operators are made
+// up of characters that have no other class.
+func operator(ch common.AugChar, s common.Scanner) bool {
+	return ch.Class == 0
 }
 
-// RecogInit is a function that initializes a recognizer.  It will be
-// passed the lexer object, and must return a Recognizer.
-type RecogInit func(l *lexer) Recognizer
+// DefaultRecognizers constructs a registry containing the built-in
+// recognizers, which the lexer uses if the profile has no registry.
+// A dialect may add its own recognizers to the returned registry and
+// set it in the profile.  The built-in recognizers are named
+// "period", "comment", "number", "identifier", "string", and
+// "operator", with the priorities given by the Prio constants.
+func DefaultRecognizers() *common.Recognizers {
+	return common.NewRecognizers(
+		&common.RecogEntry{
+			Name:     "period",
+			Priority: PrioPeriod,
+			Match:    periodNumber,
+			Init:     rNumber,
+		},
+		&common.RecogEntry{
+			Name:     "comment",
+			Priority: PrioComment,
+			Class:    common.CharComment,
+			Init:     rComment,
+		},
+		&common.RecogEntry{
+			Name:     "number",
+			Priority: PrioNumber,
+			Class:    common.CharDecDigit,
+			Init:     rNumber,
+		},
+		&common.RecogEntry{
+			Name:     "identifier",
+			Priority: PrioIdent,
+			Class:    common.CharIDStart,
+			Init:     rIdent,
+		},
+		&common.RecogEntry{
+			Name:     "string",
+			Priority: PrioString,
+			Class:    common.CharQuote,
+			Init:     rString,
+		},
+		&common.RecogEntry{
+			Name:     "operator",
+			Priority: PrioOp,
+			Match:    operator,
+			Init:     rOp,
+		},
+	)
+}
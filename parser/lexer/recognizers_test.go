@@ -24,16 +24,16 @@ import (
 )
 
 func TestMockRecognizerImplementsRecognizer(t *testing.T) {
-	assert.Implements(t, (*Recognizer)(nil), &mockRecognizer{})
+	assert.Implements(t, (*common.Recognizer)(nil), &mockRecognizer{})
 }
 
 type mockRecognizer struct {
 	mock.Mock
-	l *lexer
+	lc common.LexContext
 }
 
-func (m *mockRecognizer) RecogMock(l *lexer) Recognizer {
-	m.l = l
+func (m *mockRecognizer) RecogMock(lc common.LexContext) common.Recognizer {
+	m.lc = lc
 
 	return m
 }
@@ -43,7 +43,7 @@ func (m *mockRecognizer) Recognize(ch common.AugChar) {
 
 	tmpTok := args.Get(0)
 	if tmpTok != nil {
-		m.l.pushTok(
+		m.lc.PushTok(
 			tmpTok.(*common.Symbol),
 			args.Get(1).(common.Location),
 			args.Get(2),
@@ -60,11 +60,11 @@ type mockRecs struct {
 }
 
 type saveRecs struct {
-	rComment RecogInit
-	rNumber  RecogInit
-	rIdent   RecogInit
-	rString  RecogInit
-	rOp      RecogInit
+	rComment common.RecogInit
+	rNumber  common.RecogInit
+	rIdent   common.RecogInit
+	rString  common.RecogInit
+	rOp      common.RecogInit
 }
 
 func newMockRecs() *mockRecs {
@@ -110,3 +110,68 @@ func (mr *mockRecs) AssertExpectations(t *testing.T) {
 	mr.rString.AssertExpectations(t)
 	mr.rOp.AssertExpectations(t)
 }
+
+func TestDefaultRecognizers(t *testing.T) {
+	a := assert.New(t)
+
+	result := DefaultRecognizers()
+
+	names := []string{}
+	for _, entry := range result.All() {
+		names = append(names, entry.Name)
+	}
+	a.Equal([]string{"period", "comment", "number", "identifier", "string", "operator"}, names)
+	a.False(result.Frozen())
+}
+
+func TestPeriodNumber(t *testing.T) {
+	a := assert.New(t)
+	s := &common.MockScanner{}
+	next := common.AugChar{C: '5', Class: common.CharDecDigit}
+	s.On("Next").Return(next)
+	s.On("Push", next)
+
+	result := periodNumber(common.AugChar{C: '.'}, s)
+
+	a.True(result)
+	s.AssertExpectations(t)
+}
+
+func TestPeriodNumberOperator(t *testing.T) {
+	a := assert.New(t)
+	s := &common.MockScanner{}
+	next := common.AugChar{C: '.'}
+	s.On("Next").Return(next)
+	s.On("Push", next)
+
+	result := periodNumber(common.AugChar{C: '.'}, s)
+
+	a.False(result)
+	s.AssertExpectations(t)
+}
+
+func TestPeriodNumberOther(t *testing.T) {
+	a := assert.New(t)
+	s := &common.MockScanner{}
+
+	result := periodNumber(common.AugChar{C: '5', Class: common.CharDecDigit}, s)
+
+	a.False(result)
+	s.AssertExpectations(t)
+}
+
+func TestOperator(t *testing.T) {
+	a := assert.New(t)
+
+	result := operator(common.AugChar{C: '+'}, nil)
+
+	a.True(result)
+}
+
+func TestOperatorOther(t *testing.T) {
+	a := assert.New(t)
+
+	result := operator(common.AugChar{C: '$', Class: common.CharIDCont}, nil)
+
+	a.False(result)
+}
@@ -16,6 +16,7 @@ package lexer
 
 import (
 	"container/list"
+	"strings"
 
 	"github.com/hydralang/hydra/parser/common"
 	"github.com/hydralang/hydra/utils"
@@ -25,24 +26,43 @@ import (
 const (
 	SkipLeadFF uint8 = 1 << iota // Skip leading form feeds
 	SkipNL                       // Skip newlines as well
+	SkipCont                     // Skipping continuation line indent
 )
 
 // SkipFlags is a mapping of skip flags to names.
 var SkipFlags = utils.FlagSet8{
 	SkipLeadFF: "skip leading form feeds",
 	SkipNL:     "skip newlines",
+	SkipCont:   "continuation line",
 }
 
 // skipSpaces skips whitespace for the lexer.  It returns a boolean
 // indicating whether the whitespace was all one type, or whether it
 // was mixed (e.g., spaces and tabs).  Flags allow leading form feeds
 // to be ignored for the mixed-space calculation, and also can allow
-// newlines to be skipped.
+// newlines to be skipped.  The SkipCont flag indicates that the
+// whitespace begins a continuation line.  Warnings are reported for
+// trailing whitespace, form feeds in the middle of a line, and tabs
+// following spaces in the indentation of continuation lines.  When
+// leading form feeds are skipped, the whitespace is the indentation
+// of a line, and is saved for checking against the indentation
+// policy.
 func (l *lexer) skipSpaces(ch common.AugChar, flags uint8) (mixed bool) {
 	// Initialize the mixed space algorithm
 	lastChar := ch.C
 	mixed = false
 
+	// Initialize the warning state
+	lineStart := flags&SkipLeadFF != 0
+	cont := flags&SkipCont != 0
+	inRun := false
+	var run common.Location
+
+	// Initialize the indentation state
+	indent := flags&SkipLeadFF != 0
+	inLead := indent
+	var lead []rune
+
 	// Step through the whitespace
 	for ; ch.Class&common.CharWS != 0; ch = l.s.Next() {
 		// Skipping leading FF?
@@ -57,6 +77,34 @@ func (l *lexer) skipSpaces(ch common.AugChar, flags uint8) (mixed bool) {
 			flags &^= SkipLeadFF
 		}
 
+		// Check for trailing whitespace and mid-line form feeds
+		if ch.Class&common.CharNL != 0 {
+			if inRun {
+				l.warn(common.LintTrailingWS, run.Thru(ch.Loc), common.WarnTrailingWS)
+			}
+			inRun = false
+			inLead = false
+			lineStart = true
+			cont = flags&SkipNL != 0
+		} else {
+			if !inRun {
+				run = ch.Loc
+				inRun = true
+			}
+			if ch.C == '\f' && !lineStart {
+				l.warn(common.LintFormFeed, ch.Loc, common.WarnFormFeed)
+			} else if ch.C != '\f' {
+				lineStart = false
+			}
+			if cont && ch.C == '\t' && lastChar == ' ' {
+				l.warn(common.LintTabAfterSpace, ch.Loc, common.WarnTabAfterSpace)
+				cont = false
+			}
+			if inLead && ch.C != '\f' {
+				lead = append(lead, ch.C)
+			}
+		}
+
 		// Skipping newlines?
 		if ch.Class&common.CharNL != 0 && flags&SkipNL == 0 {
 			break
@@ -71,38 +119,333 @@ func (l *lexer) skipSpaces(ch common.AugChar, flags uint8) (mixed bool) {
 		lastChar = ch.C
 	}
 
+	// Save the indentation of the line
+	if indent {
+		l.lead = string(lead)
+	}
+
+	// Whitespace at the end of the file is also trailing
+	if inRun && ch.C == common.EOF {
+		l.warn(common.LintTrailingWS, run.Thru(ch.Loc), common.WarnTrailingWS)
+	}
+
 	// This character is not whitespace, so push it back
 	l.s.Push(ch)
 
 	return
 }
 
+// policy returns the indentation policy from the options.
+func (l *lexer) policy() common.IndentPolicy {
+	if l.opts == nil {
+		return common.IndentDefault
+	}
+
+	return l.opts.Indent
+}
+
+// leadColumn computes the column following the leading whitespace of
+// a line, given the size of a tab stop.
+func leadColumn(lead string, tabStop int) int {
+	loc := common.Location{E: common.FilePos{C: 1}}
+	for _, c := range lead {
+		if c == '\t' {
+			loc.AdvanceTab(tabStop)
+		} else {
+			loc.Advance(common.FilePos{C: 1})
+		}
+	}
+
+	return loc.E.C
+}
+
+// compareLeads compares the leading whitespace of two lines, given
+// the size of a tab stop.  Returns -1, 0, or 1 if the first is
+// shallower than, the same as, or deeper than the second.
+func compareLeads(a, b string, tabStop int) int {
+	colA := leadColumn(a, tabStop)
+	colB := leadColumn(b, tabStop)
+	if colA < colB {
+		return -1
+	} else if colA > colB {
+		return 1
+	}
+
+	return 0
+}
+
+// consistentLeads tests whether the leading whitespace of two lines
+// compares the same way regardless of the size of a tab stop, as the
+// Python tabnanny module does.  Beyond the length of the longer run
+// of whitespace, larger tab stops cannot change the comparison, so
+// only the tab stops up to that size must be checked.
+func consistentLeads(a, b string) bool {
+	limit := len(a)
+	if len(b) > limit {
+		limit = len(b)
+	}
+
+	cmp := compareLeads(a, b, 1)
+	for tabStop := 2; tabStop <= limit+2; tabStop++ {
+		if compareLeads(a, b, tabStop) != cmp {
+			return false
+		}
+	}
+
+	return true
+}
+
+// checkLeads checks that the indentation of the line is consistent
+// with the leading whitespace of the specified indentation levels.
+// Returns false if an error was reported.
+func (l *lexer) checkLeads(loc common.Location, leads ...string) bool {
+	for _, lead := range leads {
+		if !consistentLeads(l.lead, lead) {
+			l.pushErr(loc, common.ErrAmbiguousIndent)
+			return false
+		}
+	}
+
+	return true
+}
+
+// checkPolicy checks the indentation of the line against the
+// indentation policy, returning an error if it is not permitted.
+// The indentation must already have been skipped.
+func (l *lexer) checkPolicy(loc common.Location, mixed bool) error {
+	switch l.policy() {
+	case common.IndentSpaces:
+		if strings.ContainsRune(l.lead, '\t') {
+			return l.indentError(common.ErrTabIndent, loc, false)
+		}
+
+	case common.IndentTabs:
+		if strings.ContainsRune(l.lead, ' ') {
+			return l.indentError(common.ErrSpaceIndent, loc, true)
+		}
+
+	case common.IndentConsistent:
+		// Mixed indentation is checked by doIndent
+
+	default:
+		if mixed {
+			return l.indentError(common.ErrMixedIndent, loc, false)
+		}
+	}
+
+	return nil
+}
+
 // doIndent is the core routine that manages indentation tracking.  It
 // will push TokIndent and TokDedent tokens onto the token stack as
-// appropriate, depending on the indentation level.
-func (l *lexer) doIndent(col int, loc common.Location) {
+// appropriate, depending on the indentation level.  If the policy
+// requires consistent indentation, the leading whitespace of each
+// level is tracked as well, and the indentation of the line is
+// checked against it.  Returns false if an error was reported and the
+// lexer is not recovering, in which case the line's token must not be
+// queued.
+func (l *lexer) doIndent(col int, loc common.Location) bool {
+	consistent := l.policy() == common.IndentConsistent && l.leads.Len() == l.indent.Len()
+
 	// Handle the simple cases first
 	curCol := l.indent.Back().Value.(int)
 	if col == curCol {
 		// Same column
-		return
+		if consistent && !l.checkLeads(loc, l.leads.Back().Value.(string)) {
+			return l.recov
+		}
+		return true
 	} else if col > curCol {
 		// Deeper indentation
+		if consistent {
+			if !l.checkLeads(loc, l.leads.Back().Value.(string)) && !l.recov {
+				return false
+			}
+			l.leads.PushBack(l.lead)
+		}
 		l.pushTok(common.TokIndent, loc, nil)
 		l.indent.PushBack(col)
-		return
+		return true
 	}
 
 	// Shallower indentation; produce one or more dedents to get
 	// back to that point
 	var elem *list.Element
+	var lastCol int
+	var lastLead string
 	for elem = l.indent.Back(); elem.Value.(int) > col; elem = l.indent.Back() {
 		l.pushTok(common.TokDedent, loc, nil)
+		lastCol = elem.Value.(int)
 		l.indent.Remove(elem)
+		if consistent {
+			lastLead = l.leads.Remove(l.leads.Back()).(string)
+		}
 	}
 
 	// Produce an error if there's inconsistent indentation
 	if elem.Value.(int) != col {
-		l.pushErr(loc, common.ErrBadIndent)
+		l.pushErr(loc, common.ErrIndentMismatch(col, elem.Value.(int), lastCol))
+		return l.recov
+	} else if consistent && !l.checkLeads(loc, lastLead, l.leads.Back().Value.(string)) {
+		return l.recov
+	}
+
+	return true
+}
+
+// contPolicy returns the line continuation policy from the profile.
+func (l *lexer) contPolicy() common.ContPolicy {
+	if l.opts == nil || l.opts.Prof == nil {
+		return common.ContStrict
+	}
+
+	return l.opts.Prof.Continuation
+}
+
+// infixCont tests whether the logical line continues implicitly onto
+// the next line, because the last token is an infix operator and the
+// profile permits implicit continuation.
+func (l *lexer) infixCont() bool {
+	if l.opts == nil || l.opts.Prof == nil || !l.opts.Prof.InfixCont {
+		return false
+	}
+
+	tok := l.lastTok()
+	if tok == nil {
+		return false
+	}
+	binding, ok := l.opts.Prof.Bindings[tok.Sym]
+	return ok && binding.Fixity&common.FixInfix != 0
+}
+
+// afterBackslash skips the whitespace and comment that the lenient
+// continuation policy permits to follow a backslash, returning the
+// first character following them.  Trailing whitespace, not followed
+// by a comment, is reported with a warning.
+func (l *lexer) afterBackslash(ch common.AugChar) common.AugChar {
+	// Skip whitespace
+	inWS := false
+	var ws common.Location
+	for ; ch.Class&common.CharWS != 0 && ch.Class&common.CharNL == 0; ch = l.s.Next() {
+		if !inWS {
+			ws = ch.Loc
+			inWS = true
+		}
+	}
+
+	// Skip a comment, or warn about trailing whitespace
+	if ch.Class&common.CharComment != 0 {
+		for ch.C != common.EOF && ch.C != common.Err && ch.Class&common.CharNL == 0 {
+			ch = l.s.Next()
+		}
+	} else if inWS && ch.C == '\n' {
+		l.warn(common.LintBackslashWS, ws.Thru(ch.Loc), common.WarnBackslashWS)
+	}
+
+	return ch
+}
+
+// contLine skips the indentation of a continuation line.  Tabs
+// following spaces in the indentation are reported with a warning.
+func (l *lexer) contLine() {
+	ch := l.s.Next()
+	if ch.Class&common.CharWS != 0 && ch.Class&common.CharNL == 0 {
+		l.skipSpaces(ch, SkipCont)
+	} else {
+		l.s.Push(ch)
+	}
+}
+
+// space handles newlines, whitespace, and backslash continuations,
+// subject to the flags of the active mode.  Returns true if the
+// character was handled, or false if it must be passed to a
+// recognizer.
+func (l *lexer) space(ch common.AugChar, flags uint8) bool {
+	// Handle newlines and whitespace
+	if ch.Class&common.CharNL != 0 && flags&common.ModeStopNL != 0 {
+		// The mode's recognizers handle the newline
+		return false
+	} else if ch.Class&common.CharNL != 0 && l.pair.Len() == 0 && flags&common.ModeIgnoreNL == 0 {
+		// Continue the line after an infix operator
+		if l.infixCont() {
+			l.contLine()
+			return true
+		}
+
+		// Generate a newline token
+		l.lead = ""
+		l.pushTok(common.TokNewline, ch.Loc, nil)
+		return true
+	} else if ch.Class&common.CharWS != 0 {
+		// Are we concerned about mixed spaces?
+		errMixed := false
+
+		// Set up the skipSpaces flags
+		var skip uint8
+		if flags&common.ModeStopNL == 0 && (l.pair.Len() > 0 || flags&common.ModeIgnoreNL != 0) {
+			skip = SkipNL
+		} else if flags&common.ModeNoIndent == 0 {
+			prevTok := l.lastTok()
+			if prevTok == nil || prevTok.Sym == common.TokNewline {
+				skip = SkipLeadFF
+				errMixed = true
+			}
+		}
+
+		// Skip the whitespace
+		mixed := l.skipSpaces(ch, skip)
+
+		// Check the indentation against the policy
+		if errMixed {
+			if err := l.checkPolicy(ch.Loc, mixed); err != nil {
+				l.pushErr(ch.Loc, err)
+			}
+		}
+
+		return true
 	}
+
+	// Handle backslash continuation
+	if ch.C == '\\' {
+		bs := ch
+
+		// Is backslash continuation allowed?
+		policy := l.contPolicy()
+		if policy == common.ContNone {
+			l.pushErr(bs.Loc, common.ErrBackslashCont)
+			return true
+		}
+
+		// Get next character and make sure it's
+		// newline
+		ch = l.s.Next()
+		if policy == common.ContLenient {
+			ch = l.afterBackslash(ch)
+		}
+		if ch.C == common.Err {
+			// Hmm, got an error
+			l.pushErr(ch.Loc, ch.Val.(error))
+			return true
+		} else if ch.C != '\n' {
+			l.pushErr(ch.Loc, common.ErrDanglingBackslash)
+
+			// If recovering, reprocess the character
+			if l.recov {
+				l.s.Push(ch)
+			}
+			return true
+		}
+
+		// Backslash is redundant inside brackets
+		if l.pair.Len() > 0 {
+			l.warn(common.LintBackslash, bs.Loc, common.WarnBackslash)
+		}
+
+		// Check the indentation of the continuation line
+		l.contLine()
+
+		return true
+	}
+
+	return false
 }
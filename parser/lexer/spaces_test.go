@@ -15,6 +15,7 @@
 package lexer
 
 import (
+	"errors"
 	"strings"
 	"testing"
 
@@ -115,6 +116,169 @@ func TestLexerSkipSpacesNewlineSkipped(t *testing.T) {
 	a.Equal('c', next.C)
 }
 
+func TestLexerSkipSpacesTrailing(t *testing.T) {
+	a := assert.New(t)
+	var warnings []*common.Diagnostic
+	opts := makeWarnOptions(strings.NewReader("  \t\nc"), &warnings)
+	s, _ := scanner.Scan(opts)
+	l := &lexer{s: s, opts: opts}
+
+	l.skipSpaces(s.Next(), 0)
+
+	a.Equal('\n', s.Next().C)
+	a.Equal([]*common.Diagnostic{
+		common.Warning(common.WarnTrailingWS, common.Location{
+			File: "file",
+			B:    common.FilePos{L: 1, C: 1},
+			E:    common.FilePos{L: 1, C: 9},
+		}),
+	}, warnings)
+}
+
+func TestLexerSkipSpacesTrailingEOF(t *testing.T) {
+	a := assert.New(t)
+	var warnings []*common.Diagnostic
+	opts := makeWarnOptions(strings.NewReader("  "), &warnings)
+	s, _ := scanner.Scan(opts)
+	l := &lexer{s: s, opts: opts}
+
+	l.skipSpaces(s.Next(), 0)
+
+	a.Equal(common.EOF, s.Next().C)
+	a.Equal([]*common.Diagnostic{
+		common.Warning(common.WarnTrailingWS, common.Location{
+			File: "file",
+			B:    common.FilePos{L: 1, C: 1},
+			E:    common.FilePos{L: 1, C: 3},
+		}),
+	}, warnings)
+}
+
+func TestLexerSkipSpacesTrailingSkipped(t *testing.T) {
+	a := assert.New(t)
+	var warnings []*common.Diagnostic
+	opts := makeWarnOptions(strings.NewReader(" \n  \n c"), &warnings)
+	s, _ := scanner.Scan(opts)
+	l := &lexer{s: s, opts: opts}
+
+	l.skipSpaces(s.Next(), SkipNL)
+
+	a.Equal('c', s.Next().C)
+	a.Equal([]*common.Diagnostic{
+		common.Warning(common.WarnTrailingWS, common.Location{
+			File: "file",
+			B:    common.FilePos{L: 1, C: 1},
+			E:    common.FilePos{L: 1, C: 2},
+		}),
+		common.Warning(common.WarnTrailingWS, common.Location{
+			File: "file",
+			B:    common.FilePos{L: 2, C: 1},
+			E:    common.FilePos{L: 2, C: 3},
+		}),
+	}, warnings)
+}
+
+func TestLexerSkipSpacesNotTrailing(t *testing.T) {
+	a := assert.New(t)
+	var warnings []*common.Diagnostic
+	opts := makeWarnOptions(strings.NewReader("  # c\n"), &warnings)
+	s, _ := scanner.Scan(opts)
+	l := &lexer{s: s, opts: opts}
+
+	l.skipSpaces(s.Next(), 0)
+
+	a.Equal('#', s.Next().C)
+	a.Nil(warnings)
+}
+
+func TestLexerSkipSpacesFormFeed(t *testing.T) {
+	a := assert.New(t)
+	var warnings []*common.Diagnostic
+	opts := makeWarnOptions(strings.NewReader("  \f c"), &warnings)
+	s, _ := scanner.Scan(opts)
+	l := &lexer{s: s, opts: opts}
+
+	l.skipSpaces(s.Next(), 0)
+
+	a.Equal('c', s.Next().C)
+	a.Equal([]*common.Diagnostic{
+		common.Warning(common.WarnFormFeed, common.Location{
+			File: "file",
+			B:    common.FilePos{L: 1, C: 3},
+			E:    common.FilePos{L: 1, C: 4},
+		}),
+	}, warnings)
+}
+
+func TestLexerSkipSpacesLeadingFormFeed(t *testing.T) {
+	a := assert.New(t)
+	var warnings []*common.Diagnostic
+	opts := makeWarnOptions(strings.NewReader("\f\f c"), &warnings)
+	s, _ := scanner.Scan(opts)
+	l := &lexer{s: s, opts: opts}
+
+	l.skipSpaces(s.Next(), SkipLeadFF)
+
+	a.Equal('c', s.Next().C)
+	a.Nil(warnings)
+}
+
+func TestLexerSkipSpacesTabAfterSpace(t *testing.T) {
+	a := assert.New(t)
+	var warnings []*common.Diagnostic
+	opts := makeWarnOptions(strings.NewReader("  \t \tc"), &warnings)
+	s, _ := scanner.Scan(opts)
+	l := &lexer{s: s, opts: opts}
+
+	l.skipSpaces(s.Next(), SkipCont)
+
+	a.Equal('c', s.Next().C)
+	a.Equal([]*common.Diagnostic{
+		common.Warning(common.WarnTabAfterSpace, common.Location{
+			File: "file",
+			B:    common.FilePos{L: 1, C: 3},
+			E:    common.FilePos{L: 1, C: 9},
+		}),
+	}, warnings)
+}
+
+func TestLexerSkipSpacesTabAfterSpaceSkipped(t *testing.T) {
+	a := assert.New(t)
+	var warnings []*common.Diagnostic
+	opts := makeWarnOptions(strings.NewReader(" \t\n \tc"), &warnings)
+	s, _ := scanner.Scan(opts)
+	l := &lexer{s: s, opts: opts}
+
+	l.skipSpaces(s.Next(), SkipNL)
+
+	a.Equal('c', s.Next().C)
+	a.Equal([]*common.Diagnostic{
+		common.Warning(common.WarnTrailingWS, common.Location{
+			File: "file",
+			B:    common.FilePos{L: 1, C: 1},
+			E:    common.FilePos{L: 1, C: 9},
+		}),
+		common.Warning(common.WarnTabAfterSpace, common.Location{
+			File: "file",
+			B:    common.FilePos{L: 2, C: 2},
+			E:    common.FilePos{L: 2, C: 9},
+		}),
+	}, warnings)
+}
+
+func TestLexerSkipSpacesTabAfterSpaceNotCont(t *testing.T) {
+	a := assert.New(t)
+	var warnings []*common.Diagnostic
+	opts := makeWarnOptions(strings.NewReader(" \tc"), &warnings)
+	s, _ := scanner.Scan(opts)
+	l := &lexer{s: s, opts: opts}
+
+	l.skipSpaces(s.Next(), 0)
+
+	a.Equal('c', s.Next().C)
+	a.Nil(warnings)
+}
+
 func TestDoIndentSameColumn(t *testing.T) {
 	a := assert.New(t)
 	loc := common.Location{
@@ -225,6 +389,813 @@ func TestDoIndentShallowerColumnBadIndent(t *testing.T) {
 	a.Equal(&common.Token{
 		Sym: common.TokError,
 		Loc: loc,
-		Val: common.ErrBadIndent,
+		Val: common.Diagnose(common.ErrIndentMismatch(4, 1, 5), loc),
 	}, elem.Value.(*common.Token))
 }
+
+func TestLexerSpaceNewline(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("\nb"))
+	s, _ := scanner.Scan(opts)
+	l := &lexer{s: s, prevTok: &common.Token{Sym: common.TokIdent}}
+	l.indent.PushBack(1)
+
+	result := l.space(s.Next(), 0)
+
+	a.True(result)
+	a.Equal(1, l.tokens.Len())
+	a.Equal(common.TokNewline, l.tokens.Front().Value.(*common.Token).Sym)
+}
+
+func TestLexerSpaceIgnoreNL(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("\n  b"))
+	s, _ := scanner.Scan(opts)
+	l := &lexer{s: s, prevTok: &common.Token{Sym: common.TokIdent}}
+	l.indent.PushBack(1)
+
+	result := l.space(s.Next(), common.ModeIgnoreNL)
+
+	a.True(result)
+	a.Equal(0, l.tokens.Len())
+	next := s.Next()
+	a.Equal('b', next.C)
+}
+
+func TestLexerSpaceStopNL(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("\nb"))
+	s, _ := scanner.Scan(opts)
+	l := &lexer{s: s, prevTok: &common.Token{Sym: common.TokIdent}}
+	l.indent.PushBack(1)
+	l.pair.PushBack(&common.Token{Sym: common.TokFExprStart})
+
+	result := l.space(s.Next(), common.ModeStopNL|common.ModeNoIndent)
+
+	a.False(result)
+	a.Equal(0, l.tokens.Len())
+}
+
+func TestLexerSpaceStopNLSpaces(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("  \nb"))
+	s, _ := scanner.Scan(opts)
+	l := &lexer{s: s, prevTok: &common.Token{Sym: common.TokIdent}}
+	l.indent.PushBack(1)
+	l.pair.PushBack(&common.Token{Sym: common.TokFExprStart})
+
+	result := l.space(s.Next(), common.ModeStopNL|common.ModeNoIndent)
+
+	a.True(result)
+	a.Equal(0, l.tokens.Len())
+	next := s.Next()
+	a.Equal('\n', next.C)
+}
+
+func TestLexerSpaceNoIndentMixed(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader(" \tb"))
+	s, _ := scanner.Scan(opts)
+	l := &lexer{s: s, prevTok: &common.Token{Sym: common.TokNewline}}
+	l.indent.PushBack(1)
+
+	result := l.space(s.Next(), common.ModeNoIndent)
+
+	a.True(result)
+	a.Equal(0, l.tokens.Len())
+	next := s.Next()
+	a.Equal('b', next.C)
+}
+
+func TestLexerSpaceOther(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("b"))
+	s, _ := scanner.Scan(opts)
+	l := &lexer{s: s}
+	l.indent.PushBack(1)
+
+	result := l.space(s.Next(), 0)
+
+	a.False(result)
+	a.Equal(0, l.tokens.Len())
+}
+
+func TestLexerSkipSpacesLead(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("\f\t  c"))
+	s, _ := scanner.Scan(opts)
+	l := &lexer{s: s}
+
+	l.skipSpaces(s.Next(), SkipLeadFF)
+
+	a.Equal("\t  ", l.lead)
+}
+
+func TestLexerSkipSpacesLeadNewline(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("  \n\tc"))
+	s, _ := scanner.Scan(opts)
+	l := &lexer{s: s}
+
+	l.skipSpaces(s.Next(), SkipLeadFF|SkipNL)
+
+	a.Equal("  ", l.lead)
+}
+
+func TestLexerSkipSpacesLeadNotIndent(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("  c"))
+	s, _ := scanner.Scan(opts)
+	l := &lexer{s: s, lead: "\t"}
+
+	l.skipSpaces(s.Next(), 0)
+
+	a.Equal("\t", l.lead)
+}
+
+func TestLexerPolicy(t *testing.T) {
+	a := assert.New(t)
+	l := &lexer{opts: &common.Options{Indent: common.IndentTabs}}
+
+	result := l.policy()
+
+	a.Equal(common.IndentTabs, result)
+}
+
+func TestLexerPolicyNoOptions(t *testing.T) {
+	a := assert.New(t)
+	l := &lexer{}
+
+	result := l.policy()
+
+	a.Equal(common.IndentDefault, result)
+}
+
+func TestLeadColumn(t *testing.T) {
+	a := assert.New(t)
+
+	a.Equal(1, leadColumn("", 8))
+	a.Equal(5, leadColumn("    ", 8))
+	a.Equal(9, leadColumn("\t", 8))
+	a.Equal(9, leadColumn("  \t", 8))
+	a.Equal(13, leadColumn("\t    ", 8))
+	a.Equal(5, leadColumn("\t", 4))
+}
+
+func TestCompareLeads(t *testing.T) {
+	a := assert.New(t)
+
+	a.Equal(-1, compareLeads("  ", "\t", 8))
+	a.Equal(0, compareLeads("        ", "\t", 8))
+	a.Equal(1, compareLeads("\t ", "\t", 8))
+}
+
+func TestConsistentLeads(t *testing.T) {
+	a := assert.New(t)
+
+	a.True(consistentLeads("\t", "\t"))
+	a.True(consistentLeads("\t  ", "\t"))
+	a.True(consistentLeads("\t\t", "\t"))
+	a.True(consistentLeads("", "   "))
+}
+
+func TestConsistentLeadsAmbiguous(t *testing.T) {
+	a := assert.New(t)
+
+	a.False(consistentLeads("        ", "\t"))
+	a.False(consistentLeads("    ", "\t"))
+	a.False(consistentLeads("\t", "  \t"))
+}
+
+func TestLexerCheckLeads(t *testing.T) {
+	a := assert.New(t)
+	loc := common.Location{
+		File: "file",
+		B:    common.FilePos{L: 3, C: 2},
+		E:    common.FilePos{L: 3, C: 3},
+	}
+	l := &lexer{lead: "\t  "}
+
+	result := l.checkLeads(loc, "", "\t")
+
+	a.True(result)
+	a.Equal(0, l.tokens.Len())
+}
+
+func TestLexerCheckLeadsAmbiguous(t *testing.T) {
+	a := assert.New(t)
+	loc := common.Location{
+		File: "file",
+		B:    common.FilePos{L: 3, C: 2},
+		E:    common.FilePos{L: 3, C: 3},
+	}
+	l := &lexer{lead: "    ", recov: true}
+
+	result := l.checkLeads(loc, "\t", "        ")
+
+	a.False(result)
+	a.Equal(1, l.tokens.Len())
+	a.Equal(&common.Token{
+		Sym: common.TokError,
+		Loc: loc,
+		Val: common.Diagnose(common.ErrAmbiguousIndent, loc),
+	}, l.tokens.Front().Value.(*common.Token))
+}
+
+func TestLexerCheckPolicyDefault(t *testing.T) {
+	a := assert.New(t)
+	l := &lexer{lead: "\t"}
+
+	result := l.checkPolicy(common.Location{}, false)
+
+	a.NoError(result)
+}
+
+func TestLexerCheckPolicyDefaultMixed(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader(" \tb"))
+	s, _ := scanner.Scan(opts)
+	ch := s.Next()
+	l := &lexer{s: s, opts: opts}
+	l.skipSpaces(ch, SkipLeadFF)
+
+	result := l.checkPolicy(ch.Loc, true)
+
+	a.True(errors.Is(result, common.ErrMixedIndent))
+}
+
+func TestLexerCheckPolicySpaces(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("  b"))
+	opts.Indent = common.IndentSpaces
+	s, _ := scanner.Scan(opts)
+	ch := s.Next()
+	l := &lexer{s: s, opts: opts}
+	l.skipSpaces(ch, SkipLeadFF)
+
+	result := l.checkPolicy(ch.Loc, false)
+
+	a.NoError(result)
+}
+
+func TestLexerCheckPolicySpacesTab(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("\tb"))
+	opts.Indent = common.IndentSpaces
+	s, _ := scanner.Scan(opts)
+	ch := s.Next()
+	l := &lexer{s: s, opts: opts}
+	l.skipSpaces(ch, SkipLeadFF)
+
+	result := l.checkPolicy(ch.Loc, false)
+
+	a.True(errors.Is(result, common.ErrTabIndent))
+	a.Equal("        ", result.(*common.Diagnostic).Fixes[0].Edits[0].Text)
+}
+
+func TestLexerCheckPolicyTabs(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("\t\tb"))
+	opts.Indent = common.IndentTabs
+	s, _ := scanner.Scan(opts)
+	ch := s.Next()
+	l := &lexer{s: s, opts: opts}
+	l.skipSpaces(ch, SkipLeadFF)
+
+	result := l.checkPolicy(ch.Loc, false)
+
+	a.NoError(result)
+}
+
+func TestLexerCheckPolicyTabsSpace(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("        b"))
+	opts.Indent = common.IndentTabs
+	s, _ := scanner.Scan(opts)
+	ch := s.Next()
+	l := &lexer{s: s, opts: opts}
+	l.skipSpaces(ch, SkipLeadFF)
+
+	result := l.checkPolicy(ch.Loc, false)
+
+	a.True(errors.Is(result, common.ErrSpaceIndent))
+	a.Equal("\t", result.(*common.Diagnostic).Fixes[0].Edits[0].Text)
+}
+
+func TestLexerCheckPolicyConsistent(t *testing.T) {
+	a := assert.New(t)
+	l := &lexer{opts: &common.Options{Indent: common.IndentConsistent}, lead: " \t"}
+
+	result := l.checkPolicy(common.Location{}, true)
+
+	a.NoError(result)
+}
+
+func TestDoIndentConsistentSame(t *testing.T) {
+	a := assert.New(t)
+	loc := common.Location{
+		File: "file",
+		B:    common.FilePos{L: 3, C: 9},
+		E:    common.FilePos{L: 3, C: 10},
+	}
+	l := &lexer{opts: &common.Options{Indent: common.IndentConsistent}, lead: "        ", recov: true}
+	l.indent.PushBack(1)
+	l.indent.PushBack(9)
+	l.leads.PushBack("")
+	l.leads.PushBack("\t")
+
+	result := l.doIndent(9, loc)
+
+	a.True(result)
+	a.Equal(2, l.indent.Len())
+	a.Equal(1, l.tokens.Len())
+	a.Equal(&common.Token{
+		Sym: common.TokError,
+		Loc: loc,
+		Val: common.Diagnose(common.ErrAmbiguousIndent, loc),
+	}, l.tokens.Front().Value.(*common.Token))
+}
+
+func TestDoIndentConsistentDeeper(t *testing.T) {
+	a := assert.New(t)
+	loc := common.Location{
+		File: "file",
+		B:    common.FilePos{L: 3, C: 13},
+		E:    common.FilePos{L: 3, C: 14},
+	}
+	l := &lexer{opts: &common.Options{Indent: common.IndentConsistent}, lead: "\t    "}
+	l.indent.PushBack(1)
+	l.indent.PushBack(9)
+	l.leads.PushBack("")
+	l.leads.PushBack("\t")
+
+	result := l.doIndent(13, loc)
+
+	a.True(result)
+	a.Equal(3, l.indent.Len())
+	a.Equal(3, l.leads.Len())
+	a.Equal("\t    ", l.leads.Back().Value.(string))
+	a.Equal(1, l.tokens.Len())
+	a.Equal(common.TokIndent, l.tokens.Front().Value.(*common.Token).Sym)
+}
+
+func TestDoIndentConsistentDeeperHalts(t *testing.T) {
+	a := assert.New(t)
+	loc := common.Location{
+		File: "file",
+		B:    common.FilePos{L: 3, C: 13},
+		E:    common.FilePos{L: 3, C: 14},
+	}
+	l := &lexer{opts: &common.Options{Indent: common.IndentConsistent}, lead: "    \t"}
+	l.indent.PushBack(1)
+	l.indent.PushBack(9)
+	l.leads.PushBack("")
+	l.leads.PushBack("\t")
+
+	result := l.doIndent(13, loc)
+
+	a.False(result)
+	a.Equal(2, l.indent.Len())
+	a.Equal(2, l.leads.Len())
+	a.Equal(1, l.tokens.Len())
+	a.Equal(common.TokError, l.tokens.Front().Value.(*common.Token).Sym)
+}
+
+func TestDoIndentConsistentShallower(t *testing.T) {
+	a := assert.New(t)
+	loc := common.Location{
+		File: "file",
+		B:    common.FilePos{L: 3, C: 9},
+		E:    common.FilePos{L: 3, C: 10},
+	}
+	l := &lexer{opts: &common.Options{Indent: common.IndentConsistent}, lead: "    ", recov: true}
+	l.indent.PushBack(1)
+	l.indent.PushBack(5)
+	l.indent.PushBack(9)
+	l.leads.PushBack("")
+	l.leads.PushBack("    ")
+	l.leads.PushBack("\t")
+
+	l.doIndent(5, loc)
+
+	a.Equal(2, l.indent.Len())
+	a.Equal(2, l.leads.Len())
+	a.Equal(2, l.tokens.Len())
+	a.Equal(common.TokDedent, l.tokens.Front().Value.(*common.Token).Sym)
+	a.Equal(common.TokError, l.tokens.Back().Value.(*common.Token).Sym)
+	a.Equal(common.Diagnose(common.ErrAmbiguousIndent, loc), l.tokens.Back().Value.(*common.Token).Val)
+}
+
+func TestLexerSpaceNewlineLead(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("\nb"))
+	s, _ := scanner.Scan(opts)
+	l := &lexer{s: s, prevTok: &common.Token{Sym: common.TokIdent}, lead: "  "}
+	l.indent.PushBack(1)
+
+	l.space(s.Next(), 0)
+
+	a.Equal("", l.lead)
+}
+
+func lexAll(opts *common.Options) []*common.Token {
+	l, _ := Lex(opts, nil)
+
+	toks := []*common.Token{}
+	for tok := l.Next(); tok != nil; tok = l.Next() {
+		toks = append(toks, tok)
+	}
+	return toks
+}
+
+func firstError(toks []*common.Token) *common.Token {
+	for _, tok := range toks {
+		if tok.Sym == common.TokError {
+			return tok
+		}
+	}
+	return nil
+}
+
+func TestLexerBadIndentCandidates(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("a\n    b\n        c\n      d\n"))
+
+	result := lexAll(opts)
+
+	last := firstError(result)
+	a.Equal(common.FilePos{L: 4, C: 7}, last.Loc.B)
+	a.Equal("inconsistent indentation: expected column 5 or 9, got 7", last.Val.(*common.Diagnostic).Msg)
+	a.Equal([]int{5, 9}, last.Val.(*common.Diagnostic).Fields[common.FieldExpected])
+}
+
+func TestLexerIndentSpaces(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("a\n    b\n\tc\n"))
+	opts.Indent = common.IndentSpaces
+	opts.Recover = true
+
+	result := lexAll(opts)
+
+	a.Equal([]interface{}{
+		"a", nil, nil, "b", nil, common.CodeTabIndent, "c", nil, nil, nil,
+	}, tokVals(result))
+}
+
+func TestLexerIndentTabs(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("a\n\tb\n        c\n"))
+	opts.Indent = common.IndentTabs
+	opts.Recover = true
+
+	result := lexAll(opts)
+
+	a.Equal([]interface{}{
+		"a", nil, nil, "b", nil, common.CodeSpaceIndent, "c", nil, nil, nil,
+	}, tokVals(result))
+}
+
+func TestLexerIndentConsistent(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("a\n\tb\n\t    c\n\td\ne\n"))
+	opts.Indent = common.IndentConsistent
+
+	result := lexAll(opts)
+
+	a.Equal([]*common.Symbol{
+		common.TokIdent,
+		common.TokNewline,
+		common.TokIndent,
+		common.TokIdent,
+		common.TokNewline,
+		common.TokIndent,
+		common.TokIdent,
+		common.TokNewline,
+		common.TokDedent,
+		common.TokIdent,
+		common.TokNewline,
+		common.TokDedent,
+		common.TokIdent,
+		common.TokNewline,
+		common.TokEOF,
+	}, tokSyms(result))
+}
+
+func TestLexerIndentConsistentAmbiguous(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("a\n\tb\n        c\n"))
+	opts.Indent = common.IndentConsistent
+
+	result := lexAll(opts)
+
+	last := firstError(result)
+	a.True(errors.Is(last.Val.(*common.Diagnostic), common.ErrAmbiguousIndent))
+	a.Equal(common.FilePos{L: 3, C: 9}, last.Loc.B)
+}
+
+func TestLexerIndentConsistentHalts(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("a\n\tb\n    \tc\nd\n"))
+	opts.Indent = common.IndentConsistent
+
+	result := lexAll(opts)
+
+	a.Equal([]*common.Symbol{
+		common.TokIdent,
+		common.TokNewline,
+		common.TokIndent,
+		common.TokIdent,
+		common.TokNewline,
+		common.TokError,
+	}, tokSyms(result))
+}
+
+func TestLexerIndentConsistentSnapshot(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("a\n\tb\n"))
+	opts.Indent = common.IndentConsistent
+	l, _ := Lex(opts, nil)
+	for i := 0; i < 4; i++ {
+		l.Next()
+	}
+
+	result := l.Snapshot()
+
+	a.Equal([]int{1, 9}, result.Indent)
+	a.Equal([]string{"", "\t"}, result.Leads)
+}
+
+func TestLexerIndentConsistentRestore(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("        c\n"))
+	opts.Indent = common.IndentConsistent
+	opts.Start = common.FilePos{L: 3, C: 1}
+	l, _ := Lex(opts, nil)
+	state := &common.LexState{
+		Pos:    common.FilePos{L: 3, C: 1},
+		Indent: []int{1, 9},
+		Leads:  []string{"", "\t"},
+		Prev:   &common.Token{Sym: common.TokNewline},
+	}
+
+	err := l.Restore(state)
+
+	a.NoError(err)
+	tok := l.Next()
+	a.Equal(common.TokError, tok.Sym)
+	a.True(errors.Is(tok.Val.(*common.Diagnostic), common.ErrAmbiguousIndent))
+}
+
+func makeContOptions(src string, policy common.ContPolicy, warnings *[]*common.Diagnostic) *common.Options {
+	opts := makeWarnOptions(strings.NewReader(src), warnings)
+	opts.Prof.Continuation = policy
+	opts.Prof.InfixCont = true
+	opts.Prof.Bindings = common.Bindings{
+		testOperators.Next('+').Sym: {Fixity: common.FixInfix, Infix: 10},
+		testOperators.Next('-').Sym: {Fixity: common.FixPrefix | common.FixInfix, Prefix: 30, Infix: 10},
+		testOperators.Next('(').Sym: {Fixity: common.FixPostfix, Postfix: 40},
+	}
+	return opts
+}
+
+func TestLexerContPolicy(t *testing.T) {
+	a := assert.New(t)
+	l := &lexer{opts: makeContOptions("", common.ContNone, nil)}
+
+	result := l.contPolicy()
+
+	a.Equal(common.ContNone, result)
+}
+
+func TestLexerContPolicyNoProfile(t *testing.T) {
+	a := assert.New(t)
+	l := &lexer{opts: &common.Options{}}
+
+	result := l.contPolicy()
+
+	a.Equal(common.ContStrict, result)
+}
+
+func TestLexerInfixCont(t *testing.T) {
+	a := assert.New(t)
+	l := &lexer{
+		opts:    makeContOptions("", common.ContStrict, nil),
+		prevTok: &common.Token{Sym: testOperators.Next('-').Sym},
+	}
+
+	result := l.infixCont()
+
+	a.True(result)
+}
+
+func TestLexerInfixContDisabled(t *testing.T) {
+	a := assert.New(t)
+	opts := makeContOptions("", common.ContStrict, nil)
+	opts.Prof.InfixCont = false
+	l := &lexer{opts: opts, prevTok: &common.Token{Sym: testOperators.Next('+').Sym}}
+
+	result := l.infixCont()
+
+	a.False(result)
+}
+
+func TestLexerInfixContNoProfile(t *testing.T) {
+	a := assert.New(t)
+	l := &lexer{opts: &common.Options{}, prevTok: &common.Token{Sym: testOperators.Next('+').Sym}}
+
+	result := l.infixCont()
+
+	a.False(result)
+}
+
+func TestLexerInfixContNoToken(t *testing.T) {
+	a := assert.New(t)
+	l := &lexer{opts: makeContOptions("", common.ContStrict, nil)}
+
+	result := l.infixCont()
+
+	a.False(result)
+}
+
+func TestLexerInfixContNotInfix(t *testing.T) {
+	a := assert.New(t)
+	l := &lexer{
+		opts:    makeContOptions("", common.ContStrict, nil),
+		prevTok: &common.Token{Sym: testOperators.Next('(').Sym},
+	}
+
+	result := l.infixCont()
+
+	a.False(result)
+}
+
+func TestLexerInfixContNoBinding(t *testing.T) {
+	a := assert.New(t)
+	l := &lexer{
+		opts:    makeContOptions("", common.ContStrict, nil),
+		prevTok: &common.Token{Sym: testOperators.Next('*').Sym},
+	}
+
+	result := l.infixCont()
+
+	a.False(result)
+}
+
+func TestLexerAfterBackslashNewline(t *testing.T) {
+	a := assert.New(t)
+	warnings := []*common.Diagnostic{}
+	opts := makeContOptions("\nb", common.ContLenient, &warnings)
+	s, _ := scanner.Scan(opts)
+	l := &lexer{s: s, opts: opts}
+
+	result := l.afterBackslash(s.Next())
+
+	a.Equal('\n', result.C)
+	a.Len(warnings, 0)
+}
+
+func TestLexerAfterBackslashSpaces(t *testing.T) {
+	a := assert.New(t)
+	warnings := []*common.Diagnostic{}
+	opts := makeContOptions(" \t\nb", common.ContLenient, &warnings)
+	s, _ := scanner.Scan(opts)
+	l := &lexer{s: s, opts: opts}
+
+	result := l.afterBackslash(s.Next())
+
+	a.Equal('\n', result.C)
+	a.Len(warnings, 1)
+	a.Equal(common.CodeBackslashWS, warnings[0].Code)
+	a.Equal(common.Location{
+		File: "file",
+		B:    common.FilePos{L: 1, C: 1},
+		E:    common.FilePos{L: 1, C: 9},
+	}, warnings[0].Loc)
+}
+
+func TestLexerAfterBackslashComment(t *testing.T) {
+	a := assert.New(t)
+	warnings := []*common.Diagnostic{}
+	opts := makeContOptions("  # comment\nb", common.ContLenient, &warnings)
+	s, _ := scanner.Scan(opts)
+	l := &lexer{s: s, opts: opts}
+
+	result := l.afterBackslash(s.Next())
+
+	a.Equal('\n', result.C)
+	a.Len(warnings, 0)
+}
+
+func TestLexerAfterBackslashOther(t *testing.T) {
+	a := assert.New(t)
+	warnings := []*common.Diagnostic{}
+	opts := makeContOptions("  b", common.ContLenient, &warnings)
+	s, _ := scanner.Scan(opts)
+	l := &lexer{s: s, opts: opts}
+
+	result := l.afterBackslash(s.Next())
+
+	a.Equal('b', result.C)
+	a.Len(warnings, 0)
+}
+
+func TestLexerContLine(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("    b"))
+	s, _ := scanner.Scan(opts)
+	l := &lexer{s: s}
+
+	l.contLine()
+
+	a.Equal('b', s.Next().C)
+}
+
+func TestLexerContLineNoIndent(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("b"))
+	s, _ := scanner.Scan(opts)
+	l := &lexer{s: s}
+
+	l.contLine()
+
+	a.Equal('b', s.Next().C)
+}
+
+func TestLexerContStrictTrailingSpace(t *testing.T) {
+	a := assert.New(t)
+	opts := makeContOptions("a = \\ \nb\n", common.ContStrict, nil)
+	opts.Warn = nil
+	opts.Recover = true
+
+	result := lexAll(opts)
+
+	a.Equal([]interface{}{
+		"a", "=", common.CodeDanglingBackslash, nil, "b", nil, nil,
+	}, tokVals(result))
+}
+
+func TestLexerContLenient(t *testing.T) {
+	a := assert.New(t)
+	warnings := []*common.Diagnostic{}
+	opts := makeContOptions("a = \\ \n  b \\  # why\n  * c\n", common.ContLenient, &warnings)
+
+	result := lexAll(opts)
+
+	a.Equal([]interface{}{
+		"a", "=", "b", "*", "c", nil, nil,
+	}, tokVals(result))
+	a.Len(warnings, 1)
+	a.Equal(common.CodeBackslashWS, warnings[0].Code)
+	a.Equal(common.FilePos{L: 1, C: 6}, warnings[0].Loc.B)
+}
+
+func TestLexerContLenientDangling(t *testing.T) {
+	a := assert.New(t)
+	opts := makeContOptions("a = \\ b\n", common.ContLenient, nil)
+	opts.Warn = nil
+	opts.Recover = true
+
+	result := lexAll(opts)
+
+	a.Equal([]interface{}{
+		"a", "=", common.CodeDanglingBackslash, "b", nil, nil,
+	}, tokVals(result))
+	a.Equal(common.FilePos{L: 1, C: 7}, result[2].Loc.B)
+}
+
+func TestLexerContNone(t *testing.T) {
+	a := assert.New(t)
+	opts := makeContOptions("a = \\\nb\n", common.ContNone, nil)
+	opts.Warn = nil
+	opts.Recover = true
+
+	result := lexAll(opts)
+
+	a.Equal([]interface{}{
+		"a", "=", common.CodeBackslashCont, nil, "b", nil, nil,
+	}, tokVals(result))
+	a.Equal(common.FilePos{L: 1, C: 5}, result[2].Loc.B)
+}
+
+func TestLexerContInfix(t *testing.T) {
+	a := assert.New(t)
+	opts := makeContOptions("a = b +\n\n  c -  # more\n    d\ne =\n", common.ContStrict, nil)
+	opts.Warn = nil
+
+	result := lexAll(opts)
+
+	a.Equal([]*common.Symbol{
+		common.TokIdent,
+		testOperators.Next('=').Sym,
+		common.TokIdent,
+		testOperators.Next('+').Sym,
+		common.TokIdent,
+		testOperators.Next('-').Sym,
+		common.TokIdent,
+		common.TokNewline,
+		common.TokIdent,
+		testOperators.Next('=').Sym,
+		common.TokNewline,
+		common.TokEOF,
+	}, tokSyms(result))
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2019 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package lexer
+
+import (
+	"context"
+
+	"github.com/hydralang/hydra/parser/common"
+)
+
+// streamBuffer is the number of tokens that Stream will lex ahead of
+// the consumer.
+const streamBuffer = 64
+
+// All lexes the source described by the options, returning all the
+// tokens through the EOF token.  Lexing stops at the first error
+// token; in that case, the tokens preceding it are returned, along
+// with the *common.Diagnostic describing the error.  This is true
+// even if error recovery is enabled in the options.
+func All(opts *common.Options) ([]*common.Token, error) {
+	l, err := Lex(opts, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Collect the tokens
+	toks := []*common.Token{}
+	for tok := l.Next(); tok != nil; tok = l.Next() {
+		if tok.Sym == common.TokError {
+			return toks, tok.Val.(error)
+		}
+
+		toks = append(toks, tok)
+	}
+
+	return toks, nil
+}
+
+// Stream lexes the source described by the options in a separate
+// goroutine, sending the tokens to the returned channel, which is
+// closed after the last token has been sent.  The lexer runs at most
+// a fixed number of tokens ahead of the consumer.  Lexing stops
+// early, and the channel is closed, if the context is canceled; a
+// consumer that stops reading tokens before the channel is closed
+// must cancel the context to release the goroutine.  If the lexer
+// cannot be constructed, a single error token is sent.
+func Stream(ctx context.Context, opts *common.Options) <-chan *common.Token {
+	toks := make(chan *common.Token, streamBuffer)
+
+	go func() {
+		defer close(toks)
+
+		// Construct the lexer
+		l, err := Lex(opts, nil)
+		if err != nil {
+			loc := common.Location{File: opts.Filename}
+			send(ctx, toks, &common.Token{
+				Sym: common.TokError,
+				Loc: loc,
+				Val: common.Diagnose(err, loc),
+			})
+			return
+		}
+
+		// Send the tokens
+		for tok := l.Next(); tok != nil; tok = l.Next() {
+			if !send(ctx, toks, tok) {
+				return
+			}
+		}
+	}()
+
+	return toks
+}
+
+// send sends a token to the channel, unless the context is canceled
+// first.  Returns false if the context was canceled.
+func send(ctx context.Context, toks chan<- *common.Token, tok *common.Token) bool {
+	select {
+	case toks <- tok:
+		return true
+
+	case <-ctx.Done():
+		return false
+	}
+}
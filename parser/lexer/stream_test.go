@@ -0,0 +1,107 @@
+// Copyright (c) 2019 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package lexer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hydralang/hydra/parser/common"
+)
+
+func TestAllBase(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("a = b\n"))
+
+	result, err := All(opts)
+
+	a.NoError(err)
+	a.Len(result, 5)
+	a.Equal("a", result[0].Val)
+	a.Equal(common.TokEOF, result[4].Sym)
+}
+
+func TestAllError(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("a ? b\n"))
+	opts.Recover = true
+
+	result, err := All(opts)
+
+	a.True(errors.Is(err, common.ErrBadOp))
+	a.Len(result, 1)
+	a.Equal("a", result[0].Val)
+}
+
+func TestAllLexError(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("a = b\n"))
+	opts.Encoding = "no-such-encoding"
+
+	result, err := All(opts)
+
+	a.Error(err)
+	a.Nil(result)
+}
+
+func TestStreamBase(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("a = b\n"))
+
+	toks := Stream(context.Background(), opts)
+
+	result := []*common.Token{}
+	for tok := range toks {
+		result = append(result, tok)
+	}
+	a.Len(result, 5)
+	a.Equal("a", result[0].Val)
+	a.Equal(common.TokEOF, result[4].Sym)
+}
+
+func TestStreamCanceled(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader(strings.Repeat("a ", 1000)))
+	ctx, cancel := context.WithCancel(context.Background())
+
+	toks := Stream(ctx, opts)
+	cancel()
+
+	cnt := 0
+	for range toks {
+		cnt++
+	}
+	a.True(cnt < 1000)
+}
+
+func TestStreamLexError(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("a = b\n"))
+	opts.Encoding = "no-such-encoding"
+
+	toks := Stream(context.Background(), opts)
+
+	result := []*common.Token{}
+	for tok := range toks {
+		result = append(result, tok)
+	}
+	a.Len(result, 1)
+	a.Equal(common.TokError, result[0].Sym)
+	a.Equal(common.Location{File: "file"}, result[0].Loc)
+}
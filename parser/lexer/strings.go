@@ -101,9 +101,9 @@ type recognizeString struct {
 }
 
 // recogString constructs a recognizer for strings.
-func recogString(l *lexer) Recognizer {
+func recogString(lc common.LexContext) common.Recognizer {
 	return &recognizeString{
-		l: l,
+		l: lc.(*lexer),
 	}
 }
 
@@ -174,6 +174,12 @@ func (r *recognizeString) escape(ch common.AugChar) (common.Location, error) {
 			return loc.ThruEnd(eLoc), err
 		}
 
+		// Warn about legacy octal escapes; a lone "\0" is
+		// acceptable
+		if ch.Class&common.CharOctDigit != 0 && (ch.C != '0' || eLoc != ch.Loc) {
+			r.l.warn(common.LintOctalEscape, loc.ThruEnd(eLoc), common.WarnOctalEscape)
+		}
+
 		// Write the escaped character; escapes return EOF to
 		// indicate no character should be written, e.g.,
 		// escaped newline
@@ -186,8 +192,75 @@ func (r *recognizeString) escape(ch common.AugChar) (common.Location, error) {
 		return common.Location{}, nil
 	}
 
-	// Not a valid escape
-	return loc.ThruEnd(ch.Loc), common.ErrBadEscape
+	// Not a valid escape; suggest escaping the backslash
+	diag := common.Diagnose(common.ErrBadEscape, loc.ThruEnd(ch.Loc))
+	diag.Fixes = []common.Fix{
+		{
+			Msg:   "escape the backslash",
+			Edits: []common.Edit{common.Insert(loc, "\\")},
+		},
+	}
+	return diag.Loc, diag
+}
+
+// unclosed constructs the error for an unclosed string, including a
+// suggested fix that inserts the missing close quotes at the
+// specified location.
+func (r *recognizeString) unclosed(loc common.Location) error {
+	quote := string(r.q)
+	if r.flags&common.StrMulti != 0 {
+		quote = strings.Repeat(quote, 3)
+	}
+
+	diag := common.Diagnose(common.ErrUnclosedStr, loc)
+	diag.Fixes = []common.Fix{
+		{
+			Msg:   "insert the missing close quote",
+			Edits: []common.Edit{common.Insert(loc, quote)},
+		},
+	}
+	return diag
+}
+
+// fail reports an error encountered while processing a string.  If
+// the lexer is recovering from errors, the remainder of the string is
+// skipped, so that lexing resumes after the close quote, or at the
+// end of the line for an unclosed single-line string.
+func (r *recognizeString) fail(loc common.Location, err error) {
+	r.l.pushErr(loc, err)
+	if r.l.s == nil {
+		return
+	}
+
+	// Skip to the end of the string
+	qcnt := 0
+	for ch := r.l.s.Next(); ; ch = r.l.s.Next() {
+		// Handle the end of the input and the end of the line
+		if ch.C == common.EOF || ch.C == common.Err ||
+			(ch.C == '\n' && r.flags&common.StrMulti == 0) {
+			r.l.s.Push(ch)
+			return
+		}
+
+		// Handle quotes
+		if ch.C == r.q {
+			qcnt++
+			if r.flags&common.StrMulti == 0 || qcnt >= 3 {
+				return
+			}
+			continue
+		}
+		qcnt = 0
+
+		// Skip escaped characters
+		if ch.C == '\\' {
+			ch = r.l.s.Next()
+			if ch.C == common.EOF || ch.C == common.Err {
+				r.l.s.Push(ch)
+				return
+			}
+		}
+	}
 }
 
 // Recognize is called to recognize a string.  Will be called with the
@@ -211,6 +284,7 @@ func (r *recognizeString) Recognize(ch common.AugChar) {
 	ch = r.l.s.Next()
 	if r.flags&common.StrTriple != 0 {
 		if ch.C == r.q {
+			second := ch
 			ch = r.l.s.Next()
 			if ch.C == r.q {
 				// Triple quote; mark for multi-line
@@ -223,6 +297,13 @@ func (r *recognizeString) Recognize(ch common.AugChar) {
 				// back
 				r.l.s.Push(ch)
 
+				// Lex f-strings separately
+				if r.flags&common.StrFormat != 0 {
+					r.flags &^= common.StrTriple
+					r.format(second)
+					return
+				}
+
 				// Push a token
 				r.l.pushTok(
 					r.buf.sym(),
@@ -238,6 +319,12 @@ func (r *recognizeString) Recognize(ch common.AugChar) {
 		}
 	}
 
+	// Lex f-strings separately
+	if r.flags&common.StrFormat != 0 {
+		r.format(ch)
+		return
+	}
+
 	// Accumulate characters until the close
 	for ; ; ch = r.l.s.Next() {
 		// Handle quotes
@@ -259,7 +346,7 @@ func (r *recognizeString) Recognize(ch common.AugChar) {
 		for ; r.qcnt > 0; r.qcnt-- {
 			// Add quotes we skipped over before
 			if err := r.buf.putC(r.q); err != nil {
-				r.l.pushErr(r.runLoc, err)
+				r.fail(r.runLoc, err)
 				return
 			}
 		}
@@ -271,24 +358,26 @@ func (r *recognizeString) Recognize(ch common.AugChar) {
 			return
 
 		case common.EOF: // EOF in a string?
-			r.l.pushErr(ch.Loc, common.ErrUnclosedStr)
+			r.l.s.Push(ch)
+			r.fail(ch.Loc, r.unclosed(ch.Loc))
 			return
 
 		case '\\': // Introduces an escape
 			if loc, err := r.escape(ch); err != nil {
-				r.l.pushErr(loc, err)
+				r.fail(loc, err)
 				return
 			}
 
 		case '\n': // Newline, possible unclosed string
 			if r.flags&common.StrMulti == 0 {
-				r.l.pushErr(ch.Loc, common.ErrUnclosedStr)
+				r.l.s.Push(ch)
+				r.fail(ch.Loc, r.unclosed(ch.Loc))
 				return
 			}
 			fallthrough
 		default: // Regular character
 			if err := r.buf.putC(ch.C); err != nil {
-				r.l.pushErr(ch.Loc, err)
+				r.fail(ch.Loc, err)
 				return
 			}
 		}
@@ -15,6 +15,7 @@
 package lexer
 
 import (
+	"errors"
 	"strings"
 	"testing"
 	"unicode"
@@ -112,7 +113,7 @@ func TestBufBytesSym(t *testing.T) {
 }
 
 func TestRecognizeStringImplementsRecognizer(t *testing.T) {
-	assert.Implements(t, (*Recognizer)(nil), &recognizeString{})
+	assert.Implements(t, (*common.Recognizer)(nil), &recognizeString{})
 }
 
 func TestRecogString(t *testing.T) {
@@ -578,7 +579,22 @@ func TestRecognizeStringEscapeUnknown(t *testing.T) {
 
 	loc, err := r.escape(ch)
 
-	a.Equal(common.ErrBadEscape, err)
+	a.True(errors.Is(err, common.ErrBadEscape))
+	a.Equal([]common.Fix{
+		{
+			Msg: "escape the backslash",
+			Edits: []common.Edit{
+				{
+					Loc: common.Location{
+						File: "file",
+						B:    common.FilePos{L: 1, C: 1},
+						E:    common.FilePos{L: 1, C: 1},
+					},
+					Text: "\\",
+				},
+			},
+		},
+	}, err.(*common.Diagnostic).Fixes)
 	a.Equal("", r.buf.get())
 	a.Equal(common.Location{
 		File: "file",
@@ -840,7 +856,11 @@ func TestRecognizeStringRecognizeTripleInclusionsBadQuote(t *testing.T) {
 			B:    common.FilePos{L: 1, C: 5},
 			E:    common.FilePos{L: 1, C: 6},
 		},
-		Val: common.ErrBadStrChar,
+		Val: common.Diagnose(common.ErrBadStrChar, common.Location{
+			File: "file",
+			B:    common.FilePos{L: 1, C: 5},
+			E:    common.FilePos{L: 1, C: 6},
+		}),
 	}, l.tokens.Front().Value.(*common.Token))
 }
 
@@ -907,7 +927,11 @@ func TestRecognizeStringRecognizeReadError(t *testing.T) {
 			B:    common.FilePos{L: 1, C: 4},
 			E:    common.FilePos{L: 1, C: 5},
 		},
-		Val: assert.AnError,
+		Val: common.Diagnose(assert.AnError, common.Location{
+			File: "file",
+			B:    common.FilePos{L: 1, C: 4},
+			E:    common.FilePos{L: 1, C: 5},
+		}),
 	}, l.tokens.Front().Value.(*common.Token))
 }
 
@@ -973,7 +997,11 @@ func TestRecognizeStringRecognizeEOF(t *testing.T) {
 			B:    common.FilePos{L: 1, C: 4},
 			E:    common.FilePos{L: 1, C: 5},
 		},
-		Val: common.ErrUnclosedStr,
+		Val: r.unclosed(common.Location{
+			File: "file",
+			B:    common.FilePos{L: 1, C: 4},
+			E:    common.FilePos{L: 1, C: 5},
+		}),
 	}, l.tokens.Front().Value.(*common.Token))
 }
 
@@ -1031,7 +1059,32 @@ func TestRecognizeStringRecognizeEscapeBadEscape(t *testing.T) {
 			B:    common.FilePos{L: 1, C: 4},
 			E:    common.FilePos{L: 1, C: 6},
 		},
-		Val: common.ErrBadEscape,
+		Val: &common.Diagnostic{
+			Code:     common.CodeBadEscape,
+			Severity: common.SevError,
+			Msg:      common.ErrBadEscape.Error(),
+			Loc: common.Location{
+				File: "file",
+				B:    common.FilePos{L: 1, C: 4},
+				E:    common.FilePos{L: 1, C: 6},
+			},
+			Fixes: []common.Fix{
+				{
+					Msg: "escape the backslash",
+					Edits: []common.Edit{
+						{
+							Loc: common.Location{
+								File: "file",
+								B:    common.FilePos{L: 1, C: 4},
+								E:    common.FilePos{L: 1, C: 4},
+							},
+							Text: "\\",
+						},
+					},
+				},
+			},
+			Err: common.ErrBadEscape,
+		},
 	}, l.tokens.Front().Value.(*common.Token))
 }
 
@@ -1060,7 +1113,11 @@ func TestRecognizeStringRecognizeNewline(t *testing.T) {
 			B:    common.FilePos{L: 1, C: 4},
 			E:    common.FilePos{L: 2, C: 1},
 		},
-		Val: common.ErrUnclosedStr,
+		Val: r.unclosed(common.Location{
+			File: "file",
+			B:    common.FilePos{L: 1, C: 4},
+			E:    common.FilePos{L: 2, C: 1},
+		}),
 	}, l.tokens.Front().Value.(*common.Token))
 }
 
@@ -1141,6 +1198,191 @@ func TestRecognizeStringRecognizeBadRune(t *testing.T) {
 			B:    common.FilePos{L: 1, C: 3},
 			E:    common.FilePos{L: 1, C: 4},
 		},
-		Val: common.ErrBadStrChar,
+		Val: common.Diagnose(common.ErrBadStrChar, common.Location{
+			File: "file",
+			B:    common.FilePos{L: 1, C: 3},
+			E:    common.FilePos{L: 1, C: 4},
+		}),
 	}, l.tokens.Front().Value.(*common.Token))
 }
+
+func TestRecognizeStringUnclosed(t *testing.T) {
+	a := assert.New(t)
+	r := &recognizeString{q: '"'}
+	loc := common.Location{
+		File: "file",
+		B:    common.FilePos{L: 1, C: 4},
+		E:    common.FilePos{L: 2, C: 1},
+	}
+
+	result := r.unclosed(loc)
+
+	a.True(errors.Is(result, common.ErrUnclosedStr))
+	a.Equal([]common.Fix{
+		{
+			Msg: "insert the missing close quote",
+			Edits: []common.Edit{
+				{
+					Loc: common.Location{
+						File: "file",
+						B:    common.FilePos{L: 1, C: 4},
+						E:    common.FilePos{L: 1, C: 4},
+					},
+					Text: "\"",
+				},
+			},
+		},
+	}, result.(*common.Diagnostic).Fixes)
+}
+
+func TestRecognizeStringUnclosedMulti(t *testing.T) {
+	a := assert.New(t)
+	r := &recognizeString{
+		flags: common.StrTriple | common.StrMulti,
+		q:     '\'',
+	}
+	loc := common.Location{
+		File: "file",
+		B:    common.FilePos{L: 3, C: 4},
+		E:    common.FilePos{L: 3, C: 4},
+	}
+
+	result := r.unclosed(loc)
+
+	a.True(errors.Is(result, common.ErrUnclosedStr))
+	a.Equal([]common.Fix{
+		{
+			Msg: "insert the missing close quote",
+			Edits: []common.Edit{
+				{
+					Loc:  loc,
+					Text: "'''",
+				},
+			},
+		},
+	}, result.(*common.Diagnostic).Fixes)
+}
+
+func TestRecognizeStringFailHalted(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("\\q spam\" eggs"))
+	s, _ := scanner.Scan(opts)
+	l := &lexer{
+		s:    s,
+		opts: opts,
+	}
+	r := &recognizeString{
+		l: l,
+		q: '"',
+	}
+
+	r.fail(common.Location{}, common.ErrBadEscape)
+
+	a.Nil(l.s)
+	a.Equal(1, l.tokens.Len())
+}
+
+func TestRecognizeStringFailSkipsString(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("q sp\\\"am\" eggs"))
+	s, _ := scanner.Scan(opts)
+	l := &lexer{
+		s:     s,
+		opts:  opts,
+		recov: true,
+	}
+	r := &recognizeString{
+		l: l,
+		q: '"',
+	}
+
+	r.fail(common.Location{}, common.ErrBadEscape)
+
+	a.Equal(s, l.s)
+	a.Equal(1, l.tokens.Len())
+	a.Equal(' ', s.Next().C)
+	a.Equal('e', s.Next().C)
+}
+
+func TestRecognizeStringFailSkipsTripleQuoted(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("q \"\nsp\"\"am\"\"\" eggs"))
+	s, _ := scanner.Scan(opts)
+	l := &lexer{
+		s:     s,
+		opts:  opts,
+		recov: true,
+	}
+	r := &recognizeString{
+		l:     l,
+		flags: common.StrTriple | common.StrMulti,
+		q:     '"',
+	}
+
+	r.fail(common.Location{}, common.ErrBadEscape)
+
+	a.Equal(s, l.s)
+	a.Equal(' ', s.Next().C)
+	a.Equal('e', s.Next().C)
+}
+
+func TestRecognizeStringFailStopsAtNewline(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("q spam\neggs\""))
+	s, _ := scanner.Scan(opts)
+	l := &lexer{
+		s:     s,
+		opts:  opts,
+		recov: true,
+	}
+	r := &recognizeString{
+		l: l,
+		q: '"',
+	}
+
+	r.fail(common.Location{}, common.ErrBadEscape)
+
+	a.Equal('\n', s.Next().C)
+}
+
+func TestRecognizeStringFailStopsAtEOF(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("q spam\\"))
+	s, _ := scanner.Scan(opts)
+	l := &lexer{
+		s:     s,
+		opts:  opts,
+		recov: true,
+	}
+	r := &recognizeString{
+		l: l,
+		q: '"',
+	}
+
+	r.fail(common.Location{}, common.ErrBadEscape)
+
+	a.Equal(common.EOF, s.Next().C)
+}
+
+func TestRecognizeStringRecognizeNewlineRecovering(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("\"sp\nam\""))
+	s, _ := scanner.Scan(opts)
+	l := &lexer{
+		s:     s,
+		opts:  opts,
+		recov: true,
+	}
+	l.indent.PushBack(1)
+	r := &recognizeString{
+		l: l,
+	}
+	ch := l.s.Next()
+
+	r.Recognize(ch)
+
+	a.Equal(s, l.s)
+	a.Equal(1, l.tokens.Len())
+	a.Equal(common.TokError, l.tokens.Front().Value.(*common.Token).Sym)
+	a.Equal('\n', s.Next().C)
+}
@@ -14,7 +14,11 @@
 
 package lexer
 
-import "github.com/hydralang/hydra/parser/common"
+import (
+	"golang.org/x/text/language"
+
+	"github.com/hydralang/hydra/parser/common"
+)
 
 // lastTok retrieves the last token that was generated by the lexer.
 // This could include pushed-back tokens.
@@ -30,7 +34,8 @@ func (l *lexer) lastTok() *common.Token {
 
 // pushTok pushes a token onto the end of the token queue.  This is in
 // contrast to Push(), which pushes onto the beginning of the token
-// queue.
+// queue.  Returns the token, or nil if the token was elided or an
+// indentation error halted the lexer.
 func (l *lexer) pushTok(sym *common.Symbol, loc common.Location, val interface{}) *common.Token {
 	// Avoid recursive calls
 	if sym != common.TokError && sym != common.TokIndent && sym != common.TokDedent {
@@ -43,14 +48,22 @@ func (l *lexer) pushTok(sym *common.Symbol, loc common.Location, val interface{}
 				return nil
 			}
 
-			// Apply indentation
-			if sym == common.TokEOF {
-				// For EOF, dedent back to column 1
-				l.doIndent(1, loc)
-			} else {
-				// Adjust indent for beginning of
-				// token
-				l.doIndent(loc.B.C, loc)
+			// Apply indentation, unless the mode doesn't
+			// track it
+			if l.modeFlags()&common.ModeNoIndent == 0 {
+				indent := loc.B.C
+				if sym == common.TokEOF {
+					// For EOF, dedent back to
+					// column 1
+					l.lead = ""
+					indent = 1
+				}
+
+				// Adjust indent for beginning of token;
+				// on an error, halt without the token
+				if !l.doIndent(indent, loc) {
+					return nil
+				}
 			}
 		}
 	}
@@ -62,18 +75,94 @@ func (l *lexer) pushTok(sym *common.Symbol, loc common.Location, val interface{}
 		Val: val,
 	}
 	l.tokens.PushBack(tok)
+	l.attach(tok)
 
 	return tok
 }
 
 // pushErr pushes an error token onto the end of the token queue.
 // This is similar to pushTok(), but it additionally closes the
-// scanner, so that token processing is halted.
+// scanner, so that token processing is halted, unless the lexer is
+// recovering from errors.  The semantic value of the error token is a
+// *common.Diagnostic describing the error, with the message
+// translated into the language selected by the options.
 func (l *lexer) pushErr(loc common.Location, err error) {
+	diag := common.Diagnose(err, loc)
+	if l.opts != nil && l.opts.Lang != language.Und {
+		diag.Msg = diag.Localize(l.opts.Lang)
+	}
+
 	l.tokens.PushBack(&common.Token{
 		Sym: common.TokError,
 		Loc: loc,
-		Val: err,
+		Val: diag,
 	})
-	l.s = nil
+
+	// Halt token processing unless we're recovering
+	if !l.recov {
+		l.s = nil
+	}
+}
+
+// warn reports a warning through the warning hook set in the
+// options, if the specified lint check is enabled in the profile.
+func (l *lexer) warn(lint uint16, loc common.Location, err error) {
+	if l.opts == nil || l.opts.Warn == nil || l.opts.Prof == nil || l.opts.Prof.Lints&lint == 0 {
+		return
+	}
+
+	diag := common.Warning(err, loc)
+	if l.opts.Lang != language.Und {
+		diag.Msg = diag.Localize(l.opts.Lang)
+	}
+	l.opts.Warn(diag)
+}
+
+// resync resynchronizes the lexer after an error by skipping
+// characters up to the next whitespace character or the end of file.
+// The character that ends the skip is pushed back for reprocessing.
+// This does nothing if the lexer is not recovering from errors.
+func (l *lexer) resync() {
+	if l.s == nil {
+		return
+	}
+
+	// Skip characters
+	ch := l.s.Next()
+	for ch.Class&common.CharWS == 0 && ch.C != common.EOF && ch.C != common.Err {
+		ch = l.s.Next()
+	}
+
+	// Push back the character
+	l.s.Push(ch)
+}
+
+// Scanner returns the scanner for the source.  This will be nil if
+// token processing has been halted.
+func (l *lexer) Scanner() common.Scanner {
+	return l.s
+}
+
+// Options returns the parser options the lexer was constructed with.
+func (l *lexer) Options() *common.Options {
+	return l.opts
+}
+
+// PushTok pushes a token onto the end of the token queue.  This
+// enables recognizers outside the lexer package to push tokens.
+func (l *lexer) PushTok(sym *common.Symbol, loc common.Location, val interface{}) *common.Token {
+	return l.pushTok(sym, loc, val)
+}
+
+// PushErr pushes an error token onto the end of the token queue.
+// This enables recognizers outside the lexer package to push errors.
+func (l *lexer) PushErr(loc common.Location, err error) {
+	l.pushErr(loc, err)
+}
+
+// Warn reports a warning through the warning hook set in the options.
+// This enables recognizers outside the lexer package to report
+// warnings.
+func (l *lexer) Warn(lint uint16, loc common.Location, err error) {
+	l.warn(lint, loc, err)
 }
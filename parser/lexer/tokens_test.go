@@ -15,12 +15,15 @@
 package lexer
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
 
 	"github.com/hydralang/hydra/parser/common"
 	"github.com/hydralang/hydra/parser/scanner"
+	"github.com/hydralang/hydra/testutils"
 )
 
 func TestLexerLastTokNil(t *testing.T) {
@@ -156,6 +159,30 @@ func TestLexerPushTokInitialNewline(t *testing.T) {
 	a.Equal(1, l.indent.Len())
 }
 
+func TestLexerPushTokNoIndent(t *testing.T) {
+	a := assert.New(t)
+	loc := common.Location{
+		File: "file",
+		B:    common.FilePos{L: 3, C: 5},
+		E:    common.FilePos{L: 3, C: 6},
+	}
+	l := &lexer{
+		prevTok: &common.Token{Sym: common.TokNewline},
+	}
+	l.indent.PushBack(1)
+	l.modes.PushBack(&common.Mode{Flags: common.ModeNoIndent})
+
+	result := l.pushTok(common.TokIdent, loc, "val")
+
+	a.Equal(&common.Token{
+		Sym: common.TokIdent,
+		Loc: loc,
+		Val: "val",
+	}, result)
+	a.Equal(1, l.tokens.Len())
+	a.Equal(1, l.indent.Len())
+}
+
 func TestLexerPushTokDedentEOF(t *testing.T) {
 	a := assert.New(t)
 	loc := common.Location{
@@ -245,7 +272,240 @@ func TestLexerPushErr(t *testing.T) {
 	a.Equal(&common.Token{
 		Sym: common.TokError,
 		Loc: loc,
-		Val: assert.AnError,
+		Val: &common.Diagnostic{
+			Code:     common.CodeUnknown,
+			Severity: common.SevError,
+			Msg:      assert.AnError.Error(),
+			Loc:      loc,
+			Err:      assert.AnError,
+		},
+	}, l.tokens.Front().Value.(*common.Token))
+	a.Nil(l.s)
+}
+
+func TestLexerPushErrRecovering(t *testing.T) {
+	a := assert.New(t)
+	opts := &common.Options{
+		Encoding: "utf-8",
+	}
+	s, _ := scanner.Scan(opts)
+	loc := common.Location{
+		File: "file",
+		B:    common.FilePos{L: 3, C: 2},
+		E:    common.FilePos{L: 3, C: 3},
+	}
+	l := &lexer{s: s, recov: true}
+
+	l.pushErr(loc, common.ErrBadOp)
+
+	a.Equal(1, l.tokens.Len())
+	a.Equal(&common.Token{
+		Sym: common.TokError,
+		Loc: loc,
+		Val: common.Diagnose(common.ErrBadOp, loc),
 	}, l.tokens.Front().Value.(*common.Token))
+	a.Equal(s, l.s)
+}
+
+func TestLexerPushErrLocalized(t *testing.T) {
+	a := assert.New(t)
+	common.Messages.SetString(language.French, common.CodeBadOp, "mauvais caract\u00e8re d'op\u00e9rateur")
+	opts := &common.Options{
+		Encoding: "utf-8",
+		Lang:     language.French,
+	}
+	s, _ := scanner.Scan(opts)
+	loc := common.Location{
+		File: "file",
+		B:    common.FilePos{L: 3, C: 2},
+		E:    common.FilePos{L: 3, C: 3},
+	}
+	l := &lexer{s: s, opts: opts}
+
+	l.pushErr(loc, common.ErrBadOp)
+
+	a.Equal(1, l.tokens.Len())
+	a.Equal(&common.Token{
+		Sym: common.TokError,
+		Loc: loc,
+		Val: &common.Diagnostic{
+			Code:     common.CodeBadOp,
+			Severity: common.SevError,
+			Msg:      "mauvais caract\u00e8re d'op\u00e9rateur",
+			Loc:      loc,
+			Err:      common.ErrBadOp,
+		},
+	}, l.tokens.Front().Value.(*common.Token))
+	a.Nil(l.s)
+}
+
+func TestLexerWarn(t *testing.T) {
+	a := assert.New(t)
+	var warnings []*common.Diagnostic
+	opts := makeWarnOptions(strings.NewReader(""), &warnings)
+	l := &lexer{opts: opts}
+	loc := common.Location{
+		File: "file",
+		B:    common.FilePos{L: 3, C: 2},
+		E:    common.FilePos{L: 3, C: 3},
+	}
+
+	l.warn(common.LintTrailingWS, loc, common.WarnTrailingWS)
+
+	a.Equal([]*common.Diagnostic{
+		common.Warning(common.WarnTrailingWS, loc),
+	}, warnings)
+}
+
+func TestLexerWarnDisabled(t *testing.T) {
+	a := assert.New(t)
+	var warnings []*common.Diagnostic
+	opts := makeWarnOptions(strings.NewReader(""), &warnings)
+	opts.Prof.Lints = common.LintAll &^ common.LintTrailingWS
+	l := &lexer{opts: opts}
+
+	l.warn(common.LintTrailingWS, common.Location{}, common.WarnTrailingWS)
+
+	a.Nil(warnings)
+}
+
+func TestLexerWarnNoHook(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader(""))
+	l := &lexer{opts: opts}
+
+	a.NotPanics(func() {
+		l.warn(common.LintTrailingWS, common.Location{}, common.WarnTrailingWS)
+	})
+}
+
+func TestLexerWarnNoOptions(t *testing.T) {
+	a := assert.New(t)
+	l := &lexer{}
+
+	a.NotPanics(func() {
+		l.warn(common.LintTrailingWS, common.Location{}, common.WarnTrailingWS)
+	})
+}
+
+func TestLexerWarnLocalized(t *testing.T) {
+	a := assert.New(t)
+	common.Messages.SetString(language.French, common.CodeTrailingWS, "espaces en fin de ligne")
+	var warnings []*common.Diagnostic
+	opts := makeWarnOptions(strings.NewReader(""), &warnings)
+	opts.Lang = language.French
+	l := &lexer{opts: opts}
+
+	l.warn(common.LintTrailingWS, common.Location{}, common.WarnTrailingWS)
+
+	a.Len(warnings, 1)
+	a.Equal("espaces en fin de ligne", warnings[0].Msg)
+}
+
+func TestLexerResync(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("abc+1 def"))
+	s, _ := scanner.Scan(opts)
+	l := &lexer{s: s, recov: true}
+
+	l.resync()
+
+	a.Equal(' ', s.Next().C)
+}
+
+func TestLexerResyncEOF(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("abc"))
+	s, _ := scanner.Scan(opts)
+	l := &lexer{s: s, recov: true}
+
+	l.resync()
+
+	a.Equal(common.EOF, s.Next().C)
+}
+
+func TestLexerResyncHalted(t *testing.T) {
+	l := &lexer{}
+
+	l.resync()
+}
+
+func TestLexerScanner(t *testing.T) {
+	a := assert.New(t)
+	s := &common.MockScanner{}
+	l := &lexer{s: s}
+
+	result := l.Scanner()
+
+	testutils.AssertPtrEqual(a, s, result)
+}
+
+func TestLexerOptions(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader(""))
+	l := &lexer{opts: opts}
+
+	result := l.Options()
+
+	testutils.AssertPtrEqual(a, opts, result)
+}
+
+func TestLexerPushTokExported(t *testing.T) {
+	a := assert.New(t)
+	loc := common.Location{
+		File: "file",
+		B:    common.FilePos{L: 3, C: 2},
+		E:    common.FilePos{L: 3, C: 3},
+	}
+	l := &lexer{
+		prevTok: &common.Token{Sym: common.TokEOF},
+	}
+	l.indent.PushBack(1)
+
+	result := l.PushTok(common.TokIdent, loc, "val")
+
+	a.Equal(&common.Token{
+		Sym: common.TokIdent,
+		Loc: loc,
+		Val: "val",
+	}, result)
+	a.Equal(1, l.tokens.Len())
+}
+
+func TestLexerPushErrExported(t *testing.T) {
+	a := assert.New(t)
+	opts := &common.Options{
+		Encoding: "utf-8",
+	}
+	s, _ := scanner.Scan(opts)
+	loc := common.Location{
+		File: "file",
+		B:    common.FilePos{L: 3, C: 2},
+		E:    common.FilePos{L: 3, C: 3},
+	}
+	l := &lexer{s: s}
+
+	l.PushErr(loc, assert.AnError)
+
+	a.Equal(1, l.tokens.Len())
+	a.Equal(common.TokError, l.tokens.Front().Value.(*common.Token).Sym)
 	a.Nil(l.s)
 }
+
+func TestLexerWarnExported(t *testing.T) {
+	a := assert.New(t)
+	var warnings []*common.Diagnostic
+	opts := makeWarnOptions(strings.NewReader(""), &warnings)
+	l := &lexer{opts: opts}
+	loc := common.Location{
+		File: "file",
+		B:    common.FilePos{L: 3, C: 2},
+		E:    common.FilePos{L: 3, C: 3},
+	}
+
+	l.Warn(common.LintTrailingWS, loc, common.WarnTrailingWS)
+
+	a.Equal([]*common.Diagnostic{
+		common.Warning(common.WarnTrailingWS, loc),
+	}, warnings)
+}
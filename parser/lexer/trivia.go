@@ -0,0 +1,235 @@
+// Copyright (c) 2019 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package lexer
+
+import (
+	"container/list"
+	"strings"
+
+	"github.com/hydralang/hydra/parser/common"
+)
+
+// recorder is a wrapper for a scanner that records each character
+// read from the source, so that the source text may be attributed to
+// the tokens produced by the lexer.
+type recorder struct {
+	s     common.Scanner   // The scanner being recorded
+	queue list.List        // Characters pushed back
+	log   []common.AugChar // Characters not yet attributed
+	last  *common.Location // Location of the last recorded char
+}
+
+// Next retrieves the next rune from the file.  An EOF augmented
+// character is returned on end of file, and an Err augmented
+// character is returned in the event of an error.
+func (r *recorder) Next() common.AugChar {
+	// Handle characters pushed back by Push
+	if r.queue.Len() > 0 {
+		return r.queue.Remove(r.queue.Front()).(common.AugChar)
+	}
+
+	// Get a character and record it
+	ch := r.s.Next()
+	if ch.C != common.EOF && ch.C != common.Err {
+		// Characters that don't advance the location, such as
+		// leading form feeds, are recorded as zero-width so
+		// that they can be ordered
+		rec := ch
+		if r.last != nil && *r.last == ch.Loc {
+			rec.Loc.B = rec.Loc.E
+		}
+		r.log = append(r.log, rec)
+		r.last = &ch.Loc
+	}
+
+	return ch
+}
+
+// Push pushes back a single augmented character onto the scanner.
+// Any number of characters may be pushed back.
+func (r *recorder) Push(ch common.AugChar) {
+	r.queue.PushFront(ch)
+}
+
+// attach is called with each token pushed onto the token queue, and
+// attributes the recorded source text to the token.  When trivia is
+// being preserved, the trivia following a token can't be attributed
+// until the following token is known, so the attribution of each
+// token is deferred until the next token is pushed.  Synthetic
+// tokens, such as indents and errors, have no source text, and are
+// ignored.
+func (l *lexer) attach(tok *common.Token) {
+	if l.rec == nil || tok.Sym == common.TokIndent || tok.Sym == common.TokDedent || tok.Sym == common.TokError {
+		return
+	}
+
+	// Without trivia, only the text of the token is needed
+	if !l.trivia {
+		i, j := splitToken(l.rec.log, tok)
+		tok.Text = charText(l.rec.log[i:j])
+		l.rec.log = append(l.rec.log[:0], l.rec.log[j:]...)
+		return
+	}
+
+	if l.pend != nil {
+		l.attribute(l.pend, tok)
+	}
+	l.pend = tok
+}
+
+// splitToken locates a token in a list of recorded characters.  It
+// returns the index of the first character of the token's text and
+// the index of the character following its text; the characters
+// preceding the text are the token's leading trivia.  This includes
+// zero-width whitespace at the start of the token.
+func splitToken(chars []common.AugChar, tok *common.Token) (int, int) {
+	isNL := tok.Sym == common.TokNewline
+
+	// Select the leading trivia
+	i := 0
+	for ; i < len(chars); i++ {
+		ch := chars[i]
+		if tok.Sym != common.TokEOF && !ch.Loc.B.Before(tok.Loc.B) &&
+			(ch.Class&common.CharWS == 0 || ch.Loc.B != ch.Loc.E || isNL && ch.Class&common.CharNL != 0) {
+			break
+		}
+	}
+
+	// Select the text of the token
+	j := i
+	for j < len(chars) && chars[j].Loc.B.Before(tok.Loc.E) {
+		j++
+	}
+
+	return i, j
+}
+
+// attribute attributes the recorded source text to a token.  The
+// text preceding the token becomes its leading trivia, and the text
+// following it, up to the next newline or the next token, becomes its
+// trailing trivia.  A line continuation ends the trailing trivia.
+// The next token is nil if there are no further tokens.
+func (l *lexer) attribute(tok, next *common.Token) {
+	chars := l.rec.log
+	i, j := splitToken(chars, tok)
+
+	// Select the trailing trivia
+	k := j
+	inComment := false
+	for tok.Sym != common.TokNewline && k < len(chars) {
+		ch := chars[k]
+		if ch.Class&common.CharNL != 0 || next != nil && !ch.Loc.B.Before(next.Loc.B) {
+			break
+		} else if inComment || ch.Class&common.CharComment != 0 {
+			inComment = true
+		} else if n := contLen(chars[k:]); n > 0 {
+			k += n
+			break
+		} else if ch.Class&common.CharWS == 0 {
+			break
+		}
+		k++
+	}
+
+	// Save the text and trivia
+	tok.Lead = splitTrivia(chars[:i])
+	tok.Text = charText(chars[i:j])
+	tok.Trail = splitTrivia(chars[j:k])
+	l.rec.log = append(chars[:0], chars[k:]...)
+}
+
+// charText returns the source text of a list of characters.
+func charText(chars []common.AugChar) string {
+	text := strings.Builder{}
+	for _, ch := range chars {
+		text.WriteString(ch.Text())
+	}
+
+	return text.String()
+}
+
+// contLen returns the number of characters in the backslash line
+// continuation at the beginning of a list of characters, including
+// any whitespace and comment between the backslash and the newline,
+// or 0 if the characters do not begin with a continuation.
+func contLen(chars []common.AugChar) int {
+	if len(chars) == 0 || chars[0].C != '\\' {
+		return 0
+	}
+
+	// Skip whitespace and a comment
+	i := 1
+	for i < len(chars) && chars[i].Class&common.CharWS != 0 && chars[i].Class&common.CharNL == 0 {
+		i++
+	}
+	if i < len(chars) && chars[i].Class&common.CharComment != 0 {
+		for i < len(chars) && chars[i].Class&common.CharNL == 0 {
+			i++
+		}
+	}
+
+	// Must end with the newline
+	if i < len(chars) && chars[i].Class&common.CharNL != 0 {
+		return i + 1
+	}
+
+	return 0
+}
+
+// splitTrivia splits a list of characters into trivia.
+func splitTrivia(chars []common.AugChar) []common.Trivia {
+	var result []common.Trivia
+	for i := 0; i < len(chars); {
+		// Determine the kind and extent of the trivia
+		var kind uint8
+		j := i + 1
+		n := contLen(chars[i:])
+		switch ch := chars[i]; {
+		case ch.Class&common.CharComment != 0:
+			kind = common.TriviaComment
+			for j < len(chars) && chars[j].Class&common.CharNL == 0 {
+				j++
+			}
+
+		case n > 0:
+			kind = common.TriviaContinuation
+			j = i + n
+
+		case ch.Class&common.CharNL != 0:
+			kind = common.TriviaNewline
+
+		case ch.Class&common.CharWS != 0:
+			kind = common.TriviaSpace
+			for j < len(chars) && chars[j].Class&common.CharWS != 0 && chars[j].Class&common.CharNL == 0 {
+				j++
+			}
+
+		default:
+			kind = common.TriviaSkipped
+			for j < len(chars) && chars[j].Class&(common.CharWS|common.CharComment) == 0 {
+				j++
+			}
+		}
+
+		result = append(result, common.Trivia{
+			Kind: kind,
+			Text: charText(chars[i:j]),
+			Loc:  chars[i].Loc.ThruEnd(chars[j-1].Loc),
+		})
+		i = j
+	}
+
+	return result
+}
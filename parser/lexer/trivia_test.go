@@ -0,0 +1,440 @@
+// Copyright (c) 2019 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package lexer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hydralang/hydra/parser/common"
+	"github.com/hydralang/hydra/parser/scanner"
+)
+
+func makeRecorder(src string) *recorder {
+	s, _ := scanner.Scan(makeOptions(strings.NewReader(src)))
+	return &recorder{s: s}
+}
+
+func recordAll(src string) *recorder {
+	r := makeRecorder(src)
+	for r.Next().C != common.EOF {
+	}
+	return r
+}
+
+func TestRecorderImplementsScanner(t *testing.T) {
+	assert.Implements(t, (*common.Scanner)(nil), &recorder{})
+}
+
+func TestRecorderNext(t *testing.T) {
+	a := assert.New(t)
+	r := makeRecorder("ab")
+
+	result := r.Next()
+
+	a.Equal('a', result.C)
+	a.Equal([]common.AugChar{result}, r.log)
+}
+
+func TestRecorderNextEOF(t *testing.T) {
+	a := assert.New(t)
+	r := makeRecorder("")
+
+	result := r.Next()
+
+	a.Equal(common.EOF, result.C)
+	a.Nil(r.log)
+}
+
+func TestRecorderNextZeroWidth(t *testing.T) {
+	a := assert.New(t)
+	r := makeRecorder("a\f")
+	r.Next()
+
+	result := r.Next()
+
+	a.Equal('\f', result.C)
+	a.Equal(common.Location{
+		File: "file",
+		B:    common.FilePos{L: 1, C: 1},
+		E:    common.FilePos{L: 1, C: 2},
+	}, result.Loc)
+	a.Len(r.log, 2)
+	a.Equal(common.Location{
+		File: "file",
+		B:    common.FilePos{L: 1, C: 2},
+		E:    common.FilePos{L: 1, C: 2},
+	}, r.log[1].Loc)
+}
+
+func TestRecorderPush(t *testing.T) {
+	a := assert.New(t)
+	r := makeRecorder("ab")
+	ch := r.Next()
+
+	r.Push(ch)
+
+	a.Equal(ch, r.Next())
+	a.Len(r.log, 1)
+}
+
+func TestLexerAttachDisabled(t *testing.T) {
+	a := assert.New(t)
+	tok := &common.Token{Sym: common.TokIdent}
+	l := &lexer{}
+
+	l.attach(tok)
+
+	a.Nil(l.pend)
+}
+
+func TestLexerAttachSynthetic(t *testing.T) {
+	a := assert.New(t)
+	tok := &common.Token{Sym: common.TokIndent}
+	l := &lexer{rec: &recorder{}}
+
+	l.attach(tok)
+
+	a.Nil(l.pend)
+}
+
+func TestLexerAttachText(t *testing.T) {
+	a := assert.New(t)
+	r := recordAll(" ab  c")
+	tok := &common.Token{
+		Sym: common.TokIdent,
+		Loc: common.Location{
+			File: "file",
+			B:    common.FilePos{L: 1, C: 2},
+			E:    common.FilePos{L: 1, C: 4},
+		},
+	}
+	l := &lexer{rec: r}
+
+	l.attach(tok)
+
+	a.Nil(l.pend)
+	a.Equal("ab", tok.Text)
+	a.Nil(tok.Lead)
+	a.Nil(tok.Trail)
+	a.Equal("  c", charText(r.log))
+}
+
+func TestLexerAttachFirst(t *testing.T) {
+	a := assert.New(t)
+	tok := &common.Token{Sym: common.TokIdent}
+	l := &lexer{rec: &recorder{}, trivia: true}
+
+	l.attach(tok)
+
+	a.Equal(tok, l.pend)
+}
+
+func TestLexerAttachNext(t *testing.T) {
+	a := assert.New(t)
+	r := recordAll(" a  b")
+	pend := &common.Token{
+		Sym: common.TokIdent,
+		Loc: common.Location{
+			File: "file",
+			B:    common.FilePos{L: 1, C: 2},
+			E:    common.FilePos{L: 1, C: 3},
+		},
+	}
+	tok := &common.Token{
+		Sym: common.TokIdent,
+		Loc: common.Location{
+			File: "file",
+			B:    common.FilePos{L: 1, C: 5},
+			E:    common.FilePos{L: 1, C: 6},
+		},
+	}
+	l := &lexer{rec: r, trivia: true, pend: pend}
+
+	l.attach(tok)
+
+	a.Equal(tok, l.pend)
+	a.Equal(" a  ", pend.FullText())
+	a.Equal("a", pend.Text)
+	a.Len(r.log, 1)
+}
+
+func TestSplitToken(t *testing.T) {
+	a := assert.New(t)
+	chars := recordAll("  ab c").log
+	tok := &common.Token{
+		Sym: common.TokIdent,
+		Loc: common.Location{
+			File: "file",
+			B:    common.FilePos{L: 1, C: 3},
+			E:    common.FilePos{L: 1, C: 5},
+		},
+	}
+
+	i, j := splitToken(chars, tok)
+
+	a.Equal(2, i)
+	a.Equal(4, j)
+}
+
+func TestSplitTokenZeroWidth(t *testing.T) {
+	a := assert.New(t)
+	chars := recordAll("a\fb").log
+	tok := &common.Token{
+		Sym: common.TokIdent,
+		Loc: common.Location{
+			File: "file",
+			B:    common.FilePos{L: 1, C: 2},
+			E:    common.FilePos{L: 1, C: 3},
+		},
+	}
+
+	i, j := splitToken(chars, tok)
+
+	a.Equal(2, i)
+	a.Equal(3, j)
+}
+
+func TestSplitTokenLeadingSpace(t *testing.T) {
+	a := assert.New(t)
+	chars := recordAll("a  b").log
+	tok := &common.Token{
+		Sym: common.TokFStringPart,
+		Loc: common.Location{
+			File: "file",
+			B:    common.FilePos{L: 1, C: 2},
+			E:    common.FilePos{L: 1, C: 5},
+		},
+	}
+
+	i, j := splitToken(chars, tok)
+
+	a.Equal(1, i)
+	a.Equal(4, j)
+}
+
+func TestLexerAttributeNewline(t *testing.T) {
+	a := assert.New(t)
+	r := recordAll("\n  a")
+	tok := &common.Token{
+		Sym: common.TokNewline,
+		Loc: common.Location{
+			File: "file",
+			B:    common.FilePos{L: 1, C: 1},
+			E:    common.FilePos{L: 2, C: 1},
+		},
+	}
+	l := &lexer{rec: r}
+
+	l.attribute(tok, nil)
+
+	a.Nil(tok.Lead)
+	a.Equal("\n", tok.Text)
+	a.Nil(tok.Trail)
+	a.Len(r.log, 3)
+}
+
+func TestLexerAttributeContinuation(t *testing.T) {
+	a := assert.New(t)
+	r := recordAll("a \\\n b")
+	tok := &common.Token{
+		Sym: common.TokIdent,
+		Loc: common.Location{
+			File: "file",
+			B:    common.FilePos{L: 1, C: 1},
+			E:    common.FilePos{L: 1, C: 2},
+		},
+	}
+	next := &common.Token{
+		Sym: common.TokIdent,
+		Loc: common.Location{
+			File: "file",
+			B:    common.FilePos{L: 2, C: 2},
+			E:    common.FilePos{L: 2, C: 3},
+		},
+	}
+	l := &lexer{rec: r}
+
+	l.attribute(tok, next)
+
+	a.Equal("a", tok.Text)
+	a.Equal([]uint8{common.TriviaSpace, common.TriviaContinuation}, []uint8{tok.Trail[0].Kind, tok.Trail[1].Kind})
+	a.Len(r.log, 2)
+}
+
+func TestLexerAttributeEOF(t *testing.T) {
+	a := assert.New(t)
+	r := recordAll("# c\n")
+	tok := &common.Token{
+		Sym: common.TokEOF,
+		Loc: common.Location{
+			File: "file",
+			B:    common.FilePos{L: 2, C: 1},
+			E:    common.FilePos{L: 2, C: 1},
+		},
+	}
+	l := &lexer{rec: r}
+
+	l.attribute(tok, nil)
+
+	a.Equal("# c\n", tok.FullText())
+	a.Equal("", tok.Text)
+	a.Len(r.log, 0)
+}
+
+func TestCharText(t *testing.T) {
+	a := assert.New(t)
+	chars := recordAll("a\r\nb").log
+
+	result := charText(chars)
+
+	a.Equal("a\r\nb", result)
+}
+
+func TestSplitTrivia(t *testing.T) {
+	a := assert.New(t)
+	chars := recordAll(" \t# c \n\\\nxy z").log
+
+	result := splitTrivia(chars)
+
+	a.Equal([]common.Trivia{
+		{
+			Kind: common.TriviaSpace,
+			Text: " \t",
+			Loc:  common.Location{File: "file", B: common.FilePos{L: 1, C: 1}, E: common.FilePos{L: 1, C: 9}},
+		},
+		{
+			Kind: common.TriviaComment,
+			Text: "# c ",
+			Loc:  common.Location{File: "file", B: common.FilePos{L: 1, C: 9}, E: common.FilePos{L: 1, C: 13}},
+		},
+		{
+			Kind: common.TriviaNewline,
+			Text: "\n",
+			Loc:  common.Location{File: "file", B: common.FilePos{L: 1, C: 13}, E: common.FilePos{L: 2, C: 1}},
+		},
+		{
+			Kind: common.TriviaContinuation,
+			Text: "\\\n",
+			Loc:  common.Location{File: "file", B: common.FilePos{L: 2, C: 1}, E: common.FilePos{L: 3, C: 1}},
+		},
+		{
+			Kind: common.TriviaSkipped,
+			Text: "xy",
+			Loc:  common.Location{File: "file", B: common.FilePos{L: 3, C: 1}, E: common.FilePos{L: 3, C: 3}},
+		},
+		{
+			Kind: common.TriviaSpace,
+			Text: " ",
+			Loc:  common.Location{File: "file", B: common.FilePos{L: 3, C: 3}, E: common.FilePos{L: 3, C: 4}},
+		},
+		{
+			Kind: common.TriviaSkipped,
+			Text: "z",
+			Loc:  common.Location{File: "file", B: common.FilePos{L: 3, C: 4}, E: common.FilePos{L: 3, C: 5}},
+		},
+	}, result)
+}
+
+func TestSplitTriviaLenientContinuation(t *testing.T) {
+	a := assert.New(t)
+	chars := recordAll("\\  # c\nx").log
+
+	result := splitTrivia(chars)
+
+	a.Equal(common.Trivia{
+		Kind: common.TriviaContinuation,
+		Text: "\\  # c\n",
+		Loc:  common.Location{File: "file", B: common.FilePos{L: 1, C: 1}, E: common.FilePos{L: 2, C: 1}},
+	}, result[0])
+}
+
+func TestSplitTriviaEmpty(t *testing.T) {
+	a := assert.New(t)
+
+	result := splitTrivia(nil)
+
+	a.Nil(result)
+}
+
+func TestContLen(t *testing.T) {
+	a := assert.New(t)
+	chars := recordAll("\\\nx").log
+
+	result := contLen(chars)
+
+	a.Equal(2, result)
+}
+
+func TestContLenComment(t *testing.T) {
+	a := assert.New(t)
+	chars := recordAll("\\ \t# c\nx").log
+
+	result := contLen(chars)
+
+	a.Equal(7, result)
+}
+
+func TestContLenDangling(t *testing.T) {
+	a := assert.New(t)
+	chars := recordAll("\\ x\n").log
+
+	result := contLen(chars)
+
+	a.Equal(0, result)
+}
+
+func TestContLenNoBackslash(t *testing.T) {
+	a := assert.New(t)
+	chars := recordAll(" \n").log
+
+	result := contLen(chars)
+
+	a.Equal(0, result)
+}
+
+func TestContLenEmpty(t *testing.T) {
+	a := assert.New(t)
+
+	result := contLen(nil)
+
+	a.Equal(0, result)
+}
+
+func TestLexerTriviaLenientContinuation(t *testing.T) {
+	a := assert.New(t)
+	src := "a = b \\  # why\n  + c \\ \n  + d\n"
+	opts := makeContOptions(src, common.ContLenient, nil)
+	opts.Warn = nil
+	opts.Trivia = true
+	l, _ := Lex(opts, nil)
+
+	text := &strings.Builder{}
+	toks := []*common.Token{}
+	for tok := l.Next(); tok != nil; tok = l.Next() {
+		text.WriteString(tok.FullText())
+		toks = append(toks, tok)
+	}
+
+	a.Equal(src, text.String())
+	a.Equal("b", toks[2].Text)
+	a.Equal(common.TriviaContinuation, toks[2].Trail[1].Kind)
+	a.Equal("\\  # why\n", toks[2].Trail[1].Text)
+	a.Equal("c", toks[4].Text)
+	a.Equal(common.TriviaContinuation, toks[4].Trail[1].Kind)
+	a.Equal("\\ \n", toks[4].Trail[1].Text)
+}
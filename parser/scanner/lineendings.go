@@ -40,6 +40,7 @@ func (s *scanner) leUnknown(ch rune) rune {
 	} else if ch == '\n' {
 		// We're in both style
 		s.le = s.leBoth
+		s.raw = "\r\n"
 		return '\n'
 	}
 
@@ -83,6 +84,7 @@ func (s *scanner) leBoth(ch rune) rune {
 
 		// If next is a newline, that's what we'll return
 		if ch == '\n' {
+			s.raw = "\r\n"
 			return '\n'
 		}
 
@@ -90,6 +90,7 @@ func TestScannerLeUnknownBoth(t *testing.T) {
 	a.Equal('\n', result)
 	a.Nil(s.err)
 	a.Equal(common.Err, s.pushed)
+	a.Equal("\r\n", s.raw)
 	testutils.AssertPtrEqual(a, s.leBoth, s.le)
 }
 
@@ -190,6 +191,7 @@ func TestScannerLeBothCarriageNewline(t *testing.T) {
 	a.Equal('\n', result)
 	a.Nil(s.err)
 	a.Equal(common.Err, s.pushed)
+	a.Equal("\r\n", s.raw)
 }
 
 func TestScannerLeBothCarriageOther(t *testing.T) {
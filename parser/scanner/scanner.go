@@ -61,6 +61,7 @@ type scanner struct {
 	end    int               // The end of the buffer
 	le     lineEnding        // The processor for line ending style
 	pushed rune              // One char pushback for line endings
+	raw    string            // Source text of a combined line ending
 	err    error             // Deferred error
 	loc    common.Location   // Location of head of read buffer
 	queue  list.List         // List of pushed-back chars
@@ -74,6 +75,12 @@ func Scan(opts *common.Options) (common.Scanner, error) {
 		return nil, err
 	}
 
+	// Determine the starting position
+	start := opts.Start
+	if start.L <= 0 {
+		start = common.FilePos{L: 1, C: 1}
+	}
+
 	// Construct our scanner object
 	s := &scanner{
 		source: transform.NewReader(opts.Source, enc.NewDecoder()),
@@ -81,8 +88,8 @@ func Scan(opts *common.Options) (common.Scanner, error) {
 		pushed: common.Err, // sentinel for nothing there
 		loc: common.Location{
 			File: opts.Filename,
-			B:    common.FilePos{L: 1, C: 1},
-			E:    common.FilePos{L: 1, C: 1},
+			B:    start,
+			E:    start,
 		},
 	}
 
@@ -200,6 +207,7 @@ func (s *scanner) Next() common.AugChar {
 	// OK, get the next character to process
 	var ch rune
 	var err error
+	var raw string
 	if s.pushed != common.Err {
 		ch = s.pushed
 		s.pushed = common.Err
@@ -209,9 +217,17 @@ func (s *scanner) Next() common.AugChar {
 	} else {
 		ch, err = s.nextChar()
 
-		// Handle line endings
+		// Handle line endings, saving the source text if
+		// it was translated
 		if ch == '\r' || ch == '\n' {
+			orig := ch
 			ch = s.le(ch)
+			if s.raw != "" {
+				raw = s.raw
+				s.raw = ""
+			} else if ch != orig {
+				raw = string(orig)
+			}
 		}
 	}
 
@@ -219,5 +235,7 @@ func (s *scanner) Next() common.AugChar {
 	s.opts.Advance(ch, &s.loc)
 
 	// Classify the character and return it
-	return s.opts.Classify(ch, s.loc, err)
+	aug := s.opts.Classify(ch, s.loc, err)
+	aug.Raw = raw
+	return aug
 }
@@ -194,6 +194,29 @@ func TestScanISO8859_1(t *testing.T) {
 	a.Equal([]byte{69, 108, 78, 105, 195, 177, 111}, buf[:n])
 }
 
+func TestScanStart(t *testing.T) {
+	a := assert.New(t)
+	opts := makeOptions(strings.NewReader("ab"))
+	opts.Start = common.FilePos{L: 5, C: 1}
+
+	result, err := Scan(opts)
+
+	a.NoError(err)
+	s := result.(*scanner)
+	a.Equal(common.Location{
+		File: "file",
+		B:    common.FilePos{L: 5, C: 1},
+		E:    common.FilePos{L: 5, C: 1},
+	}, s.loc)
+	ch := s.Next()
+	a.Equal('a', ch.C)
+	a.Equal(common.Location{
+		File: "file",
+		B:    common.FilePos{L: 5, C: 1},
+		E:    common.FilePos{L: 5, C: 2},
+	}, ch.Loc)
+}
+
 func TestScanNoSuchEncoding(t *testing.T) {
 	a := assert.New(t)
 	opts := makeOptions(bytes.NewReader([]byte{69, 108, 78, 105, 110, 204, 131, 111}))
@@ -640,6 +663,38 @@ func TestScannerNextNewline(t *testing.T) {
 	}, s.loc)
 }
 
+func TestScannerNextCarriageNewline(t *testing.T) {
+	a := assert.New(t)
+	s := &scanner{
+		opts:   makeOptions(bytes.NewReader([]byte{})),
+		pushed: common.Err,
+		end:    5,
+		loc: common.Location{
+			File: "filename",
+			B:    common.FilePos{L: 1, C: 3},
+			E:    common.FilePos{L: 1, C: 4},
+		},
+	}
+	copy(s.buf[0:], []byte{'\r', '\n', 'e', 's', 't', utf8.RuneSelf})
+	s.le = s.leUnknown
+
+	ch := s.Next()
+
+	a.Equal(common.AugChar{
+		C:     '\n',
+		Class: common.CharWS | common.CharNL,
+		Loc: common.Location{
+			File: "filename",
+			B:    common.FilePos{L: 1, C: 4},
+			E:    common.FilePos{L: 2, C: 1},
+		},
+		Val: nil,
+		Raw: "\r\n",
+	}, ch)
+	a.Equal([]byte("est"), s.buf[s.pos:s.end])
+	a.Equal("", s.raw)
+}
+
 func leSwap(ch rune) rune {
 	if ch == '\n' {
 		return '\r'
@@ -673,6 +728,7 @@ func TestScannerNextCarriageSwapped(t *testing.T) {
 			E:    common.FilePos{L: 2, C: 1},
 		},
 		Val: nil,
+		Raw: "\r",
 	}, ch)
 	a.Equal([]byte("est"), s.buf[s.pos:s.end])
 	a.Nil(s.err)